@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// privileges lists what the exporter needs with the current flags.
+type privileges struct {
+	// HostPaths are host filesystem paths that must be mounted.
+	HostPaths []string
+	// Files are files read from ordinary volumes (secrets, config maps,
+	// projected tokens).
+	Files []string
+	// APIPermissions are the RBAC permissions needed against the api-server.
+	APIPermissions []string
+	// Writes are the flags making the exporter push, keep or change
+	// anything beyond serving scrapes.
+	Writes []string
+}
+
+// privileges audits the flags for the privileges they require.
+func (cli *CLI) privileges() privileges {
+	p := privileges{
+		Files: []string{cli.CA},
+	}
+
+	switch {
+	case cli.NodeProxy:
+		p.APIPermissions = append(p.APIPermissions, "get nodes/proxy")
+	case cli.plainHTTP():
+		// kubelet's read-only port serves anyone.
+	case cli.TokenRequest:
+		p.APIPermissions = append(p.APIPermissions, "get nodes/stats", "create serviceaccounts/token")
+	case cli.KubeconfigCredentials:
+		p.APIPermissions = append(p.APIPermissions, "get nodes/stats")
+	default:
+		p.APIPermissions = append(p.APIPermissions, "get nodes/stats")
+		p.Files = append(p.Files, cli.TokenPath)
+	}
+
+	if cli.Kubeconfig != "" {
+		p.Files = append(p.Files, cli.Kubeconfig)
+	}
+	if cli.ClientCert != "" {
+		p.Files = append(p.Files, cli.ClientCert, cli.ClientKey)
+	}
+
+	if cli.SysfsPath != "" {
+		p.HostPaths = append(p.HostPaths, cli.SysfsPath)
+	}
+	if path, ok := strings.CutPrefix(cli.CRIEndpoint, "unix://"); ok {
+		p.HostPaths = append(p.HostPaths, path)
+	}
+	if cli.CgroupPath != "" {
+		p.HostPaths = append(p.HostPaths, cli.CgroupPath)
+	}
+	if cli.ExpressionsFile != "" {
+		p.Files = append(p.Files, cli.ExpressionsFile)
+	}
+	if cli.MetricOverridesFile != "" {
+		p.Files = append(p.Files, cli.MetricOverridesFile)
+	}
+	if cli.RelabelConfigFile != "" {
+		p.Files = append(p.Files, cli.RelabelConfigFile)
+	}
+	if cli.TargetsFile != "" {
+		p.Files = append(p.Files, cli.TargetsFile)
+	}
+	if cli.ScrapeNowTokenFile != "" {
+		p.Files = append(p.Files, cli.ScrapeNowTokenFile)
+	}
+	if cli.TenantHashKeyFile != "" {
+		p.Files = append(p.Files, cli.TenantHashKeyFile)
+	}
+	if cli.WebConfigFile != "" {
+		p.Files = append(p.Files, cli.WebConfigFile)
+	}
+	for _, file := range []string{cli.RemoteWriteBearerTokenFile, cli.RemoteWritePasswordFile, cli.RemoteWriteCA, cli.RemoteWriteCert, cli.RemoteWriteKey} {
+		if file != "" {
+			p.Files = append(p.Files, file)
+		}
+	}
+
+	if cli.LookUpHostname {
+		p.APIPermissions = append(p.APIPermissions, "get nodes")
+	}
+	switch {
+	case cli.DiscoverNodes:
+		p.APIPermissions = append(p.APIPermissions, "list/watch nodes")
+	case cli.APIEnrichment:
+		p.APIPermissions = append(p.APIPermissions, "list/watch nodes", "list/watch pods")
+	}
+	if (cli.ResourceRatios || cli.PodInfo) && !cli.APIEnrichment {
+		p.APIPermissions = append(p.APIPermissions, "list/watch pods")
+	}
+	if cli.Cadvisor || cli.ResourceMetrics {
+		p.APIPermissions = append(p.APIPermissions, "get nodes/metrics")
+	}
+	if cli.ImageGC {
+		p.APIPermissions = append(p.APIPermissions, "get nodes/proxy")
+	}
+	if cli.ScrapeConfig != "" {
+		p.APIPermissions = append(p.APIPermissions, "get scrapeconfigs", "update scrapeconfigs/status")
+	}
+
+	if cli.UsageHistory > 0 {
+		p.Writes = append(p.Writes, "--usage-history")
+	}
+	if cli.OTLPEndpoint != "" {
+		p.Writes = append(p.Writes, "--otlp.endpoint")
+	}
+	if cli.RemoteWriteURL != "" {
+		p.Writes = append(p.Writes, "--remote-write.url")
+	}
+	if cli.ScrapeNowTokenFile != "" {
+		p.Writes = append(p.Writes, "--scrape-now-token-file")
+	}
+	if cli.ScrapeConfig != "" {
+		p.Writes = append(p.Writes, "--scrape-config")
+	}
+
+	return p
+}
+
+// checkRestricted fails if the flags need privileges unavailable under the
+// restricted PodSecurity profile.
+func (p privileges) checkRestricted() error {
+	if len(p.HostPaths) > 0 {
+		return fmt.Errorf("host paths %v can't be mounted under the restricted profile", p.HostPaths)
+	}
+
+	return nil
+}
+
+// checkReadOnly fails if the flags make the exporter do more than read
+// kubelet and serve scrapes.
+func (p privileges) checkReadOnly() error {
+	if len(p.Writes) > 0 {
+		return fmt.Errorf("%v push, keep or change state beyond serving scrapes", p.Writes)
+	}
+
+	return nil
+}
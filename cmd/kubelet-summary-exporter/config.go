@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/alecthomas/kong"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"sigs.k8s.io/yaml"
+)
+
+// configFileEnv names the config file like --config-file does.
+const configFileEnv = "CONFIG_FILE"
+
+// parse parses args over the config file and the environment.
+func parse(args []string) (*CLI, *kong.Kong, *kong.Context, error) {
+	// A config file named by the environment is loaded up front, kong only
+	// loads --config-file when it is on the command line.
+	var paths []string
+	if path := os.Getenv(configFileEnv); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", configFileEnv, err)
+		}
+		paths = append(paths, path)
+	}
+
+	cli := &CLI{}
+	parser, err := kong.New(cli, kong.Configuration(loadConfig, paths...))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	kctx, err := parser.Parse(args)
+	return cli, parser, kctx, err
+}
+
+// loadConfig reads a YAML config file keyed by flag names, either as
+// written on the command line, in snake_case or in camelCase, with dotted
+// names optionally nested. Flags set on the command line or through their
+// environment variable override the file.
+func loadConfig(r io.Reader) (kong.Resolver, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	return configResolver(values), nil
+}
+
+type configResolver map[string]interface{}
+
+// Validate rejects keys that match no flag, so typos don't go unnoticed.
+func (c configResolver) Validate(app *kong.Application) error {
+	var names []string
+	var visit func(node *kong.Node)
+	visit = func(node *kong.Node) {
+		for _, flag := range node.Flags {
+			names = append(names, flag.Name)
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(app.Node)
+
+	if unknown := unknownKeys(c, names, ""); len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (c configResolver) Resolve(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (interface{}, error) {
+	if flag.Env != "" && os.Getenv(flag.Env) != "" {
+		return nil, nil
+	}
+
+	value, _ := lookup(c, flag.Name)
+	return value, nil
+}
+
+// lookup finds the value of the flag called name.
+func lookup(values map[string]interface{}, name string) (interface{}, bool) {
+	for _, key := range configKeys(name) {
+		if value, ok := values[key]; ok {
+			return value, true
+		}
+	}
+
+	if first, rest, ok := strings.Cut(name, "."); ok {
+		for _, key := range configKeys(first) {
+			if nested, ok := values[key].(map[string]interface{}); ok {
+				return lookup(nested, rest)
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// unknownKeys returns the keys of values, prefixed with prefix, that are
+// not looked up for any of the flags called names.
+func unknownKeys(values map[string]interface{}, names []string, prefix string) []string {
+	var unknown []string
+	for key, value := range values {
+		matched := false
+		var nestedNames []string
+		for _, name := range names {
+			if contains(configKeys(name), key) {
+				matched = true
+				break
+			}
+			if first, rest, ok := strings.Cut(name, "."); ok && contains(configKeys(first), key) {
+				nestedNames = append(nestedNames, rest)
+			}
+		}
+
+		nested, isMap := value.(map[string]interface{})
+		switch {
+		case matched:
+		case len(nestedNames) > 0 && isMap:
+			unknown = append(unknown, unknownKeys(nested, nestedNames, prefix+key+".")...)
+		default:
+			unknown = append(unknown, prefix+key)
+		}
+	}
+	return unknown
+}
+
+// configKeys returns the keys a flag can be set by: its name, in snake_case
+// and in camelCase.
+func configKeys(name string) []string {
+	camel := strings.Split(name, "-")
+	for i := 1; i < len(camel); i++ {
+		if camel[i] != "" {
+			camel[i] = strings.ToUpper(camel[i][:1]) + camel[i][1:]
+		}
+	}
+
+	return []string{name, strings.ReplaceAll(name, "-", "_"), strings.Join(camel, "")}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadError stops serving to serve again with cli.
+type reloadError struct {
+	cli *CLI
+}
+
+func (reloadError) Error() string {
+	return "configuration reloaded"
+}
+
+// reloadOnSIGHUP returns a run group actor that re-reads the configuration on
+// SIGHUP and, once it parses, stops the group with a reloadError. A
+// configuration that doesn't parse is logged and the current one kept, as is
+// one that fails to serve.
+func reloadOnSIGHUP(ctx context.Context, logger logging.Logger) (func() error, func(error)) {
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	return func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-signals:
+				}
+
+				cli, _, _, err := parse(os.Args[1:])
+				if err != nil {
+					logger.Error("failed to parse reloaded configuration, keeping the current one", "error", err)
+					continue
+				}
+
+				return reloadError{cli: cli}
+			}
+		}, func(error) {
+			signal.Stop(signals)
+			cancel()
+		}
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/dashboard"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+type DashboardCmd struct {
+	Title string `help:"Dashboard title" default:"Kubelet summary"`
+}
+
+// Run prints a Grafana dashboard for the metrics the exporter would serve
+// with the current flags.
+func (d *DashboardCmd) Run(cli *CLI) error {
+	opts, err := cli.scraperOptions()
+	if err != nil {
+		return err
+	}
+	s := scraper.NewScraper(logging.Nop(), cli.NodeHost, cli.TokenPath, cli.Timeout, opts...)
+
+	out, err := dashboard.Generate(d.Title, s.Metrics()).JSON()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, string(out))
+	return err
+}
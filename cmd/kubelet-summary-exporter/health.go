@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"context"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/auth"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/server"
+)
+
+// readinessChecks returns the checks of /readyz: the token file loads and,
+// scraping a single kubelet, kubelet answers. A central exporter stays ready
+// while some of its kubelets are down.
+func (cli *CLI) readinessChecks(collector *scraper.Scraper, tokenFile *auth.TokenFile) []server.ReadinessCheck {
+	var checks []server.ReadinessCheck
+	if tokenFile != nil {
+		checks = append(checks, server.ReadinessCheck{Name: "token", Check: func(context.Context) error {
+			return tokenFile.Check()
+		}})
+	}
+	if !cli.central() {
+		checks = append(checks, server.ReadinessCheck{Name: "kubelet", Check: func(ctx context.Context) error {
+			return collector.CheckKubelet(ctx, cli.ReadinessWindow)
+		}})
+	}
+
+	return checks
+}
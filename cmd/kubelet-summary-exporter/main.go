@@ -8,116 +8,1173 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/oklog/run"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/auth"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/cardinality"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/clientmetrics"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/cri"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/dcgm"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/enrichment"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/merge"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/nodeproxy"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/otlp"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/psi"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/remotewrite"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scrapeconfig"
 	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/server"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/targets"
 	"github.com/salesforce/kubelet-summary-exporter/pkg/utils"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/version"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/webconfig"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"k8s.io/client-go/dynamic"
 )
 
 type CLI struct {
-	PromListen     string        `help:"Address to listen for for Prometheus metrics" default:":9091"`
+	ConfigFile kong.ConfigFlag `placeholder:"STRING" help:"YAML file setting flags by name, overridden by the command line and environment variables, re-read on SIGHUP" env:"CONFIG_FILE"`
+
+	PromListen     string        `help:"Address to listen for for Prometheus metrics, disabled when empty if metrics are pushed" default:":9091"`
+	TrustedProxies []string      `help:"Addresses or CIDRs of reverse proxies whose X-Forwarded-For headers are trusted" env:"TRUSTED_PROXIES"`
 	NodeHost       string        `help:"Address to request kubelet's stats/summary from" env:"NODE_HOST"`
+	TargetsFile    string        `help:"Prometheus file_sd file listing kubelets to scrape instead of --node-host" env:"TARGETS_FILE" type:"existingfile"`
+	DiscoverNodes  bool          `help:"Scrape the kubelet of every node, discovered through the api-server, instead of --node-host (assumes in cluster config or --kubeconfig)" env:"DISCOVER_NODES" default:"false"`
+	TargetsRefresh time.Duration `help:"How often targets are re-read from --targets-file or the discovered nodes" env:"TARGETS_REFRESH" default:"30s"`
 	Insecure       bool          `help:"Don't validate certificates" env:"INSECURE" default:"false"`
 	CA             string        `help:"Certificate location" env:"CA_CRT"`
+	VerifyKubelet  bool          `help:"Verify kubelet's serving certificate against --ca instead of skipping verification" env:"VERIFY_KUBELET" default:"false"`
+	ClientCert     string        `help:"Client certificate presented to kubelet" env:"CLIENT_CERT"`
+	ClientKey      string        `help:"Key of the client certificate presented to kubelet" env:"CLIENT_KEY"`
+	TLSServerName  string        `help:"Name to verify kubelet's serving certificate against, it is requested by IP otherwise" env:"TLS_SERVER_NAME"`
 	TokenPath      string        `help:"Token location" env:"TOKEN"`
+	TokenRefresh   time.Duration `help:"How often the token file is re-read in case a change was missed" env:"TOKEN_REFRESH" default:"1m"`
+	TokenRequest   bool          `help:"Request kubelet tokens through the TokenRequest API instead of reading --token-path (assumes in cluster config or --kubeconfig)" env:"TOKEN_REQUEST" default:"false"`
+	ServiceAccount string        `help:"Service account to request tokens for" env:"SERVICE_ACCOUNT" default:"kubelet-summary-exporter"`
+	Namespace      string        `name:"service-account-namespace" help:"Namespace of the service account to request tokens for" env:"POD_NAMESPACE"`
+	Restricted     bool          `help:"Refuse options needing host filesystem access so the exporter can run under the restricted PodSecurity profile, implies --token-request" env:"RESTRICTED" default:"false"`
+	ReadOnly       bool          `help:"Refuse options that push, keep or change anything beyond serving scrapes, the record command and scrape --output" env:"READ_ONLY" default:"false"`
 	Timeout        time.Duration `help:"Timeout for requests" env:"TIMEOUT" default:"5s"`
-	LookUpHostname bool          `help:"Use api-server to deterimine hostname (assumes in cluster config)" env:"LOOK_UP_HOSTNAME" default:"true"`
+	HedgeDelay     time.Duration `help:"Send a second stats summary request when kubelet hasn't answered within this delay, 0 disables" env:"HEDGE_DELAY" default:"0s"`
+	MaxScrapeGap   time.Duration `help:"Restart derived rates after this long without scrapes or a clock jump as large, 0 disables" env:"MAX_SCRAPE_GAP" default:"5m"`
+	LookUpHostname bool          `help:"Use api-server to deterimine hostname (assumes in cluster config or --kubeconfig)" env:"LOOK_UP_HOSTNAME" default:"true"`
+
+	ScrapeTimeoutOffset time.Duration `help:"Bound kubelet requests of /metrics by Prometheus' scrape timeout less this offset, so it gets a response before giving up" env:"SCRAPE_TIMEOUT_OFFSET" default:"500ms"`
+	ShutdownGracePeriod time.Duration `help:"How long scrapes in flight on SIGTERM or a reload may take to finish before their connections are closed" env:"SHUTDOWN_GRACE_PERIOD" default:"5s"`
+	ReadinessWindow     time.Duration `help:"Report ready on /readyz while kubelet's summary was fetched within this long, fetching it on the probe otherwise" env:"READINESS_WINDOW" default:"1m"`
+	RuntimeMetrics      bool          `help:"Export the Go runtime and process metrics of the exporter" env:"RUNTIME_METRICS" default:"false"`
+
+	WebConfigFile      string   `name:"web.config.file" help:"Web config file in exporter-toolkit's format enabling TLS, client certificate or basic auth and HTTP/2 on the Prometheus and tenant listeners" env:"WEB_CONFIG_FILE" type:"existingfile"`
+	WebListenAddresses []string `name:"web.listen-address" help:"Further addresses to listen for Prometheus metrics on, like --prom-listen" env:"WEB_LISTEN_ADDRESS"`
+	WebSystemdSocket   bool     `name:"web.systemd-socket" help:"Listen for Prometheus metrics on the sockets systemd activated the exporter with instead of --prom-listen" env:"WEB_SYSTEMD_SOCKET" default:"false"`
+
+	ShardCount int `name:"shard.count" help:"Replicas sharing the kubelets scraped from --targets-file or --discover-nodes, each scraping those whose node name hashes to its shard" env:"SHARD_COUNT" default:"1"`
+	ShardIndex int `name:"shard.index" help:"Shard of this replica, the StatefulSet ordinal ending its hostname when negative" env:"SHARD_INDEX" default:"-1"`
+
+	RetryMaxAttempts int           `name:"retry.max-attempts" help:"Stats summary requests per scrape when they fail transiently, 1 disables retries" env:"RETRY_MAX_ATTEMPTS" default:"1"`
+	RetryBackoff     time.Duration `name:"retry.backoff" help:"Delay before the first retry, doubled for every following one" env:"RETRY_BACKOFF" default:"100ms"`
+	RetryMaxBackoff  time.Duration `name:"retry.max-backoff" help:"Longest delay between retries" env:"RETRY_MAX_BACKOFF" default:"1s"`
+	RetryJitter      float64       `name:"retry.jitter" help:"Share of the delay between retries randomized, between 0 and 1" env:"RETRY_JITTER" default:"0.2"`
+
+	IdleConns           int           `help:"Idle connections to kubelet kept open between scrapes" env:"IDLE_CONNS" default:"2"`
+	IdleTimeout         time.Duration `help:"How long idle connections to kubelet are kept open" env:"IDLE_TIMEOUT" default:"90s"`
+	TLSHandshakeTimeout time.Duration `help:"Timeout for TLS handshakes with kubelet" env:"TLS_HANDSHAKE_TIMEOUT" default:"10s"`
+	Compression         bool          `help:"Ask kubelet for gzip-compressed summaries and compress /metrics for scrapers accepting gzip" env:"COMPRESSION" negatable:"" default:"true"`
+	PodWorkers          int           `help:"Pods whose metrics are built at once, which shortens scrapes of nodes with hundreds of pods given spare CPUs" env:"POD_WORKERS" default:"1"`
+
+	ScrapeInterval time.Duration `help:"Scrape kubelet on this interval and serve the last successful scrape from memory, 0 scrapes kubelet on every request; targets can set their own with __scrape_interval__" env:"SCRAPE_INTERVAL" default:"0s"`
+	CacheMaxAge    time.Duration `help:"How long the last successful scrape is served while scrapes fail, 0 serves it indefinitely" env:"CACHE_MAX_AGE" default:"5m"`
+
+	ScrapeNowTokenFile string        `help:"File holding the bearer token that authorizes on demand scrapes at /-/scrape-now, which is disabled without it" env:"SCRAPE_NOW_TOKEN_FILE" type:"existingfile"`
+	ScrapeNowInterval  time.Duration `help:"Minimum time between on demand scrapes of a target" env:"SCRAPE_NOW_INTERVAL" default:"10s"`
+
+	Probe        bool   `help:"Serve /probe?target=<node>, scraping a kubelet on demand in the style of the blackbox exporter" env:"PROBE" default:"false"`
+	ProbeTargets string `help:"Regular expression of other node names and addresses /probe may scrape; they are sent the kubelet token" env:"PROBE_TARGETS"`
+
+	TenantListen      string   `help:"Address to serve metrics redacted for tenants on, disabled when empty" env:"TENANT_LISTEN"`
+	TenantRedaction   string   `help:"How labels of tenant metrics are redacted: hash their values or drop the series" env:"TENANT_REDACTION" enum:"hash,drop" default:"hash"`
+	TenantLabels      []string `help:"Labels redacted from tenant metrics" env:"TENANT_LABELS" default:"namespace,pod"`
+	TenantHashKeyFile string   `help:"File holding the key of hashed tenant label values, random on every start when unset" env:"TENANT_HASH_KEY_FILE" type:"existingfile"`
+
+	OTLPEndpoint  string            `name:"otlp.endpoint" help:"OTLP/HTTP endpoint metrics are pushed to as JSON, e.g. http://otel-collector:4318/v1/metrics; disabled when empty" env:"OTLP_ENDPOINT"`
+	OTLPInterval  time.Duration     `name:"otlp.interval" help:"Time between pushes to --otlp.endpoint" env:"OTLP_INTERVAL" default:"30s"`
+	OTLPHeaders   map[string]string `name:"otlp.headers" help:"Headers of pushes to --otlp.endpoint, e.g. for authentication" env:"OTLP_HEADERS"`
+	OTLPCluster   string            `name:"otlp.cluster" help:"Cluster name pushed as the k8s.cluster.name resource attribute" env:"OTLP_CLUSTER"`
+	OTLPSeriesTTL time.Duration     `name:"otlp.series-ttl" help:"How long series missing from the exported metrics, e.g. after a failed scrape, are still pushed to --otlp.endpoint with their last value before they expire, 0 expires them at once" env:"OTLP_SERIES_TTL" default:"0s"`
+
+	RemoteWriteURL             string        `name:"remote-write.url" help:"Prometheus remote_write endpoint metrics are pushed to, e.g. https://prometheus.example.com/api/v1/write; disabled when empty" env:"REMOTE_WRITE_URL"`
+	RemoteWriteInterval        time.Duration `name:"remote-write.interval" help:"Time between pushes to --remote-write.url" env:"REMOTE_WRITE_INTERVAL" default:"30s"`
+	RemoteWriteSeriesTTL       time.Duration `name:"remote-write.series-ttl" help:"How long series missing from the exported metrics, e.g. after a failed scrape, are still pushed to --remote-write.url with their last value before they expire, 0 expires them at once" env:"REMOTE_WRITE_SERIES_TTL" default:"0s"`
+	RemoteWriteBearerTokenFile string        `name:"remote-write.bearer-token-file" help:"File holding the bearer token of pushes to --remote-write.url" env:"REMOTE_WRITE_BEARER_TOKEN_FILE" type:"existingfile"`
+	RemoteWriteUsername        string        `name:"remote-write.username" help:"Basic auth username of pushes to --remote-write.url" env:"REMOTE_WRITE_USERNAME"`
+	RemoteWritePasswordFile    string        `name:"remote-write.password-file" help:"File holding the basic auth password of pushes to --remote-write.url" env:"REMOTE_WRITE_PASSWORD_FILE" type:"existingfile"`
+	RemoteWriteCA              string        `name:"remote-write.ca" help:"CA verifying the certificate of --remote-write.url instead of the system roots" env:"REMOTE_WRITE_CA" type:"existingfile"`
+	RemoteWriteCert            string        `name:"remote-write.cert" help:"Client certificate presented to --remote-write.url" env:"REMOTE_WRITE_CERT" type:"existingfile"`
+	RemoteWriteKey             string        `name:"remote-write.key" help:"Key of the client certificate presented to --remote-write.url" env:"REMOTE_WRITE_KEY" type:"existingfile"`
+	RemoteWriteInsecure        bool          `name:"remote-write.insecure" help:"Don't verify the certificate of --remote-write.url" env:"REMOTE_WRITE_INSECURE" default:"false"`
+
+	ScrapeConfig        string        `help:"ScrapeConfig object, as namespace/name or a name in --service-account-namespace, narrowing the pod filters, pod labels and discovered nodes (assumes in cluster config or --kubeconfig)" env:"SCRAPE_CONFIG"`
+	ScrapeConfigRefresh time.Duration `help:"How often --scrape-config is re-read" env:"SCRAPE_CONFIG_REFRESH" default:"30s"`
+
+	SampleNamespaces []string `help:"Namespaces in which only a stable subset of pods is exported" env:"SAMPLE_NAMESPACES"`
+	SampleEvery      uint32   `help:"Export only one in this many pods of sampled namespaces" env:"SAMPLE_EVERY" default:"10"`
+
+	NamespaceAggregates bool `help:"Export the CPU usage and working set of pods summed by namespace, which remain with the pod and container collectors disabled" env:"NAMESPACE_AGGREGATES" default:"false"`
+	UserDefinedMetrics  bool `help:"Export the user defined metrics of containers, whose names and labels are chosen by the workloads" env:"USER_DEFINED_METRICS" default:"false"`
+
+	IncludeNamespaces string `help:"Regular expression of namespaces whose pods are exported" env:"INCLUDE_NAMESPACES"`
+	ExcludeNamespaces string `help:"Regular expression of namespaces whose pods are not exported" env:"EXCLUDE_NAMESPACES"`
+	IncludePods       string `help:"Regular expression of pod names that are exported" env:"INCLUDE_PODS"`
+	ExcludePods       string `help:"Regular expression of pod names that are not exported" env:"EXCLUDE_PODS"`
+
+	NetworkInterfaceInclude string `name:"network.interface-include" help:"Regular expression of network interfaces whose node and pod metrics are exported" env:"NETWORK_INTERFACE_INCLUDE"`
+	NetworkInterfaceExclude string `name:"network.interface-exclude" help:"Regular expression of network interfaces whose node and pod metrics are not exported, e.g. veth.*|cali.*|lxc.*" env:"NETWORK_INTERFACE_EXCLUDE"`
+
+	KubeletScheme string `help:"Scheme of kubelet requests, http for the read-only port, over which no token is sent" env:"KUBELET_SCHEME" enum:"http,https" default:"https"`
+	KubeletPort   int    `help:"Port of kubelet, unless a target has a port of its own" env:"KUBELET_PORT" default:"10250"`
+
+	NodeProxy      bool    `help:"Request kubelet through the api-server's node proxy, for clusters where kubelet's port is firewalled from pods" env:"NODE_PROXY" default:"false"`
+	Kubeconfig     string  `help:"Kubeconfig of the api-server, for running out of cluster, the in-cluster config is used when empty" env:"KUBECONFIG" type:"existingfile"`
+	NodeProxyQPS   float32 `help:"Requests per second --node-proxy sends to the api-server" env:"NODE_PROXY_QPS" default:"5"`
+	NodeProxyBurst int     `help:"Requests --node-proxy may send to the api-server at once" env:"NODE_PROXY_BURST" default:"10"`
+
+	KubeconfigCredentials bool `help:"Authenticate to kubelet with the credentials of --kubeconfig, e.g. its exec credential plugin, instead of reading --token-path" env:"KUBECONFIG_CREDENTIALS" default:"false"`
+
+	StatsPath        string            `help:"Path of kubelet's stats summary, for kubelets behind a reverse proxy" env:"STATS_PATH" default:"/stats/summary"`
+	StatsQuery       map[string]string `help:"Extra query parameters of stats summary requests" env:"STATS_QUERY"`
+	OnlyCPUAndMemory bool              `name:"only-cpu-and-memory" help:"Request summaries without disk usage, which are much cheaper for kubelet, and leave out filesystem and volume metrics" env:"ONLY_CPU_AND_MEMORY" default:"false"`
+
+	MaxSummaryBytes int64 `help:"Fail scrapes of stats summaries larger than this many bytes, 0 disables the limit" env:"MAX_SUMMARY_BYTES" default:"0"`
+
+	MaxSeriesPerFamily int `help:"Drop the series of a metric family beyond this many per scrape, 0 disables the limit" env:"MAX_SERIES_PER_FAMILY" default:"0"`
+	MaxSeries          int `help:"Drop series beyond this many per scrape, the exporter's own metrics aside, 0 disables the limit" env:"MAX_SERIES" default:"0"`
+
+	SysfsPath string `help:"Host sysfs mount used to read node interface link speeds" env:"SYSFS_PATH"`
+	ImageGC   bool   `help:"Read image garbage collection thresholds from kubelet's configz to export image fs headroom" env:"IMAGE_GC" default:"false"`
+
+	ExpressionsFile string `help:"YAML file defining additional metrics as path expressions over the summary" env:"EXPRESSIONS_FILE" type:"existingfile"`
+
+	MetricOverridesFile string `help:"YAML file mapping metric names to a help text and constant labels replacing their defaults, e.g. to link runbooks" env:"METRIC_OVERRIDES_FILE" type:"existingfile"`
+
+	MetricPrefix      string            `help:"Prefix of the names of summary metrics, the exporter's own metrics keep theirs" env:"METRIC_PREFIX" default:"kubelet_summary"`
+	ConstLabels       map[string]string `help:"Labels added to every exported series that doesn't have them, e.g. cluster=prod;region=eu" env:"CONST_LABELS"`
+	RelabelConfigFile string            `help:"YAML file of rules like Prometheus' metric_relabel_configs applied to every exported series, e.g. to drop veth interfaces" env:"RELABEL_CONFIG_FILE" type:"existingfile"`
+
+	UsageHistory        time.Duration `help:"Keep container usage over this window to serve percentiles at /api/v1/recent-usage, 0 disables" env:"USAGE_HISTORY" default:"0s"`
+	UsageHistorySamples int           `help:"Maximum number of scrapes kept per container in the usage history" env:"USAGE_HISTORY_SAMPLES" default:"240"`
+
+	NativeHistograms bool `help:"Also expose histograms as native histograms (Prometheus 2.40+, protobuf scrapes only)" env:"NATIVE_HISTOGRAMS" default:"false"`
+	Counters         bool `help:"Expose cumulative values as counters with a _total suffix instead of gauges (the default in the next major version)" env:"COUNTERS" default:"false"`
+	Timestamps       bool `help:"Export stats with the time kubelet took them instead of the scrape time" env:"TIMESTAMPS" default:"false"`
+
+	Profile string `help:"Preset of exported metric groups: essentials (node and pods), standard (all but containers) or full; --no-collector.<group> disables more" env:"PROFILE" enum:"essentials,standard,full" default:"full"`
+
+	CollectorNode             bool `name:"collector.node" help:"Export node metrics" env:"COLLECTOR_NODE" negatable:"" default:"true"`
+	CollectorSystemContainers bool `name:"collector.system-containers" help:"Export system container metrics" env:"COLLECTOR_SYSTEM_CONTAINERS" negatable:"" default:"true"`
+	CollectorPods             bool `name:"collector.pods" help:"Export pod metrics" env:"COLLECTOR_PODS" negatable:"" default:"true"`
+	CollectorContainers       bool `name:"collector.containers" help:"Export container metrics" env:"COLLECTOR_CONTAINERS" negatable:"" default:"true"`
+	CollectorVolumes          bool `name:"collector.volumes" help:"Export pod volume metrics" env:"COLLECTOR_VOLUMES" negatable:"" default:"true"`
+	CollectorNetwork          bool `name:"collector.network" help:"Export node and pod interface metrics" env:"COLLECTOR_NETWORK" negatable:"" default:"true"`
+	CollectorAccelerators     bool `name:"collector.accelerators" help:"Export accelerator metrics" env:"COLLECTOR_ACCELERATORS" negatable:"" default:"true"`
+
+	CollectorMaxFailures int           `help:"Consecutive failures after which an optional collector is disabled" env:"COLLECTOR_MAX_FAILURES" default:"5"`
+	CollectorCooldown    time.Duration `help:"How long an optional collector stays disabled after exhausting its error budget" env:"COLLECTOR_COOLDOWN" default:"10m"`
+
+	SLOFreshnessObjective float64       `name:"slo.freshness-objective" help:"Share of intervals that must end with fresh stats, e.g. 0.99, to export error budget burn rates; 0 disables" env:"SLO_FRESHNESS_OBJECTIVE" default:"0"`
+	SLOFreshnessInterval  time.Duration `name:"slo.freshness-interval" help:"Length of the intervals of the freshness SLO, usually the scrape interval" env:"SLO_FRESHNESS_INTERVAL" default:"30s"`
+	SLOFreshnessMaxAge    time.Duration `name:"slo.freshness-max-age" help:"Age after which stats count as stale, twice the interval when 0" env:"SLO_FRESHNESS_MAX_AGE" default:"0s"`
+
+	Canary bool `help:"Check every scraped summary against invariants of healthy kubelets, e.g. non-zero capacities, and export which hold" env:"CANARY" default:"false"`
+
+	Cadvisor           bool     `help:"Merge kubelet's /metrics/cadvisor into /metrics for data the summary lacks, like CPU throttling" env:"CADVISOR" default:"false"`
+	CadvisorAllow      []string `name:"cadvisor.allow" help:"Regular expressions matching the names of the /metrics/cadvisor families kept" env:"CADVISOR_ALLOW" default:"container_cpu_cfs_.*,container_blkio_.*,container_fs_(reads|writes)_.*"`
+	CadvisorDropLabels []string `name:"cadvisor.drop-labels" help:"Labels removed from /metrics/cadvisor series, e.g. the cgroup path" env:"CADVISOR_DROP_LABELS" default:"id,name"`
+
+	ResourceMetrics               bool   `help:"Merge kubelet's /metrics/resource, which metrics-server reads, into /metrics" env:"RESOURCE_METRICS" default:"false"`
+	ResourceMetricsPrefix         string `name:"resource-metrics.prefix" help:"Prefix added to the names of /metrics/resource families, e.g. to keep them apart from those of --cadvisor" env:"RESOURCE_METRICS_PREFIX"`
+	ResourceMetricsDropDuplicates bool   `name:"resource-metrics.drop-duplicates" help:"Drop /metrics/resource families exported by the summary or --cadvisor too, instead of labeling their series with source=\"resource\"" env:"RESOURCE_METRICS_DROP_DUPLICATES" default:"false"`
+
+	DCGMURL string `name:"dcgm.url" help:"URL of the node's NVIDIA DCGM exporter, e.g. http://$HOST_IP:9400/metrics, whose GPU utilization, bandwidth and temperatures are exported as accelerator metrics" env:"DCGM_URL"`
+
+	CRIEndpoint string `name:"cri.endpoint" help:"CRI socket of the node's container runtime, e.g. unix:///run/containerd/containerd.sock, whose pod and container stats fill the fields missing from the summary" env:"CRI_ENDPOINT"`
+	CRIMode     string `name:"cri.mode" help:"Whether the runtime's stats only fill missing fields (fallback) or replace the summary's (prefer)" env:"CRI_MODE" enum:"fallback,prefer" default:"fallback"`
+
+	CgroupPath string `help:"Host cgroup v2 mount, e.g. /host/sys/fs/cgroup, whose pressure stall information is exported for the node and its pods" env:"CGROUP_PATH"`
+
+	APIEnrichment             bool     `help:"Watch the node object through the api-server to export allocatable based metrics (assumes in cluster config or --kubeconfig)" env:"API_ENRICHMENT" default:"false"`
+	SuppressPodsInMaintenance bool     `help:"Stop exporting per-pod metrics while the node is cordoned or drained (requires --api-enrichment)" env:"SUPPRESS_PODS_IN_MAINTENANCE" default:"false"`
+	NodepoolLabels            []string `help:"Node labels holding the node's pool, first match wins" env:"NODEPOOL_LABELS" default:"karpenter.sh/nodepool,eks.amazonaws.com/nodegroup,cloud.google.com/gke-nodepool,kubernetes.azure.com/agentpool"`
+	ResourceRatios            bool     `help:"Watch the node's pods through the api-server to export container usage relative to requests and limits (assumes in cluster config or --kubeconfig)" env:"RESOURCE_RATIOS" default:"false"`
+	PodInfo                   bool     `help:"Watch the node's pods through the api-server to export pod and container info metrics with their owning workload (assumes in cluster config or --kubeconfig)" env:"POD_INFO" default:"false"`
+
+	FaultLatency   time.Duration `help:"Inject latency into kubelet requests (testing only)" hidden:"" env:"FAULT_LATENCY"`
+	FaultFailRatio float64       `help:"Fail this share of kubelet requests (testing only)" hidden:"" env:"FAULT_FAIL_RATIO"`
+	FaultStale     bool          `help:"Keep serving the first kubelet response (testing only)" hidden:"" env:"FAULT_STALE"`
+
+	Serve     struct{}     `cmd:"" default:"1" help:"Serve kubelet summary metrics for Prometheus (default)"`
+	Dashboard DashboardCmd `cmd:"" help:"Print a Grafana dashboard for the exported metrics"`
+	Rules     RulesCmd     `cmd:"" help:"Print alerting rules for the exported metrics"`
+	Scrape    ScrapeCmd    `cmd:"" help:"Fetch one summary from kubelet, print its metrics and exit, failing if the fetch does"`
+	Record    RecordCmd    `cmd:"" help:"Save summaries fetched from kubelet to timestamped files"`
+	Replay    ReplayCmd    `cmd:"" help:"Serve metrics from recorded summaries, one per scrape"`
+	Version   VersionCmd   `cmd:"" help:"Print the version of the exporter"`
+}
+
+// scraperOptions returns the scraper options selected on the command line.
+func (cli *CLI) scraperOptions() ([]scraper.Option, error) {
+	disabled, err := cli.disabledGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := scraper.CheckEndpoint(cli.KubeletScheme, cli.KubeletPort); err != nil {
+		return nil, fmt.Errorf("kubelet endpoint: %w", err)
+	}
+	if cli.plainHTTP() && (cli.VerifyKubelet || cli.ClientCert != "") {
+		return nil, fmt.Errorf("--verify-kubelet and --client-cert need --kubelet-scheme=https")
+	}
+	if cli.NodeProxy && (cli.plainHTTP() || cli.VerifyKubelet || cli.ClientCert != "") {
+		return nil, fmt.Errorf("--node-proxy connects to the api-server and can't be combined with --kubelet-scheme=http, --verify-kubelet or --client-cert")
+	}
+	if cli.RetryJitter < 0 || cli.RetryJitter > 1 {
+		return nil, fmt.Errorf("--retry.jitter must be between 0 and 1")
+	}
+
+	opts := []scraper.Option{
+		scraper.WithSampling(cli.SampleNamespaces, cli.SampleEvery),
+		scraper.WithEndpoint(cli.KubeletScheme, cli.KubeletPort),
+		scraper.WithStatsPath(cli.StatsPath, cli.StatsQuery),
+		scraper.WithMaxSummaryBytes(cli.MaxSummaryBytes),
+		scraper.WithSysfsPath(cli.SysfsPath),
+		scraper.WithCollectorErrorBudget(cli.CollectorMaxFailures, cli.CollectorCooldown),
+		scraper.WithHedging(cli.HedgeDelay),
+		scraper.WithRetries(scraper.RetryOptions{
+			MaxAttempts: cli.RetryMaxAttempts,
+			Backoff:     cli.RetryBackoff,
+			MaxBackoff:  cli.RetryMaxBackoff,
+			Jitter:      cli.RetryJitter,
+		}),
+		scraper.WithGapDetection(cli.MaxScrapeGap),
+		scraper.WithTransportOptions(scraper.TransportOptions{
+			MaxIdleConns:        cli.IdleConns,
+			IdleConnTimeout:     cli.IdleTimeout,
+			TLSHandshakeTimeout: cli.TLSHandshakeTimeout,
+			DisableCompression:  !cli.Compression,
+		}),
+		scraper.WithUsageHistory(cli.UsageHistory, cli.UsageHistorySamples),
+		scraper.WithDisabledGroups(disabled),
+		scraper.WithFaults(scraper.Faults{
+			Latency:   cli.FaultLatency,
+			FailRatio: cli.FaultFailRatio,
+			Stale:     cli.FaultStale,
+		}),
+	}
+
+	if cli.VerifyKubelet {
+		tlsConfig, err := scraper.TLSOptions{
+			CAFile:     cli.CA,
+			CertFile:   cli.ClientCert,
+			KeyFile:    cli.ClientKey,
+			ServerName: cli.TLSServerName,
+		}.Config()
+		if err != nil {
+			return nil, fmt.Errorf("kubelet tls: %w", err)
+		}
+		opts = append(opts, scraper.WithTLSConfig(tlsConfig))
+	}
+
+	filter, err := cli.podFilter()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, scraper.WithPodFilter(filter))
+
+	interfaceFilter, err := cli.interfaceFilter()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, scraper.WithInterfaceFilter(interfaceFilter))
+
+	if cli.PodWorkers < 1 {
+		return nil, fmt.Errorf("--pod-workers must be positive")
+	}
+	opts = append(opts, scraper.WithPodWorkers(cli.PodWorkers))
+
+	if cli.ImageGC {
+		opts = append(opts, scraper.WithImageGC(10*time.Minute))
+	}
+
+	if cli.OnlyCPUAndMemory {
+		opts = append(opts, scraper.WithOnlyCPUAndMemory())
+	}
+
+	if cli.NamespaceAggregates {
+		opts = append(opts, scraper.WithNamespaceAggregates())
+	}
+
+	if cli.UserDefinedMetrics {
+		opts = append(opts, scraper.WithUserDefinedMetrics())
+	}
+
+	if cli.NativeHistograms {
+		opts = append(opts, scraper.WithNativeHistograms(1.1))
+	}
+
+	if err := scraper.CheckNamespace(cli.MetricPrefix); err != nil {
+		return nil, fmt.Errorf("--metric-prefix: %w", err)
+	}
+	opts = append(opts, scraper.WithNamespace(cli.MetricPrefix))
+
+	if err := checkLabelNames(cli.ConstLabels); err != nil {
+		return nil, fmt.Errorf("--const-labels: %w", err)
+	}
+
+	if cli.Counters {
+		opts = append(opts, scraper.WithCounters())
+	}
+
+	if cli.Timestamps {
+		opts = append(opts, scraper.WithTimestamps())
+	}
+
+	if cli.SLOFreshnessObjective > 0 {
+		slo := scraper.FreshnessSLO{
+			Objective: cli.SLOFreshnessObjective,
+			Interval:  cli.SLOFreshnessInterval,
+			MaxAge:    cli.SLOFreshnessMaxAge,
+		}
+		if err := slo.Validate(); err != nil {
+			return nil, err
+		}
+		opts = append(opts, scraper.WithFreshnessSLO(slo))
+	}
+
+	if cli.Canary {
+		opts = append(opts, scraper.WithCanary())
+	}
+
+	if cli.ExpressionsFile != "" {
+		data, err := os.ReadFile(cli.ExpressionsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		exprs, err := scraper.ParseExpressions(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cli.ExpressionsFile, err)
+		}
+		opts = append(opts, scraper.WithExpressions(exprs))
+	}
+
+	if cli.MetricOverridesFile != "" {
+		data, err := os.ReadFile(cli.MetricOverridesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		overrides, err := scraper.ParseMetricOverrides(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cli.MetricOverridesFile, err)
+		}
+		opts = append(opts, scraper.WithMetricOverrides(overrides))
+	}
+
+	return opts, nil
+}
+
+// podFilter compiles the pod filters, anchored like Prometheus regular
+// expressions.
+func (cli *CLI) podFilter() (scraper.PodFilter, error) {
+	var filter scraper.PodFilter
+	err := compileFilters([]filterFlag{
+		{"--include-namespaces", cli.IncludeNamespaces, &filter.IncludeNamespaces},
+		{"--exclude-namespaces", cli.ExcludeNamespaces, &filter.ExcludeNamespaces},
+		{"--include-pods", cli.IncludePods, &filter.IncludePods},
+		{"--exclude-pods", cli.ExcludePods, &filter.ExcludePods},
+	})
+	return filter, err
+}
+
+// interfaceFilter compiles the network interface filters like podFilter.
+func (cli *CLI) interfaceFilter() (scraper.InterfaceFilter, error) {
+	var filter scraper.InterfaceFilter
+	err := compileFilters([]filterFlag{
+		{"--network.interface-include", cli.NetworkInterfaceInclude, &filter.Include},
+		{"--network.interface-exclude", cli.NetworkInterfaceExclude, &filter.Exclude},
+	})
+	return filter, err
+}
+
+// filterFlag is a regular expression flag compiled into re.
+type filterFlag struct {
+	flag string
+	expr string
+	re   **regexp.Regexp
+}
+
+func compileFilters(flags []filterFlag) error {
+	for _, f := range flags {
+		if f.expr == "" {
+			continue
+		}
+
+		re, err := regexp.Compile("^(?:" + f.expr + ")$")
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.flag, err)
+		}
+		*f.re = re
+	}
+
+	return nil
+}
+
+// disabledGroups returns the collector groups left out of --profile or
+// switched off with --no-collector.<group>.
+func (cli *CLI) disabledGroups() ([]string, error) {
+	disabled, err := scraper.ProfileDisabledGroups(cli.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	for group, enabled := range map[string]bool{
+		scraper.GroupNode:             cli.CollectorNode,
+		scraper.GroupSystemContainers: cli.CollectorSystemContainers,
+		scraper.GroupPods:             cli.CollectorPods,
+		scraper.GroupContainers:       cli.CollectorContainers,
+		scraper.GroupVolumes:          cli.CollectorVolumes,
+		scraper.GroupNetwork:          cli.CollectorNetwork,
+		scraper.GroupAccelerators:     cli.CollectorAccelerators,
+	} {
+		if !enabled {
+			disabled = append(disabled, group)
+		}
+	}
+	return disabled, nil
+}
+
+// plainHTTP reports whether kubelet is requested over http, e.g. on its
+// read-only port, without a token.
+func (cli *CLI) plainHTTP() bool {
+	return cli.KubeletScheme == "http"
+}
+
+// checkLabelNames fails for label names Prometheus would reject or reserve.
+func checkLabelNames(labels map[string]string) error {
+	for name := range labels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
 }
 
 func main() {
-	cli := &CLI{}
-	_ = kong.Parse(cli)
+	cli, parser, kctx, err := parse(os.Args[1:])
+	if parser == nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	parser.FatalIfErrorf(err)
 	ctx := context.Background()
 
 	zapConfig := zap.NewProductionConfig()
 	zapConfig.EncoderConfig.TimeKey = zapcore.OmitKey
 	zapConfig.EncoderConfig.MessageKey = "message"
 
-	logger, err := zapConfig.Build()
+	zapLogger, err := zapConfig.Build()
 	if err != nil {
 		panic(err)
 	}
 
-	logger = logger.With(zap.String("app", "kubelet-stats-exporter"))
+	logger := logging.NewZap(zapLogger.With(zap.String("app", "kubelet-stats-exporter")))
+
+	switch kctx.Command() {
+	case "dashboard":
+		if err := cli.Dashboard.Run(cli); err != nil {
+			fatal(logger, "failed to generate dashboard", "error", err)
+		}
+		return
+	case "rules":
+		if err := cli.Rules.Run(cli); err != nil {
+			fatal(logger, "failed to generate rules", "error", err)
+		}
+		return
+	case "scrape":
+		if err := cli.Scrape.Run(logger, cli); err != nil {
+			fatal(logger, "failed to scrape kubelet", "error", err)
+		}
+		return
+	case "record":
+		if err := cli.Record.Run(logger, cli); err != nil {
+			fatal(logger, "failed to record summaries", "error", err)
+		}
+		return
+	case "replay <files>":
+		if err := cli.Replay.Run(logger, cli); err != nil {
+			fatal(logger, "failed to replay summaries", "error", err)
+		}
+		return
+	case "version":
+		if err := cli.Version.Run(); err != nil {
+			fatal(logger, "failed to print version", "error", err)
+		}
+		return
+	}
+
+	// previous is the configuration served before a reload, served again
+	// if the reloaded one fails.
+	var previous *CLI
+	for {
+		err := serve(ctx, logger, cli)
+
+		var reload reloadError
+		if errors.As(err, &reload) {
+			logger.Info("reloading configuration")
+			previous, cli = cli, reload.cli
+			continue
+		}
+
+		if serr, ok := err.(run.SignalError); ok {
+			logger.Info("caught signal",
+				"signal", serr.Signal.String(),
+			)
+		} else if err != nil && previous != nil {
+			logger.Error("failed to reload configuration, keeping the current one", "error", err)
+			previous, cli = nil, previous
+			continue
+		} else if err != nil {
+			logger.Error("actor failed",
+				"error", err,
+			)
+
+			os.Exit(1)
+		}
+		return
+	}
+}
+
+// serve serves metrics as configured by cli until a signal, a reload or an
+// actor failure stops it. A configuration that fails to start is returned
+// as an error before any listener is opened, so a reload can fall back.
+func serve(ctx context.Context, logger logging.Logger, cli *CLI) error {
+	logger.Info("starting", "version", version.Version, "revision", version.Revision(), "goversion", version.GoVersion)
 
-	if err := utils.ConfigureTLS(logger, cli.CA, cli.Insecure, cli.NodeHost); err != nil {
-		logger.Fatal("unable to configure tls", zap.Error(err))
+	if cli.Restricted {
+		cli.TokenRequest = true
 	}
 
-	if _, err := os.Stat(cli.TokenPath); os.IsNotExist(err) {
-		logger.Error("token not found", zap.String("file", cli.TokenPath), zap.Error(err))
+	if err := cli.checkTargets(); err != nil {
+		return fmt.Errorf("invalid targets configuration: %w", err)
+	}
+
+	if cli.FaultLatency > 0 || cli.FaultFailRatio > 0 || cli.FaultStale {
+		logger.Warn("injecting faults into kubelet requests",
+			"latency", cli.FaultLatency.String(),
+			"fail_ratio", cli.FaultFailRatio,
+			"stale", cli.FaultStale,
+		)
+	}
+
+	privileges := cli.privileges()
+	logger.Info("startup privilege audit",
+		"host_paths", privileges.HostPaths,
+		"files", privileges.Files,
+		"api_permissions", privileges.APIPermissions,
+		"writes", privileges.Writes,
+	)
+	if cli.Restricted {
+		if err := privileges.checkRestricted(); err != nil {
+			return fmt.Errorf("restricted mode: %w", err)
+		}
+	}
+	if cli.ReadOnly {
+		if err := privileges.checkReadOnly(); err != nil {
+			return fmt.Errorf("read-only mode: %w", err)
+		}
+	}
+
+	if !cli.TokenRequest && !cli.plainHTTP() && !cli.NodeProxy && !cli.KubeconfigCredentials {
+		if _, err := os.Stat(cli.TokenPath); os.IsNotExist(err) {
+			logger.Error("token not found", "file", cli.TokenPath, "error", err)
+		}
 	}
 
 	serverAddr := cli.NodeHost
 
-	if cli.LookUpHostname {
+	// The node proxy is requested by node name, which --node-host holds.
+	if cli.LookUpHostname && !cli.central() && !cli.NodeProxy {
 		//Handle downward API using a node name that isn't identical to the node's Hostname
-		name, err := utils.ServerAddrFromCluster(cli.NodeHost)
+		name, err := utils.ServerAddrFromCluster(cli.Kubeconfig, cli.NodeHost)
 		if err != nil {
-			logger.Fatal("failed to retrieve in node hostname", zap.Error(err))
-		} else {
-			serverAddr = name
-			logger.Info("using updated serverAddr for certificate validation", zap.String("hostname", serverAddr), zap.String("original", cli.NodeHost))
+			return fmt.Errorf("failed to retrieve in node hostname: %w", err)
 		}
+		serverAddr = name
+		logger.Info("using updated serverAddr for certificate validation", "hostname", serverAddr, "original", cli.NodeHost)
 	}
 
-	scraper := scraper.NewScraper(logger, serverAddr, cli.TokenPath, cli.Timeout)
+	opts, err := cli.scraperOptions()
+	if err != nil {
+		return fmt.Errorf("failed to configure scraper: %w", err)
+	}
 
-	promRegistry := prometheus.NewRegistry()
+	var apiMetrics *clientmetrics.Metrics
+	if cli.TokenRequest || cli.APIEnrichment || cli.DiscoverNodes || cli.ScrapeConfig != "" || cli.ResourceRatios || cli.PodInfo {
+		apiMetrics = installClientMetrics()
+	}
 
-	err = promRegistry.Register(scraper)
+	authOpts, tokenFile, err := cli.kubeletAuth(logger)
 	if err != nil {
-		logger.Fatal("failed to register storage metric")
+		return fmt.Errorf("failed to configure kubelet authentication: %w", err)
+	}
+	opts = append(opts, authOpts...)
+
+	var dynamicConfig *scraper.DynamicConfig
+	if cli.ScrapeConfig != "" {
+		dynamicConfig = scraper.NewDynamicConfig()
+		opts = append(opts, scraper.WithDynamicConfig(dynamicConfig))
+	}
+
+	var nodes *enrichment.Nodes
+	var pods *enrichment.Pods
+	if cli.APIEnrichment || cli.DiscoverNodes {
+		clientset, err := utils.ClientsetFromCluster(cli.Kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to create api-server client: %w", err)
+		}
+
+		// Discovery watches every node, which serves enrichment as well.
+		nodeName := cli.NodeHost
+		if cli.DiscoverNodes {
+			nodeName = ""
+		}
+		nodes = enrichment.NewNodes(clientset, nodeName, 10*time.Minute)
+		nodes.Instrument(apiMetrics)
+
+		if cli.APIEnrichment {
+			opts = append(opts, scraper.WithNodes(nodes, cli.NodepoolLabels))
+			if cli.SuppressPodsInMaintenance {
+				opts = append(opts, scraper.WithMaintenanceSuppression())
+			}
+
+			if !cli.DiscoverNodes {
+				pods = enrichment.NewPods(clientset, cli.NodeHost, 10*time.Minute)
+				pods.Instrument(apiMetrics)
+			}
+		}
+	}
+
+	if cli.ResourceRatios || cli.PodInfo {
+		if pods == nil {
+			clientset, err := utils.ClientsetFromCluster(cli.Kubeconfig)
+			if err != nil {
+				return fmt.Errorf("failed to create api-server client: %w", err)
+			}
+
+			pods = enrichment.NewPods(clientset, cli.NodeHost, 10*time.Minute)
+			pods.Instrument(apiMetrics)
+		}
+		if cli.ResourceRatios {
+			opts = append(opts, scraper.WithPodResources(pods))
+		}
+		if cli.PodInfo {
+			opts = append(opts, scraper.WithPodInfo(pods))
+		}
+	}
+
+	var runtimeStats *cri.Filler
+	if cli.CRIEndpoint != "" {
+		runtimeStats, err = cri.NewFiller(logging.With(logger, "component", "cri"), cli.CRIEndpoint, cli.CRIMode)
+		if err != nil {
+			return fmt.Errorf("failed to configure CRI stats: %w", err)
+		}
+		defer runtimeStats.Close()
+		opts = append(opts, scraper.WithSummaryFiller(runtimeStats))
+	}
+
+	collector := scraper.NewScraper(logger, serverAddr, cli.TokenPath, cli.Timeout, opts...)
+
+	if err := collector.SelfCheck(); err != nil {
+		return fmt.Errorf("metric descriptor self-check failed: %w", err)
+	}
+
+	promRegistry := prometheus.NewRegistry()
+	promRegistry.MustRegister(version.NewCollector())
+	if cli.RuntimeMetrics {
+		promRegistry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	var manager *targets.Manager
+	var cache *scraper.Cache
+	if cli.central() {
+		manager = targets.NewManager(logger, func(target targets.Target) prometheus.Collector {
+			address := cli.targetAddress(target)
+			s := scraper.NewScraper(logging.With(logger, "target", address), address, cli.TokenPath, cli.Timeout, opts...)
+			if interval := target.Interval(cli.ScrapeInterval); interval > 0 {
+				return scraper.NewCache(s, interval, cli.CacheMaxAge)
+			}
+			return s
+		})
+	} else if cli.ScrapeInterval > 0 {
+		cache = scraper.NewCache(collector, cli.ScrapeInterval, cli.CacheMaxAge)
+		if err := promRegistry.Register(cache); err != nil {
+			return fmt.Errorf("failed to register storage metric: %w", err)
+		}
+	}
+
+	if tokenFile != nil {
+		if err := promRegistry.Register(tokenFile); err != nil {
+			return fmt.Errorf("failed to register token metrics: %w", err)
+		}
+	}
+
+	if apiMetrics != nil {
+		if err := promRegistry.Register(apiMetrics); err != nil {
+			return fmt.Errorf("failed to register kubernetes client metrics: %w", err)
+		}
+	}
+
+	if runtimeStats != nil {
+		if err := promRegistry.Register(runtimeStats); err != nil {
+			return fmt.Errorf("failed to register CRI metrics: %w", err)
+		}
+	}
+
+	if pods != nil && cli.APIEnrichment {
+		if err := promRegistry.Register(scraper.NewPodAnnotationLabels(pods, dynamicConfig).WithNamespace(cli.MetricPrefix)); err != nil {
+			return fmt.Errorf("failed to register pod annotation labels: %w", err)
+		}
 	}
 
-	promLis, err := net.Listen("tcp", cli.PromListen)
+	if len(cli.promAddresses()) == 0 && !cli.WebSystemdSocket && cli.OTLPEndpoint == "" && cli.RemoteWriteURL == "" {
+		return fmt.Errorf("nothing to export to, set --prom-listen, --otlp.endpoint or --remote-write.url")
+	}
+
+	webConfig, err := cli.webConfig()
 	if err != nil {
-		logger.Fatal("failed to open prometheus listener",
-			zap.String("prometheus-listen", cli.PromListen),
-			zap.Error(err),
-		)
+		return fmt.Errorf("invalid web config: %w", err)
 	}
 
 	promMux := http.NewServeMux()
-	promMux.Handle("/metrics", promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{}))
-	promServer := http.Server{Handler: promMux}
+	// The scraper is gathered apart from promRegistry so /metrics can bound
+	// its kubelet requests by the scrape timeout. The cache serves from
+	// memory and needn't be.
+	var summary prometheus.Gatherer = promRegistry
+	switch {
+	case manager != nil:
+		summary = server.Gatherers{promRegistry, manager}
+	case cache == nil:
+		scraped, err := scraper.NewGatherer(collector)
+		if err != nil {
+			return fmt.Errorf("failed to register storage metric: %w", err)
+		}
+		summary = server.Gatherers{promRegistry, scraped}
+	}
+	passthroughs, err := cli.passthroughSources(logger, promRegistry, collector, serverAddr)
+	if err != nil {
+		return fmt.Errorf("failed to configure kubelet metrics passthrough: %w", err)
+	}
+	if cli.DCGMURL != "" {
+		gpus := dcgm.NewCollector(logging.With(logger, "component", "dcgm"), cli.DCGMURL, serverAddr, cli.MetricPrefix, cli.Timeout)
+		if err := promRegistry.Register(gpus); err != nil {
+			return fmt.Errorf("failed to register DCGM metrics: %w", err)
+		}
+	}
+	if cli.CgroupPath != "" {
+		pressure, err := psi.NewCollector(logging.With(logger, "component", "psi"), cli.CgroupPath, serverAddr, cli.MetricPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to configure pressure metrics: %w", err)
+		}
+		if err := promRegistry.Register(pressure); err != nil {
+			return fmt.Errorf("failed to register pressure metrics: %w", err)
+		}
+	}
+	gatherer := merge.New(logger, append([]merge.Source{{Name: "summary", Gatherer: summary}}, passthroughs...)...)
+	gatherer.AddLabels(cli.ConstLabels)
+	exported, err := cli.relabeled(gatherer)
+	if err != nil {
+		return fmt.Errorf("failed to configure relabeling: %w", err)
+	}
+	if cli.MaxSeriesPerFamily > 0 || cli.MaxSeries > 0 {
+		limiter := cardinality.NewLimiter(logger, exported, cardinality.Limits{PerFamily: cli.MaxSeriesPerFamily, Total: cli.MaxSeries})
+		if err := promRegistry.Register(limiter); err != nil {
+			return fmt.Errorf("failed to register series limit metrics: %w", err)
+		}
+		exported = limiter
+	}
+
+	handlerOpts := promhttp.HandlerOpts{DisableCompression: !cli.Compression}
+	promMux.Handle("/metrics", server.MetricsHandler(exported, cli.ScrapeTimeoutOffset, handlerOpts))
+	promMux.Handle(cardinality.Path, cardinality.Handler(exported, collector.Metrics()))
+	promMux.Handle(server.HealthzPath, server.Healthz())
+	promMux.Handle(server.ReadyzPath, server.Readyz(logger, cli.Timeout, cli.readinessChecks(collector, tokenFile)...))
+	if cli.UsageHistory > 0 {
+		promMux.Handle(scraper.RecentUsagePath, collector.RecentUsageHandler())
+	}
+	if cli.ScrapeNowTokenFile != "" {
+		promMux.Handle(server.ScrapeNowPath, server.NewScrapeNow(logger, cli.ScrapeNowTokenFile, cli.ScrapeNowInterval, func(target string) (prometheus.Gatherer, bool) {
+			if manager != nil {
+				return manager.Gatherer(target)
+			}
+
+			gatherer := summary
+			if cache != nil {
+				// Serving the cache would defeat scraping now.
+				gatherer = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+					cache.Refresh()
+					return promRegistry.Gather()
+				})
+			}
+			return gatherer, target == "" || target == cli.NodeHost || target == serverAddr
+		}))
+	}
+	if cli.Probe {
+		resolve, err := cli.probeResolver(manager, serverAddr)
+		if err != nil {
+			return fmt.Errorf("invalid probe configuration: %w", err)
+		}
+		promMux.Handle(server.ProbePath, server.NewProbe(logger, resolve, func(address string) server.ProbeTarget {
+			return scraper.NewScraper(logging.With(logger, "target", address), address, cli.TokenPath, cli.Timeout, opts...)
+		}))
+	}
+	trustedProxies, err := server.ParseTrustedProxies(cli.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("failed to parse trusted proxies: %w", err)
+	}
+
+	promServer := http.Server{Handler: server.Handler(logger, trustedProxies, promMux)}
+	if err := webConfig.Configure(&promServer); err != nil {
+		return fmt.Errorf("invalid web config: %w", err)
+	}
+
+	var tenantServer http.Server
+	if cli.TenantListen != "" {
+		tenant, err := cli.tenantGatherer(logger, exported)
+		if err != nil {
+			return fmt.Errorf("failed to configure tenant metrics: %w", err)
+		}
+
+		tenantMux := http.NewServeMux()
+		tenantMux.Handle("/metrics", promhttp.HandlerFor(tenant, handlerOpts))
+		tenantServer.Handler = server.Handler(logger, trustedProxies, tenantMux)
+		if err := webConfig.Configure(&tenantServer); err != nil {
+			return fmt.Errorf("invalid web config: %w", err)
+		}
+	}
+
+	var pusher *otlp.Pusher
+	if cli.OTLPEndpoint != "" {
+		node := ""
+		if !cli.central() {
+			node = serverAddr
+		}
+		pusher = cli.otlpPusher(logger, exported, node)
+		if err := promRegistry.Register(pusher); err != nil {
+			return fmt.Errorf("failed to register otlp metrics: %w", err)
+		}
+	}
+
+	var writer *remotewrite.Writer
+	if cli.RemoteWriteURL != "" {
+		writer, err = cli.remoteWriter(logger, exported)
+		if err != nil {
+			return fmt.Errorf("failed to configure remote_write: %w", err)
+		}
+		if err := promRegistry.Register(writer); err != nil {
+			return fmt.Errorf("failed to register remote_write metrics: %w", err)
+		}
+	}
+
+	var shard targets.Shard
+	if manager != nil {
+		if shard, err = cli.shard(); err != nil {
+			return fmt.Errorf("invalid shard: %w", err)
+		}
+	}
+
+	var scrapeConfigClient dynamic.Interface
+	var namespace, name string
+	if cli.ScrapeConfig != "" {
+		if namespace, name, err = cli.scrapeConfigKey(); err != nil {
+			return fmt.Errorf("invalid scrape config: %w", err)
+		}
+		if scrapeConfigClient, err = utils.DynamicClientFromCluster(cli.Kubeconfig); err != nil {
+			return fmt.Errorf("failed to create api-server client: %w", err)
+		}
+	}
+
+	// The listeners are opened last, so a configuration failing above
+	// leaves none open.
+	promListeners, err := cli.promListeners()
+	if err != nil {
+		return fmt.Errorf("failed to open prometheus listener on %v (systemd socket %v): %w", cli.promAddresses(), cli.WebSystemdSocket, err)
+	}
+
+	var tenantLis net.Listener
+	if cli.TenantListen != "" {
+		tenantLis, err = net.Listen("tcp", cli.TenantListen)
+		if err != nil {
+			for _, lis := range promListeners {
+				_ = lis.Close()
+			}
+			return fmt.Errorf("failed to open tenant listener on %s: %w", cli.TenantListen, err)
+		}
+	}
 
 	var g run.Group
 
 	g.Add(run.SignalHandler(ctx, os.Interrupt, syscall.SIGTERM))
+	g.Add(reloadOnSIGHUP(ctx, logger))
 
-	g.Add(func() error {
-		return promServer.Serve(promLis)
-	}, func(error) {
-		// Give the prom server its own timeout to cleanly shutdown
-		sctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
+	// Actors are interrupted in the order they are added. The servers go
+	// first, so the scrapes in flight finish while the token, cache and
+	// watches they depend on are still running.
+	if len(promListeners) > 0 {
+		g.Add(func() error {
+			return serveAll(promListeners, &promServer)
+		}, func(error) {
+			shutdown(logger, &promServer, cli.ShutdownGracePeriod)
+		})
+	}
 
-		_ = promServer.Shutdown(sctx)
+	if tenantLis != nil {
+		g.Add(func() error {
+			return webconfig.Serve(tenantLis, &tenantServer)
+		}, func(error) {
+			shutdown(logger, &tenantServer, cli.ShutdownGracePeriod)
+		})
+	}
+
+	if tokenFile != nil {
+		tctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			return tokenFile.Run(tctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if cache != nil {
+		cctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			return cache.Run(cctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if manager != nil {
+		mctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			return manager.Run(mctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if nodes != nil {
+		nctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			if err := nodes.Run(nctx); err != nil {
+				return err
+			}
+			<-nctx.Done()
+			return nil
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	var selection *scrapeconfig.NodeSelection
+	if manager != nil {
+		var discovered targets.NodeLister = nodes
+		if cli.DiscoverNodes && cli.ScrapeConfig != "" {
+			selection = scrapeconfig.NewNodeSelection(nodes)
+			discovered = selection
+		}
+
+		if shard.Count > 1 {
+			logger.Info("scraping a shard of the targets", "shard", shard.Index, "shards", shard.Count)
+		}
+
+		// Nodes both listed in the targets file and discovered are scraped
+		// once, by the replica owning their shard.
+		dedupe := targets.NewDedupe(logger, shard.Sync(manager.Sync))
+		if err := promRegistry.Register(dedupe); err != nil {
+			return fmt.Errorf("failed to register target metrics: %w", err)
+		}
+
+		if cli.DiscoverNodes {
+			tctx, cancel := context.WithCancel(ctx)
+			g.Add(func() error {
+				return targets.WatchNodes(tctx, discovered, cli.TargetsRefresh, dedupe.Sync(targets.SourceNodes))
+			}, func(error) {
+				cancel()
+			})
+		}
+
+		if cli.TargetsFile != "" {
+			tctx, cancel := context.WithCancel(ctx)
+			g.Add(func() error {
+				return targets.WatchFile(tctx, logger, cli.TargetsFile, cli.TargetsRefresh, dedupe.Sync(targets.SourceFile))
+			}, func(error) {
+				cancel()
+			})
+		}
+	}
+
+	if cli.ScrapeConfig != "" {
+		cctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			return scrapeconfig.Watch(cctx, logger, scrapeConfigClient, namespace, name, cli.ScrapeConfigRefresh, func(config scrapeconfig.Config) {
+				dynamicConfig.Set(config.PodFilter, config.PodLabels)
+				if selection != nil {
+					selection.Set(config.Nodes)
+				}
+			})
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if pods != nil {
+		pctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			if err := pods.Run(pctx); err != nil {
+				return err
+			}
+			<-pctx.Done()
+			return nil
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if pusher != nil {
+		octx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			return pusher.Run(octx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	if writer != nil {
+		wctx, cancel := context.WithCancel(ctx)
+		g.Add(func() error {
+			return writer.Run(wctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
+	return g.Run()
+}
+
+// shutdown stops srv accepting scrapes and waits up to grace for those in
+// flight, then closes their connections, which cancels their kubelet
+// requests.
+func shutdown(logger logging.Logger, srv *http.Server, grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warn("scrapes still in flight after the shutdown grace period, closing their connections", "error", err)
+		_ = srv.Close()
+	}
+}
+
+// The Kubernetes client metrics outlive reloads, client-go only accepts the
+// metrics installed first.
+var (
+	clientMetrics     *clientmetrics.Metrics
+	clientMetricsOnce sync.Once
+)
+
+func installClientMetrics() *clientmetrics.Metrics {
+	clientMetricsOnce.Do(func() {
+		clientMetrics = clientmetrics.New()
+		clientMetrics.Install()
 	})
+	return clientMetrics
+}
 
-	if err := g.Run(); err != nil {
-		if serr, ok := err.(run.SignalError); ok {
-			logger.Info("caught signal",
-				zap.String("signal", serr.Signal.String()),
-			)
-		} else {
-			logger.Error("actor failed",
-				zap.Error(err),
-			)
+// kubeletAuth returns the options authenticating kubelet requests, and the
+// token file they read unless tokens are requested or not needed.
+func (cli *CLI) kubeletAuth(logger logging.Logger) ([]scraper.Option, *auth.TokenFile, error) {
+	var opts []scraper.Option
 
-			os.Exit(1)
+	if cli.KubeconfigCredentials {
+		if cli.Kubeconfig == "" {
+			return nil, nil, fmt.Errorf("--kubeconfig-credentials needs --kubeconfig")
+		}
+		if cli.TokenRequest || cli.NodeProxy {
+			return nil, nil, fmt.Errorf("--kubeconfig-credentials can't be combined with --token-request or --node-proxy")
+		}
+
+		config, err := utils.RESTConfig(cli.Kubeconfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("api-server config: %w", err)
+		}
+
+		tokens, err := auth.NewKubeconfigToken(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("kubeconfig credentials: %w", err)
 		}
+		opts = append(opts, scraper.WithTokenSource(tokens))
 	}
+
+	if cli.TokenRequest {
+		clientset, err := utils.ClientsetFromCluster(cli.Kubeconfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("api-server client: %w", err)
+		}
+
+		opts = append(opts, scraper.WithTokenSource(auth.NewTokenRequest(clientset, cli.Namespace, cli.ServiceAccount, time.Hour, cli.Timeout)))
+	}
+
+	if cli.NodeProxy {
+		config, err := utils.RESTConfig(cli.Kubeconfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("api-server config: %w", err)
+		}
+
+		proxy, err := nodeproxy.New(config, cli.NodeProxyQPS, cli.NodeProxyBurst)
+		if err != nil {
+			return nil, nil, fmt.Errorf("node proxy client: %w", err)
+		}
+		opts = append(opts, scraper.WithNodeProxy(proxy))
+	}
+
+	var tokenFile *auth.TokenFile
+	if !cli.TokenRequest && !cli.plainHTTP() && !cli.NodeProxy && !cli.KubeconfigCredentials {
+		tokenFile = auth.NewTokenFile(logger, cli.TokenPath, cli.TokenRefresh)
+		opts = append(opts, scraper.WithTokenSource(tokenFile))
+	}
+
+	return opts, tokenFile, nil
+}
+
+// fatal logs msg at error level and exits.
+func fatal(logger logging.Logger, msg string, keysAndValues ...any) {
+	logger.Error(msg, keysAndValues...)
+	os.Exit(1)
 }
@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/otlp"
+)
+
+// otlpPusher pushes gatherer to --otlp.endpoint. node is the scraped node,
+// empty when scraping many kubelets, whose metrics carry a node label.
+func (cli *CLI) otlpPusher(logger logging.Logger, gatherer prometheus.Gatherer, node string) *otlp.Pusher {
+	resource := map[string]string{}
+	if node != "" {
+		resource[otlp.AttributeNode] = node
+	}
+	if cli.OTLPCluster != "" {
+		resource[otlp.AttributeCluster] = cli.OTLPCluster
+	}
+
+	return otlp.NewPusher(logging.With(logger, "component", "otlp"), gatherer, otlp.Options{
+		Endpoint:  cli.OTLPEndpoint,
+		Headers:   cli.OTLPHeaders,
+		Interval:  cli.OTLPInterval,
+		Timeout:   cli.Timeout,
+		Resource:  resource,
+		SeriesTTL: cli.OTLPSeriesTTL,
+	})
+}
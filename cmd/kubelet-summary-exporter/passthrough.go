@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/merge"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/passthrough"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+// passthroughSources returns the kubelet metrics endpoints merged into
+// /metrics after the summary, requested by collector and labeled with the
+// scraped node like the summary's. Their error counters are registered with
+// registry.
+func (cli *CLI) passthroughSources(logger logging.Logger, registry prometheus.Registerer, collector *scraper.Scraper, node string) ([]merge.Source, error) {
+	var sources []merge.Source
+	add := func(name string, o passthrough.Options, dropDuplicates bool) error {
+		o.Labels = map[string]string{"node": node}
+		o.Timeout = cli.Timeout
+
+		g, err := passthrough.New(logging.With(logger, "component", name), collector, o)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := registry.Register(g); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		sources = append(sources, merge.Source{Name: name, Gatherer: g, DropDuplicates: dropDuplicates})
+		return nil
+	}
+
+	if cli.Cadvisor {
+		err := add("cadvisor", passthrough.Options{
+			Path:       "/metrics/cadvisor",
+			Allow:      cli.CadvisorAllow,
+			DropLabels: cli.CadvisorDropLabels,
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cli.ResourceMetrics {
+		err := add("resource", passthrough.Options{
+			Path:   "/metrics/resource",
+			Prefix: cli.ResourceMetricsPrefix,
+		}, cli.ResourceMetricsDropDuplicates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/server"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/targets"
+)
+
+// probeResolver returns the resolver of /probe targets. Probes send the
+// kubelet token to the target, so only known nodes may be probed: the
+// exporter's own node, the targets of a central exporter and targets
+// matching --probe-targets.
+func (cli *CLI) probeResolver(manager *targets.Manager, serverAddr string) (server.ProbeResolver, error) {
+	var allowed *regexp.Regexp
+	if cli.ProbeTargets != "" {
+		re, err := regexp.Compile("^(?:" + cli.ProbeTargets + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("--probe-targets: %w", err)
+		}
+		allowed = re
+	}
+
+	return func(target string) (string, bool) {
+		if manager != nil {
+			if t, ok := manager.Lookup(target); ok {
+				return cli.targetAddress(t), true
+			}
+		} else if target == cli.NodeHost || target == serverAddr {
+			return serverAddr, true
+		}
+
+		return target, allowed != nil && allowed.MatchString(target)
+	}, nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+)
+
+type RecordCmd struct {
+	Dir      string        `help:"Directory the summaries are saved in" default:"."`
+	Count    int           `help:"Number of summaries recorded" default:"1"`
+	Interval time.Duration `help:"Time between recorded summaries" default:"30s"`
+}
+
+// Run saves summaries fetched from kubelet to timestamped files, to be
+// replayed or used as test fixtures.
+func (r *RecordCmd) Run(logger logging.Logger, cli *CLI) error {
+	if cli.ReadOnly {
+		return fmt.Errorf("--read-only refuses writing summaries to files")
+	}
+
+	s, err := cli.oneShotScraper(logger)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < r.Count; i++ {
+		if i > 0 {
+			time.Sleep(r.Interval)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cli.Timeout)
+		body, err := s.RawSummary(ctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		// Parsing checks the summary is worth replaying and names it.
+		summary, err := summaryclient.Parse(body)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s-%s.json", summary.Node.NodeName, time.Now().UTC().Format("20060102T150405.000Z"))
+		path := filepath.Join(r.Dir, name)
+		if err := os.WriteFile(path, body, 0o600); err != nil {
+			return err
+		}
+		logger.Info("recorded summary", "file", path)
+	}
+
+	return nil
+}
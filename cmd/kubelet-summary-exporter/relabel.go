@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/relabel"
+)
+
+// relabelConfigs reads the relabeling rules, none without a file.
+func (cli *CLI) relabelConfigs() ([]relabel.Config, error) {
+	if cli.RelabelConfigFile == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cli.RelabelConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := relabel.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cli.RelabelConfigFile, err)
+	}
+	return configs, nil
+}
+
+// relabeled applies the relabeling rules to gatherer, which is returned as
+// is without rules.
+func (cli *CLI) relabeled(gatherer prometheus.Gatherer) (prometheus.Gatherer, error) {
+	configs, err := cli.relabelConfigs()
+	if err != nil || len(configs) == 0 {
+		return gatherer, err
+	}
+
+	return relabel.New(gatherer, configs)
+}
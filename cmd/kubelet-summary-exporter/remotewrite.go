@@ -0,0 +1,30 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/remotewrite"
+)
+
+// remoteWriter pushes gatherer to --remote-write.url.
+func (cli *CLI) remoteWriter(logger logging.Logger, gatherer prometheus.Gatherer) (*remotewrite.Writer, error) {
+	return remotewrite.New(logging.With(logger, "component", "remote_write"), gatherer, remotewrite.Options{
+		URL:                cli.RemoteWriteURL,
+		Interval:           cli.RemoteWriteInterval,
+		Timeout:            cli.Timeout,
+		SeriesTTL:          cli.RemoteWriteSeriesTTL,
+		BearerTokenFile:    cli.RemoteWriteBearerTokenFile,
+		Username:           cli.RemoteWriteUsername,
+		PasswordFile:       cli.RemoteWritePasswordFile,
+		CAFile:             cli.RemoteWriteCA,
+		CertFile:           cli.RemoteWriteCert,
+		KeyFile:            cli.RemoteWriteKey,
+		InsecureSkipVerify: cli.RemoteWriteInsecure,
+	})
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/oklog/run"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/merge"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/server"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+)
+
+type ReplayCmd struct {
+	Files []string `arg:"" help:"Recorded summaries, or directories of them, replayed in order" type:"path"`
+	Loop  bool     `help:"Start over after the last summary instead of serving it on" default:"false"`
+}
+
+// Run serves metrics from recorded summaries, one per scrape, to debug how
+// a node's summary maps to metrics without access to the node.
+func (r *ReplayCmd) Run(logger logging.Logger, cli *CLI) error {
+	files, err := summaryFiles(r.Files)
+	if err != nil {
+		return err
+	}
+
+	opts, err := cli.scraperOptions()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, scraper.WithSummaryProvider(summaryclient.NewReplay(files, r.Loop)))
+	collector := scraper.NewScraper(logger, cli.NodeHost, cli.TokenPath, cli.Timeout, opts...)
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collector); err != nil {
+		return err
+	}
+	gatherer := merge.New(logger, merge.Source{Name: "summary", Gatherer: registry})
+	gatherer.AddLabels(cli.ConstLabels)
+	exported, err := cli.relabeled(gatherer)
+	if err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", cli.PromListen)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", server.MetricsHandler(exported, cli.ScrapeTimeoutOffset, promhttp.HandlerOpts{DisableCompression: !cli.Compression}))
+	srv := http.Server{Handler: mux}
+	logger.Info("replaying summaries", "files", len(files), "listen", lis.Addr().String())
+
+	var g run.Group
+	g.Add(run.SignalHandler(context.Background(), os.Interrupt, syscall.SIGTERM))
+	g.Add(func() error {
+		return srv.Serve(lis)
+	}, func(error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(ctx)
+	})
+
+	err = g.Run()
+	if _, ok := err.(run.SignalError); ok {
+		return nil
+	}
+	return err
+}
+
+// summaryFiles expands directories to the JSON files in them, sorted so
+// recordings replay in the order they were taken.
+func summaryFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no summaries in %v", paths)
+	}
+	return files, nil
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/rules"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+type RulesCmd struct {
+	Format    string `help:"Output format (plain or prometheusrule)" enum:"plain,prometheusrule" default:"prometheusrule"`
+	Name      string `help:"PrometheusRule name" default:"kubelet-summary-exporter"`
+	Namespace string `help:"PrometheusRule namespace"`
+
+	NodeFsThreshold           float64       `help:"Node filesystem used ratio to alert on" default:"0.9"`
+	InodesFreeThreshold       float64       `help:"Free inode ratio below which to alert" default:"0.05"`
+	EphemeralStorageThreshold float64       `help:"Pod ephemeral storage used ratio to alert on" default:"0.9"`
+	For                       time.Duration `help:"How long a condition must hold before alerting" default:"15m"`
+}
+
+// Run prints alerting rules for the metrics the exporter would serve with
+// the current flags.
+func (r *RulesCmd) Run(cli *CLI) error {
+	opts, err := cli.scraperOptions()
+	if err != nil {
+		return err
+	}
+	s := scraper.NewScraper(logging.Nop(), cli.NodeHost, cli.TokenPath, cli.Timeout, opts...)
+
+	groups := rules.Generate(s.Metrics(), rules.Thresholds{
+		NodeFsUsage:           r.NodeFsThreshold,
+		InodesFree:            r.InodesFreeThreshold,
+		EphemeralStorageUsage: r.EphemeralStorageThreshold,
+		For:                   r.For,
+	})
+
+	var out []byte
+	if r.Format == "plain" {
+		out, err = groups.YAML()
+	} else {
+		out, err = groups.PrometheusRuleYAML(r.Name, r.Namespace)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(os.Stdout, string(out))
+	return err
+}
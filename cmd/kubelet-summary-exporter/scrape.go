@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/merge"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/utils"
+)
+
+type ScrapeCmd struct {
+	Output string `help:"File the metrics are written to, stdout when empty" short:"o"`
+}
+
+// Run fetches one summary from kubelet and prints the metrics the exporter
+// would serve for it, failing if the fetch does, e.g. to check kubelet
+// access from inside a pod.
+func (c *ScrapeCmd) Run(logger logging.Logger, cli *CLI) error {
+	if cli.ReadOnly && c.Output != "" {
+		return fmt.Errorf("--read-only refuses writing metrics to --output, print them to stdout instead")
+	}
+
+	s, err := cli.oneShotScraper(logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cli.Timeout)
+	defer cancel()
+
+	once := &onceCollector{scraper: s, ctx: ctx}
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(once); err != nil {
+		return err
+	}
+
+	gatherer := merge.New(logger, merge.Source{Name: "summary", Gatherer: registry})
+	gatherer.AddLabels(cli.ConstLabels)
+	exported, err := cli.relabeled(gatherer)
+	if err != nil {
+		return err
+	}
+
+	families, err := exported.Gather()
+	if err != nil {
+		return err
+	}
+	if once.err != nil {
+		return once.err
+	}
+
+	if c.Output == "" {
+		return writeText(os.Stdout, families)
+	}
+
+	f, err := os.Create(c.Output)
+	if err != nil {
+		return err
+	}
+	if err := writeText(f, families); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// oneShotScraper returns a scraper of --node-host for commands that fetch
+// a few summaries and exit.
+func (cli *CLI) oneShotScraper(logger logging.Logger) (*scraper.Scraper, error) {
+	opts, err := cli.scraperOptions()
+	if err != nil {
+		return nil, err
+	}
+	authOpts, _, err := cli.kubeletAuth(logger)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, authOpts...)
+
+	serverAddr := cli.NodeHost
+	if cli.LookUpHostname && !cli.NodeProxy {
+		if serverAddr, err = utils.ServerAddrFromCluster(cli.Kubeconfig, cli.NodeHost); err != nil {
+			return nil, fmt.Errorf("node hostname: %w", err)
+		}
+	}
+
+	return scraper.NewScraper(logger, serverAddr, cli.TokenPath, cli.Timeout, opts...), nil
+}
+
+// writeText writes families in the text exposition format.
+func writeText(w io.Writer, families []*dto.MetricFamily) error {
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(w, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onceCollector scrapes once, keeping the error Collect can't return.
+type onceCollector struct {
+	scraper *scraper.Scraper
+	ctx     context.Context
+	err     error
+}
+
+func (c *onceCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.scraper.Describe(ch)
+}
+
+func (c *onceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.err = c.scraper.ScrapeContext(c.ctx, ch)
+}
@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scrapeConfigKey returns the namespace and name of --scrape-config, which
+// defaults to the exporter's own namespace.
+func (cli *CLI) scrapeConfigKey() (string, string, error) {
+	namespace, name, ok := strings.Cut(cli.ScrapeConfig, "/")
+	if !ok {
+		namespace, name = cli.Namespace, cli.ScrapeConfig
+	}
+
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("--scrape-config %q: expected namespace/name, or a name with --service-account-namespace set", cli.ScrapeConfig)
+	}
+
+	return namespace, name, nil
+}
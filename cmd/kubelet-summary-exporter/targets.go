@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/targets"
+)
+
+// central reports whether the exporter scrapes many kubelets rather than
+// --node-host.
+func (cli *CLI) central() bool {
+	return cli.TargetsFile != "" || cli.DiscoverNodes
+}
+
+// checkTargets fails if flags that only make sense for the exporter's own
+// node are combined with scraping many kubelets.
+func (cli *CLI) checkTargets() error {
+	if !cli.central() {
+		if cli.ShardCount > 1 {
+			return fmt.Errorf("--shard.count shares targets between replicas and needs --targets-file or --discover-nodes")
+		}
+		return nil
+	}
+
+	switch {
+	case cli.TargetsFile != "" && cli.APIEnrichment:
+		return fmt.Errorf("--api-enrichment watches a single node and can't be used with --targets-file")
+	case cli.SysfsPath != "":
+		return fmt.Errorf("--sysfs-path reads the local node and can't be used when scraping many kubelets")
+	case cli.UsageHistory > 0:
+		return fmt.Errorf("--usage-history can't be used when scraping many kubelets")
+	case cli.ResourceRatios, cli.PodInfo:
+		return fmt.Errorf("--resource-ratios and --pod-info watch the pods of a single node and can't be used when scraping many kubelets")
+	case cli.Cadvisor, cli.ResourceMetrics:
+		return fmt.Errorf("--cadvisor and --resource-metrics pass through the metrics of a single kubelet and can't be used when scraping many kubelets")
+	case cli.DCGMURL != "":
+		return fmt.Errorf("--dcgm.url reads the GPUs of a single node and can't be used when scraping many kubelets")
+	case cli.CRIEndpoint != "":
+		return fmt.Errorf("--cri.endpoint reads the container runtime of a single node and can't be used when scraping many kubelets")
+	case cli.CgroupPath != "":
+		return fmt.Errorf("--cgroup-path reads the cgroups of a single node and can't be used when scraping many kubelets")
+	}
+
+	_, err := cli.shard()
+	return err
+}
+
+// targetAddress returns what a target is scraped at: its address, or its
+// node name when kubelet is requested through --node-proxy.
+func (cli *CLI) targetAddress(target targets.Target) string {
+	if node := target.Labels[targets.NodeLabel]; cli.NodeProxy && node != "" {
+		return node
+	}
+	return target.Address
+}
+
+// shard returns the share of targets this replica scrapes.
+func (cli *CLI) shard() (targets.Shard, error) {
+	if cli.ShardCount < 1 {
+		return targets.Shard{}, fmt.Errorf("--shard.count must be positive")
+	}
+	if cli.ShardCount == 1 {
+		return targets.Shard{Count: 1}, nil
+	}
+
+	index := cli.ShardIndex
+	if index < 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return targets.Shard{}, err
+		}
+		if index, err = targets.Ordinal(hostname); err != nil {
+			return targets.Shard{}, fmt.Errorf("set --shard.index: %w", err)
+		}
+	}
+	if index >= cli.ShardCount {
+		return targets.Shard{}, fmt.Errorf("shard %d out of %d shards", index, cli.ShardCount)
+	}
+
+	return targets.Shard{Index: index, Count: cli.ShardCount}, nil
+}
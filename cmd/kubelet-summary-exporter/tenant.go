@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/redact"
+)
+
+// tenantGatherer redacts gatherer for the tenant endpoint.
+func (cli *CLI) tenantGatherer(logger logging.Logger, gatherer prometheus.Gatherer) (prometheus.Gatherer, error) {
+	mode := redact.Mode(cli.TenantRedaction)
+
+	var key []byte
+	if mode == redact.Hash {
+		if cli.TenantHashKeyFile != "" {
+			data, err := os.ReadFile(cli.TenantHashKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("tenant hash key: %w", err)
+			}
+			key = bytes.TrimSpace(data)
+		} else {
+			logger.Warn("no --tenant-hash-key-file, hashed tenant labels change with every start")
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("tenant hash key: %w", err)
+			}
+		}
+	}
+
+	return redact.New(gatherer, mode, cli.TenantLabels, key)
+}
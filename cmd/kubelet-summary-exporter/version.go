@@ -0,0 +1,21 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/version"
+)
+
+type VersionCmd struct{}
+
+// Run prints the build of the exporter.
+func (v *VersionCmd) Run() error {
+	_, err := fmt.Println(version.String())
+	return err
+}
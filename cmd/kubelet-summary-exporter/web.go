@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/webconfig"
+)
+
+// promAddresses returns the addresses to listen for Prometheus metrics on.
+func (cli *CLI) promAddresses() []string {
+	var addresses []string
+	if cli.PromListen != "" {
+		addresses = append(addresses, cli.PromListen)
+	}
+	return append(addresses, cli.WebListenAddresses...)
+}
+
+// webConfig loads --web.config.file, nil when unset.
+func (cli *CLI) webConfig() (*webconfig.Config, error) {
+	if cli.WebConfigFile == "" {
+		return nil, nil
+	}
+	return webconfig.Load(cli.WebConfigFile)
+}
+
+// promListeners opens the listeners for Prometheus metrics.
+func (cli *CLI) promListeners() ([]net.Listener, error) {
+	if cli.WebSystemdSocket {
+		return webconfig.SystemdListeners()
+	}
+
+	var listeners []net.Listener
+	for _, address := range cli.promAddresses() {
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// serveAll serves srv on all listeners until one fails or srv shuts down.
+func serveAll(listeners []net.Listener, srv *http.Server) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errs <- webconfig.Serve(l, srv)
+		}(l)
+	}
+	return <-errs
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// KubeconfigToken takes kubelet bearer tokens from the credentials of a
+// kubeconfig: its token or token file, an exec credential plugin such as
+// aws-iam-authenticator or gke-gcloud-auth-plugin, or an auth provider.
+// client-go caches and refreshes them, e.g. running the plugin again once
+// its credential expired. Client certificate credentials aren't supported.
+type KubeconfigToken struct {
+	host      string
+	transport http.RoundTripper
+}
+
+// NewKubeconfigToken returns a token source for the credentials of config.
+func NewKubeconfigToken(config *rest.Config) (*KubeconfigToken, error) {
+	transport, err := rest.HTTPWrappersForConfig(config, headerEcho{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &KubeconfigToken{host: config.Host, transport: transport}, nil
+}
+
+// Token returns the bearer token client-go would send to the api-server.
+func (k *KubeconfigToken) Token() ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, k.host, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := k.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	token, ok := strings.CutPrefix(resp.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, fmt.Errorf("kubeconfig has no bearer token credentials")
+	}
+	return []byte(token), nil
+}
+
+// headerEcho answers requests with their headers instead of sending them,
+// so the headers set by client-go's authentication wrappers can be read.
+type headerEcho struct{}
+
+func (headerEcho) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     req.Header.Clone(),
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestKubeconfigToken(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	plugin := filepath.Join(dir, "plugin")
+	if err := os.WriteFile(plugin, []byte(`#!/bin/sh
+echo '{"apiVersion":"client.authentication.k8s.io/v1","kind":"ExecCredential","status":{"token":"from-plugin"}}'
+`), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		config   rest.Config
+		expected string
+	}{
+		{name: "token", config: rest.Config{BearerToken: "static"}, expected: "static"},
+		{name: "token file", config: rest.Config{BearerTokenFile: tokenFile}, expected: "from-file"},
+		{name: "exec plugin", config: rest.Config{ExecProvider: &clientcmdapi.ExecConfig{
+			Command:         plugin,
+			APIVersion:      "client.authentication.k8s.io/v1",
+			InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+		}}, expected: "from-plugin"},
+		{name: "no credentials"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Host = "https://api.example.com"
+			tokens, err := NewKubeconfigToken(&tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := tokens.Token()
+			if tc.expected == "" {
+				if err == nil {
+					t.Errorf("expected an error, got token %q", token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(token) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, token)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// TokenFile serves the bearer token of a token file, typically a projected
+// service account token, from memory. Run re-reads the file when it changes
+// and periodically. A failed read is counted and keeps the last token, so a
+// rotation caught halfway doesn't fail scrapes.
+type TokenFile struct {
+	logger  logging.Logger
+	path    string
+	refresh time.Duration
+
+	errors *prometheus.CounterVec
+
+	mu    sync.Mutex
+	token []byte
+}
+
+// NewTokenFile returns a token source for the file at path, re-read every
+// refresh by Run.
+func NewTokenFile(logger logging.Logger, path string, refresh time.Duration) *TokenFile {
+	return &TokenFile{
+		logger:  logger,
+		path:    path,
+		refresh: refresh,
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kubelet_summary_exporter",
+			Subsystem: "token",
+			Name:      "reload_errors_total",
+			Help:      "Failed reads of the token file, the last token read is used meanwhile",
+		}, []string{"type"}),
+	}
+}
+
+// Path returns the path of the token file.
+func (t *TokenFile) Path() string {
+	return t.path
+}
+
+// Token returns the last token read, reading the file if none was read yet.
+func (t *TokenFile) Token() ([]byte, error) {
+	t.mu.Lock()
+	token := t.token
+	t.mu.Unlock()
+
+	if token != nil {
+		return token, nil
+	}
+	return t.reload()
+}
+
+// Run keeps the token up to date until ctx is done. Kubelet rotates
+// projected tokens by swapping a symlink in the token's directory, so the
+// directory is watched rather than the file. Without a watch, changes are
+// picked up by the periodic re-read alone.
+func (t *TokenFile) Run(ctx context.Context) error {
+	_, _ = t.reload()
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if watcher, err := fsnotify.NewWatcher(); err != nil {
+		t.logger.Warn("failed to watch token file, re-reading it periodically only", "file", t.path, "error", err)
+	} else {
+		defer watcher.Close()
+		if err := watcher.Add(filepath.Dir(t.path)); err != nil {
+			t.logger.Warn("failed to watch token file, re-reading it periodically only", "file", t.path, "error", err)
+		} else {
+			events, watchErrors = watcher.Events, watcher.Errors
+		}
+	}
+
+	ticker := time.NewTicker(t.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-events:
+		case err := <-watchErrors:
+			t.logger.Warn("token file watch error", "file", t.path, "error", err)
+			continue
+		}
+
+		_, _ = t.reload()
+	}
+}
+
+// Check reads the file without counting failures or replacing the token,
+// so readiness reflects whether the file can be loaded now rather than
+// whether a token was ever read.
+func (t *TokenFile) Check() error {
+	token, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(token)) == 0 {
+		return fmt.Errorf("token file %s is empty", t.path)
+	}
+	return nil
+}
+
+// reload reads the file, keeping the last token when that fails.
+func (t *TokenFile) reload() ([]byte, error) {
+	token, err := os.ReadFile(t.path)
+	if err == nil && len(bytes.TrimSpace(token)) == 0 {
+		t.errors.WithLabelValues("empty").Inc()
+		err = fmt.Errorf("token file %s is empty", t.path)
+	} else if err != nil {
+		t.errors.WithLabelValues("read").Inc()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		if t.token != nil {
+			t.logger.Warn("failed to reload token, keeping the last one", "file", t.path, "error", err)
+			return t.token, nil
+		}
+		return nil, err
+	}
+
+	t.token = token
+	return token, nil
+}
+
+func (t *TokenFile) Describe(ch chan<- *prometheus.Desc) {
+	t.errors.Describe(ch)
+}
+
+func (t *TokenFile) Collect(ch chan<- prometheus.Metric) {
+	t.errors.Collect(ch)
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+
+	// write swaps the file in like kubelet's atomic writer does.
+	write := func(token string) {
+		tmp := filepath.Join(dir, "token.tmp")
+		if err := os.WriteFile(tmp, []byte(token), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eventually := func(condition func() bool, msg string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if condition() {
+				return
+			}
+		}
+		t.Fatal(msg)
+	}
+
+	tokenFile := NewTokenFile(logging.Nop(), path, time.Hour)
+	if _, err := tokenFile.Token(); err == nil {
+		t.Fatal("expected an error before the token exists")
+	}
+
+	write("token-1")
+	if token, err := tokenFile.Token(); err != nil || string(token) != "token-1" {
+		t.Fatalf("expected token-1, got %q, %v", token, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- tokenFile.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	write("token-2")
+	eventually(func() bool {
+		token, _ := tokenFile.Token()
+		return string(token) == "token-2"
+	}, "expected the rotated token to be picked up")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	eventually(func() bool {
+		return testutil.ToFloat64(tokenFile.errors.WithLabelValues("read")) > 0
+	}, "expected the failed read to be counted")
+
+	if token, err := tokenFile.Token(); err != nil || string(token) != "token-2" {
+		t.Errorf("expected the last token to be kept, got %q, %v", token, err)
+	}
+}
+
+func TestTokenFileCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	tokens := NewTokenFile(logging.Nop(), path, time.Minute)
+
+	if err := tokens.Check(); err == nil {
+		t.Error("expected a missing token file to fail the check")
+	}
+	if err := os.WriteFile(path, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Check(); err == nil {
+		t.Error("expected an empty token file to fail the check")
+	}
+	if err := os.WriteFile(path, []byte("token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Check(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TokenRequest obtains kubelet bearer tokens for a service account through
+// the TokenRequest API instead of reading a token file, and renews them once
+// 80% of their lifetime has passed.
+type TokenRequest struct {
+	clientset      kubernetes.Interface
+	namespace      string
+	serviceAccount string
+	expiration     time.Duration
+	timeout        time.Duration
+
+	mu      sync.Mutex
+	token   []byte
+	renewAt time.Time
+}
+
+// NewTokenRequest returns a token source for the service account. The
+// caller needs create on serviceaccounts/token for it.
+func NewTokenRequest(clientset kubernetes.Interface, namespace, serviceAccount string, expiration, timeout time.Duration) *TokenRequest {
+	return &TokenRequest{
+		clientset:      clientset,
+		namespace:      namespace,
+		serviceAccount: serviceAccount,
+		expiration:     expiration,
+		timeout:        timeout,
+	}
+}
+
+// Token returns a valid token, requesting a new one when needed.
+func (t *TokenRequest) Token() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.token != nil && now.Before(t.renewAt) {
+		return t.token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	seconds := int64(t.expiration.Seconds())
+	resp, err := t.clientset.CoreV1().ServiceAccounts(t.namespace).CreateToken(ctx, t.serviceAccount,
+		&authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := resp.Status.ExpirationTimestamp.Sub(now)
+	t.token = []byte(resp.Status.Token)
+	t.renewAt = now.Add(lifetime * 4 / 5)
+
+	return t.token, nil
+}
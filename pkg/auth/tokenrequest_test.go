@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTokenRequest(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Name: "exporter"},
+	})
+
+	requests := 0
+	expiration := time.Hour
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		requests++
+		return true, &authenticationv1.TokenRequest{
+			Status: authenticationv1.TokenRequestStatus{
+				Token:               fmt.Sprintf("token-%d", requests),
+				ExpirationTimestamp: metav1.NewTime(time.Now().Add(expiration)),
+			},
+		}, nil
+	})
+
+	source := NewTokenRequest(clientset, "monitoring", "exporter", time.Hour, time.Second)
+	for i := 0; i < 2; i++ {
+		token, err := source.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(token) != "token-1" {
+			t.Errorf("expected the cached token, got %s", token)
+		}
+	}
+
+	// A token past 80% of its lifetime is renewed.
+	source.renewAt = time.Now().Add(-time.Second)
+	token, err := source.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(token) != "token-2" {
+		t.Errorf("expected a renewed token, got %s", token)
+	}
+}
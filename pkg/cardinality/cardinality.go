@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package cardinality
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+// Path is where the cardinality report is served.
+const Path = "/api/v1/cardinality"
+
+// Report counts the series of a single gather.
+type Report struct {
+	Timestamp   time.Time      `json:"timestamp"`
+	Total       int            `json:"total"`
+	ByGroup     map[string]int `json:"byGroup"`
+	ByNamespace map[string]int `json:"byNamespace"`
+	ByNode      map[string]int `json:"byNode"`
+}
+
+// Count gathers once and counts the series by metric group, namespace and
+// node. Metrics unknown to metrics are grouped under their own name, series
+// without a namespace or node label are not counted in that breakdown.
+func Count(gatherer prometheus.Gatherer, metrics []scraper.MetricInfo) (*Report, error) {
+	groups := make(map[string]string, len(metrics))
+	for _, metric := range metrics {
+		groups[metric.Name] = metric.Group
+	}
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Timestamp:   time.Now(),
+		ByGroup:     map[string]int{},
+		ByNamespace: map[string]int{},
+		ByNode:      map[string]int{},
+	}
+
+	for _, family := range families {
+		group, ok := groups[family.GetName()]
+		if !ok {
+			group = family.GetName()
+		}
+
+		for _, metric := range family.GetMetric() {
+			report.Total++
+			report.ByGroup[group]++
+
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "namespace":
+					report.ByNamespace[label.GetValue()]++
+				case "node":
+					report.ByNode[label.GetValue()]++
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Handler serves the current cardinality report as JSON.
+func Handler(gatherer prometheus.Gatherer, metrics []scraper.MetricInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, err := Count(gatherer, metrics)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
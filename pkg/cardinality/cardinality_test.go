@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package cardinality
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+func TestCount(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	memory := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "kubelet_summary_pod_memory_usage_bytes"}, []string{"node", "namespace", "pod"})
+	memory.WithLabelValues("node-a", "shop", "cart").Set(1)
+	memory.WithLabelValues("node-a", "shop", "checkout").Set(1)
+	memory.WithLabelValues("node-a", "batch", "job").Set(1)
+	registry.MustRegister(memory)
+
+	errors := prometheus.NewCounter(prometheus.CounterOpts{Name: "kubelet_summary_exporter_errors"})
+	registry.MustRegister(errors)
+
+	report, err := Count(registry, []scraper.MetricInfo{{Name: "kubelet_summary_pod_memory_usage_bytes", Group: "pod_memory"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 4 {
+		t.Errorf("expected 4 series, got %d", report.Total)
+	}
+	if want := map[string]int{"pod_memory": 3, "kubelet_summary_exporter_errors": 1}; !reflect.DeepEqual(report.ByGroup, want) {
+		t.Errorf("expected groups %v, got %v", want, report.ByGroup)
+	}
+	if want := map[string]int{"shop": 2, "batch": 1}; !reflect.DeepEqual(report.ByNamespace, want) {
+		t.Errorf("expected namespaces %v, got %v", want, report.ByNamespace)
+	}
+	if want := map[string]int{"node-a": 3}; !reflect.DeepEqual(report.ByNode, want) {
+		t.Errorf("expected nodes %v, got %v", want, report.ByNode)
+	}
+}
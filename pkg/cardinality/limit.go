@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package cardinality
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/server"
+)
+
+// selfPrefix names the exporter's own metrics, which are never limited so
+// the drops stay visible.
+const selfPrefix = "kubelet_summary_exporter_"
+
+// Limits cap the series of a single gather. Zero disables a limit.
+type Limits struct {
+	// PerFamily is the maximum number of series of a metric family.
+	PerFamily int
+	// Total is the maximum number of series of all families together.
+	Total int
+}
+
+// Limiter drops the series of another Gatherer beyond its limits, so pod
+// churn on a busy node can't flood Prometheus with series.
+type Limiter struct {
+	logger   logging.Logger
+	gatherer prometheus.Gatherer
+	limits   Limits
+	dropped  *prometheus.CounterVec
+
+	mu     sync.Mutex
+	warned map[string]struct{}
+}
+
+// NewLimiter limits the series gathered by gatherer.
+func NewLimiter(logger logging.Logger, gatherer prometheus.Gatherer, limits Limits) *Limiter {
+	return &Limiter{
+		logger:   logger,
+		gatherer: gatherer,
+		limits:   limits,
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kubelet_summary_exporter_series_dropped_total",
+			Help: "Series dropped for exceeding the series limits, by metric family",
+		}, []string{"family"}),
+		warned: map[string]struct{}{},
+	}
+}
+
+func (l *Limiter) Gather() ([]*dto.MetricFamily, error) {
+	return l.GatherContext(context.Background())
+}
+
+// GatherContext is Gather passing ctx to the limited gatherer when it
+// supports it.
+func (l *Limiter) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	families, err := server.GatherContext(ctx, l.gatherer)
+	return l.limit(families), err
+}
+
+// limit applies the per family limit first, then fills the total limit with
+// the families in the order gathered, sorted by name, keeping the first
+// series of each.
+func (l *Limiter) limit(families []*dto.MetricFamily) []*dto.MetricFamily {
+	budget := l.limits.Total
+	limited := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		name := family.GetName()
+		if strings.HasPrefix(name, selfPrefix) {
+			limited = append(limited, family)
+			continue
+		}
+
+		keep := len(family.Metric)
+		if l.limits.PerFamily > 0 && keep > l.limits.PerFamily {
+			keep = l.limits.PerFamily
+		}
+		if l.limits.Total > 0 {
+			if keep > budget {
+				keep = budget
+			}
+			budget -= keep
+		}
+
+		if dropped := len(family.Metric) - keep; dropped > 0 {
+			l.dropped.WithLabelValues(name).Add(float64(dropped))
+			l.warnOnce(name, len(family.Metric), keep)
+			family.Metric = family.Metric[:keep]
+		}
+		if keep > 0 {
+			limited = append(limited, family)
+		}
+	}
+
+	return limited
+}
+
+func (l *Limiter) warnOnce(family string, series, kept int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.warned[family]; ok {
+		return
+	}
+	l.warned[family] = struct{}{}
+
+	l.logger.Warn("dropping series exceeding the series limits", "family", family, "series", series, "kept", kept)
+}
+
+func (l *Limiter) Describe(ch chan<- *prometheus.Desc) {
+	l.dropped.Describe(ch)
+}
+
+func (l *Limiter) Collect(ch chan<- prometheus.Metric) {
+	l.dropped.Collect(ch)
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package cardinality
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestLimiter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	cpu := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "kubelet_summary_pod_cpu_usage", Help: "CPU."}, []string{"pod"})
+	memory := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "kubelet_summary_pod_memory_usage_bytes", Help: "Memory."}, []string{"pod"})
+	for _, pod := range []string{"a", "b", "c"} {
+		cpu.WithLabelValues(pod).Set(1)
+		memory.WithLabelValues(pod).Set(2)
+	}
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "kubelet_summary_exporter_errors", Help: "Errors."}, []string{"stage"})
+	for _, stage := range []string{"read", "parse", "request"} {
+		errors.WithLabelValues(stage)
+	}
+	registry.MustRegister(cpu, memory, errors)
+
+	limiter := NewLimiter(logging.Nop(), registry, Limits{PerFamily: 2, Total: 3})
+
+	expected := `
+# HELP kubelet_summary_exporter_errors Errors.
+# TYPE kubelet_summary_exporter_errors counter
+kubelet_summary_exporter_errors{stage="parse"} 0
+kubelet_summary_exporter_errors{stage="read"} 0
+kubelet_summary_exporter_errors{stage="request"} 0
+# HELP kubelet_summary_pod_cpu_usage CPU.
+# TYPE kubelet_summary_pod_cpu_usage gauge
+kubelet_summary_pod_cpu_usage{pod="a"} 1
+kubelet_summary_pod_cpu_usage{pod="b"} 1
+# HELP kubelet_summary_pod_memory_usage_bytes Memory.
+# TYPE kubelet_summary_pod_memory_usage_bytes gauge
+kubelet_summary_pod_memory_usage_bytes{pod="a"} 2
+`
+	if err := testutil.GatherAndCompare(limiter, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	expected = `
+# HELP kubelet_summary_exporter_series_dropped_total Series dropped for exceeding the series limits, by metric family
+# TYPE kubelet_summary_exporter_series_dropped_total counter
+kubelet_summary_exporter_series_dropped_total{family="kubelet_summary_pod_cpu_usage"} 1
+kubelet_summary_exporter_series_dropped_total{family="kubelet_summary_pod_memory_usage_bytes"} 2
+`
+	if err := testutil.CollectAndCompare(limiter, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package clientmetrics exports the exporter's interactions with the
+// Kubernetes API, so throttling of the exporter by the api-server or by
+// client-go's own rate limiter is visible.
+package clientmetrics
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/metrics"
+)
+
+const (
+	namespace = "kubelet_summary_exporter"
+	subsystem = "k8s"
+)
+
+// Metrics collects the Kubernetes client metrics. Request metrics come from
+// client-go, which reports them process wide.
+type Metrics struct {
+	requests            *prometheus.CounterVec
+	requestDuration     *prometheus.HistogramVec
+	rateLimiterDuration *prometheus.HistogramVec
+	watchRestarts       *prometheus.CounterVec
+	cacheSynced         *prometheus.Desc
+
+	mu     sync.Mutex
+	synced map[string]cache.InformerSynced
+}
+
+// New returns the metrics, which only count requests once Install is called.
+func New() *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Requests to the Kubernetes API by status code, method and host",
+		}, []string{"code", "method", "host"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests to the Kubernetes API",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"verb"}),
+		rateLimiterDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rate_limiter_duration_seconds",
+			Help:      "Time requests to the Kubernetes API waited on the client side rate limiter",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"verb"}),
+		watchRestarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "watch_restarts_total",
+			Help:      "Watches of the Kubernetes API restarted after an error",
+		}, []string{"resource"}),
+		cacheSynced: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "cache_synced"),
+			"Whether the watch-backed cache of a resource has synced",
+			[]string{"resource"},
+			nil),
+		synced: map[string]cache.InformerSynced{},
+	}
+}
+
+// Install makes client-go report requests to m. client-go accepts only the
+// first installed metrics.
+func (m *Metrics) Install() {
+	metrics.Register(metrics.RegisterOpts{
+		RequestLatency:     latency{m.requestDuration},
+		RateLimiterLatency: latency{m.rateLimiterDuration},
+		RequestResult:      result{m.requests},
+	})
+}
+
+// Informer instruments the watch of resource. It must be called before the
+// informer is started.
+func (m *Metrics) Informer(resource string, informer cache.SharedIndexInformer) {
+	_ = informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		m.watchRestarts.WithLabelValues(resource).Inc()
+		cache.DefaultWatchErrorHandler(r, err)
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.synced[resource] = informer.HasSynced
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.requestDuration.Describe(ch)
+	m.rateLimiterDuration.Describe(ch)
+	m.watchRestarts.Describe(ch)
+	ch <- m.cacheSynced
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.requestDuration.Collect(ch)
+	m.rateLimiterDuration.Collect(ch)
+	m.watchRestarts.Collect(ch)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resources := make([]string, 0, len(m.synced))
+	for resource := range m.synced {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	for _, resource := range resources {
+		var synced float64
+		if m.synced[resource]() {
+			synced = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.cacheSynced, prometheus.GaugeValue, synced, resource)
+	}
+}
+
+// latency adapts a histogram to client-go's latency metrics. URLs are left
+// out, they hold object names.
+type latency struct {
+	histogram *prometheus.HistogramVec
+}
+
+func (l latency) Observe(_ context.Context, verb string, _ url.URL, latency time.Duration) {
+	l.histogram.WithLabelValues(verb).Observe(latency.Seconds())
+}
+
+// result adapts a counter to client-go's request results.
+type result struct {
+	counter *prometheus.CounterVec
+}
+
+func (r result) Increment(_ context.Context, code, method, host string) {
+	if _, err := strconv.Atoi(code); err != nil {
+		// client-go reports "<error>" for requests that got no response.
+		code = "error"
+	}
+	r.counter.WithLabelValues(code, method, host).Inc()
+}
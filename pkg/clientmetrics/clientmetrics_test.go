@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package clientmetrics
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestRequests(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+
+	result{m.requests}.Increment(ctx, "200", "GET", "10.0.0.1:443")
+	result{m.requests}.Increment(ctx, "429", "GET", "10.0.0.1:443")
+	result{m.requests}.Increment(ctx, "<error>", "GET", "10.0.0.1:443")
+	latency{m.rateLimiterDuration}.Observe(ctx, "GET", url.URL{Path: "/api/v1/nodes/node-a"}, 2*time.Second)
+
+	expected := `
+# HELP kubelet_summary_exporter_k8s_requests_total Requests to the Kubernetes API by status code, method and host
+# TYPE kubelet_summary_exporter_k8s_requests_total counter
+kubelet_summary_exporter_k8s_requests_total{code="200",host="10.0.0.1:443",method="GET"} 1
+kubelet_summary_exporter_k8s_requests_total{code="429",host="10.0.0.1:443",method="GET"} 1
+kubelet_summary_exporter_k8s_requests_total{code="error",host="10.0.0.1:443",method="GET"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(expected), "kubelet_summary_exporter_k8s_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	if got := testutil.CollectAndCount(m, "kubelet_summary_exporter_k8s_rate_limiter_duration_seconds"); got != 1 {
+		t.Errorf("expected one rate limiter histogram, got %d", got)
+	}
+}
+
+func TestInformer(t *testing.T) {
+	m := New()
+
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	informer := factory.Core().V1().Nodes().Informer()
+	m.Informer("nodes", informer)
+
+	synced := func(value string) string {
+		return `
+# HELP kubelet_summary_exporter_k8s_cache_synced Whether the watch-backed cache of a resource has synced
+# TYPE kubelet_summary_exporter_k8s_cache_synced gauge
+kubelet_summary_exporter_k8s_cache_synced{resource="nodes"} ` + value + "\n"
+	}
+
+	if err := testutil.CollectAndCompare(m, strings.NewReader(synced("0")), "kubelet_summary_exporter_k8s_cache_synced"); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		t.Fatal("cache didn't sync")
+	}
+
+	if err := testutil.CollectAndCompare(m, strings.NewReader(synced("1")), "kubelet_summary_exporter_k8s_cache_synced"); err != nil {
+		t.Error(err)
+	}
+}
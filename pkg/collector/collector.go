@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package collector embeds the exporter's kubelet summary metrics in other
+// programs' Prometheus registries, so agents don't need to run the exporter
+// next to them. Unlike the scraper package, which follows the exporter's
+// flags, its API only grows: options are added, never changed or removed.
+//
+//	c, err := collector.New("node-a", collector.WithPort(10250))
+//	if err != nil {
+//		return err
+//	}
+//	registry.MustRegister(c)
+package collector
+
+import (
+	"crypto/tls"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+)
+
+// DefaultTokenPath is where the service account token is mounted in pods.
+const DefaultTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// TokenSource provides the bearer token sent to kubelet.
+type TokenSource = summaryclient.TokenSource
+
+// Filters select the pods whose metrics are collected. A nil expression
+// doesn't constrain anything; excludes win over includes.
+type Filters struct {
+	IncludeNamespaces *regexp.Regexp
+	ExcludeNamespaces *regexp.Regexp
+	IncludePods       *regexp.Regexp
+	ExcludePods       *regexp.Regexp
+}
+
+type config struct {
+	logger      logging.Logger
+	timeout     time.Duration
+	port        int
+	tokenPath   string
+	tokenSource TokenSource
+	httpClient  *http.Client
+	tlsConfig   *tls.Config
+	filters     Filters
+}
+
+// Option configures a Collector.
+type Option func(*config)
+
+// WithLogger logs scrape errors to logger instead of discarding them.
+func WithLogger(logger logging.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithHTTPClient requests kubelet with client, whose transport then decides
+// about TLS, instead of a client built by the collector.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.httpClient = client
+	}
+}
+
+// WithPort requests kubelet on port instead of 10250.
+func WithPort(port int) Option {
+	return func(c *config) {
+		c.port = port
+	}
+}
+
+// WithTLSConfig verifies kubelet's serving certificate with cfg instead of
+// skipping verification.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *config) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithFilters only collects the metrics of the pods selected by filters.
+func WithFilters(filters Filters) Option {
+	return func(c *config) {
+		c.filters = filters
+	}
+}
+
+// WithTimeout limits kubelet requests to timeout instead of 5s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithTokenFile reads the bearer token from path instead of
+// DefaultTokenPath, on every scrape so rotated tokens are picked up.
+func WithTokenFile(path string) Option {
+	return func(c *config) {
+		c.tokenPath = path
+	}
+}
+
+// WithTokenSource takes bearer tokens from ts instead of a token file.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *config) {
+		c.tokenSource = ts
+	}
+}
+
+// Collector collects the stats summary of one kubelet on every Collect.
+type Collector struct {
+	scraper *scraper.Scraper
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New returns a collector of the kubelet at host, a node name or address
+// optionally followed by a port.
+func New(host string, opts ...Option) (*Collector, error) {
+	c := config{
+		logger:    logging.Nop(),
+		timeout:   5 * time.Second,
+		port:      10250,
+		tokenPath: DefaultTokenPath,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if err := scraper.CheckEndpoint("https", c.port); err != nil {
+		return nil, err
+	}
+
+	options := []scraper.Option{
+		scraper.WithEndpoint("https", c.port),
+		scraper.WithPodFilter(scraper.PodFilter(c.filters)),
+	}
+	if c.tokenSource != nil {
+		options = append(options, scraper.WithTokenSource(c.tokenSource))
+	}
+	if c.tlsConfig != nil {
+		options = append(options, scraper.WithTLSConfig(c.tlsConfig))
+	}
+	if c.httpClient != nil {
+		options = append(options, scraper.WithHTTPClient(c.httpClient))
+	}
+
+	s := scraper.NewScraper(c.logger, host, c.tokenPath, c.timeout, options...)
+	if err := s.SelfCheck(); err != nil {
+		return nil, err
+	}
+
+	return &Collector{scraper: s}, nil
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.scraper.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.scraper.Collect(ch)
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type staticToken string
+
+func (t staticToken) Token() ([]byte, error) {
+	return []byte(t), nil
+}
+
+type countingTransport struct {
+	http.RoundTripper
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return c.RoundTripper.RoundTrip(req)
+}
+
+func TestCollector(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"node":{"nodeName":"node","runtime":{}},"pods":[
+			{"podRef":{"name":"web","namespace":"default"},"memory":{"workingSetBytes":100}},
+			{"podRef":{"name":"job","namespace":"ci"},"memory":{"workingSetBytes":200}}]}`))
+	}))
+	defer kubelet.Close()
+
+	transport := &countingTransport{RoundTripper: kubelet.Client().Transport}
+	c, err := New(strings.TrimPrefix(kubelet.URL, "https://"),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithTokenSource(staticToken("token")),
+		WithFilters(Filters{ExcludeNamespaces: regexp.MustCompile("^ci$")}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+
+	expected := `
+# HELP kubelet_summary_pod_memory_working_set_bytes working set bytes in pod memory
+# TYPE kubelet_summary_pod_memory_working_set_bytes gauge
+kubelet_summary_pod_memory_working_set_bytes{namespace="default",node="node",pod="web"} 100
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "kubelet_summary_pod_memory_working_set_bytes"); err != nil {
+		t.Error(err)
+	}
+	if transport.requests.Load() == 0 {
+		t.Error("expected kubelet to be requested with the given client")
+	}
+}
+
+func TestInvalidPort(t *testing.T) {
+	if _, err := New("node", WithPort(0)); err == nil {
+		t.Error("expected port 0 to be rejected")
+	}
+}
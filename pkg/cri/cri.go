@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package cri completes stats summaries with the stats the container runtime
+// reports over its CRI socket, for runtimes whose summaries lack fields such
+// as usageNanoCores or the containers' rootfs.
+package cri
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// Modes of filling summaries.
+const (
+	// ModeFallback fills the fields missing from the summary only.
+	ModeFallback = "fallback"
+	// ModePrefer replaces the summary's fields with the runtime's.
+	ModePrefer = "prefer"
+)
+
+// maxMsgSize bounds the runtime's responses, as kubelet does.
+const maxMsgSize = 16 * 1024 * 1024
+
+// Labels kubelet sets on the containers it creates.
+const (
+	podUIDLabel        = "io.kubernetes.pod.uid"
+	containerNameLabel = "io.kubernetes.container.name"
+)
+
+// Filler fills summaries with the stats of the runtime at an endpoint. A
+// failed request is logged and counted, and leaves the summary unchanged.
+type Filler struct {
+	logger   logging.Logger
+	endpoint string
+	prefer   bool
+	conn     *grpc.ClientConn
+	client   runtimeapi.RuntimeServiceClient
+
+	errors prometheus.Counter
+}
+
+// NewFiller returns a filler of the runtime at endpoint, e.g.
+// unix:///run/containerd/containerd.sock, in mode. The connection is made on
+// the first request.
+func NewFiller(logger logging.Logger, endpoint, mode string) (*Filler, error) {
+	if mode != ModeFallback && mode != ModePrefer {
+		return nil, fmt.Errorf("unknown CRI mode %q", mode)
+	}
+	conn, err := grpc.NewClient(endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRI endpoint %q: %w", endpoint, err)
+	}
+	return &Filler{
+		logger:   logger,
+		endpoint: endpoint,
+		prefer:   mode == ModePrefer,
+		conn:     conn,
+		client:   runtimeapi.NewRuntimeServiceClient(conn),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "kubelet_summary_exporter",
+			Subsystem: "cri",
+			Name:      "errors_total",
+			Help:      "Failed requests of the container runtime's stats",
+		}),
+	}, nil
+}
+
+// Close closes the connection to the runtime.
+func (f *Filler) Close() error {
+	return f.conn.Close()
+}
+
+func (f *Filler) Describe(ch chan<- *prometheus.Desc) {
+	f.errors.Describe(ch)
+}
+
+func (f *Filler) Collect(ch chan<- prometheus.Metric) {
+	f.errors.Collect(ch)
+}
+
+// Fill completes summary with the runtime's stats of its pods and
+// containers.
+func (f *Filler) Fill(ctx context.Context, summary *statsapi.Summary) {
+	pods, err := f.podStats(ctx)
+	if err != nil {
+		f.errors.Inc()
+		f.logger.Warn("failed to get stats from the container runtime", "endpoint", f.endpoint, "error", err)
+		return
+	}
+
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+		stats, ok := pods[pod.PodRef.UID]
+		if !ok {
+			continue
+		}
+		if stats.Linux != nil {
+			f.fillCPU(&pod.CPU, stats.Linux.Cpu)
+			f.fillMemory(&pod.Memory, stats.Linux.Memory)
+		}
+
+		containers := map[string]*runtimeapi.ContainerStats{}
+		if stats.Linux != nil {
+			for _, c := range stats.Linux.Containers {
+				if name := containerName(c); name != "" {
+					containers[name] = c
+				}
+			}
+		}
+		for j := range pod.Containers {
+			container := &pod.Containers[j]
+			c, ok := containers[container.Name]
+			if !ok {
+				continue
+			}
+			f.fillCPU(&container.CPU, c.Cpu)
+			f.fillMemory(&container.Memory, c.Memory)
+			f.fillFs(&container.Rootfs, c.WritableLayer)
+		}
+	}
+}
+
+// podStats returns the runtime's stats by pod UID. Runtimes that don't
+// implement ListPodSandboxStats are asked for their containers' stats,
+// without the pods' own.
+func (f *Filler) podStats(ctx context.Context) (map[string]*runtimeapi.PodSandboxStats, error) {
+	pods := map[string]*runtimeapi.PodSandboxStats{}
+
+	resp, err := f.client.ListPodSandboxStats(ctx, &runtimeapi.ListPodSandboxStatsRequest{})
+	if err == nil {
+		for _, stats := range resp.Stats {
+			if stats.Attributes == nil || stats.Attributes.Metadata == nil {
+				continue
+			}
+			pods[stats.Attributes.Metadata.Uid] = stats
+		}
+		return pods, nil
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, err
+	}
+
+	containers, err := f.client.ListContainerStats(ctx, &runtimeapi.ListContainerStatsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range containers.Stats {
+		if c.Attributes == nil {
+			continue
+		}
+		uid := c.Attributes.Labels[podUIDLabel]
+		if uid == "" {
+			continue
+		}
+		pod, ok := pods[uid]
+		if !ok {
+			pod = &runtimeapi.PodSandboxStats{Linux: &runtimeapi.LinuxPodSandboxStats{}}
+			pods[uid] = pod
+		}
+		pod.Linux.Containers = append(pod.Linux.Containers, c)
+	}
+	return pods, nil
+}
+
+// containerName returns the name of a container in its pod spec.
+func containerName(c *runtimeapi.ContainerStats) string {
+	if c.Attributes == nil {
+		return ""
+	}
+	if c.Attributes.Metadata != nil && c.Attributes.Metadata.Name != "" {
+		return c.Attributes.Metadata.Name
+	}
+	return c.Attributes.Labels[containerNameLabel]
+}
+
+func (f *Filler) fillCPU(dst **statsapi.CPUStats, src *runtimeapi.CpuUsage) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = &statsapi.CPUStats{Time: timestamp(src.Timestamp)}
+	}
+	f.fill(&(*dst).UsageNanoCores, src.UsageNanoCores)
+	f.fill(&(*dst).UsageCoreNanoSeconds, src.UsageCoreNanoSeconds)
+}
+
+func (f *Filler) fillMemory(dst **statsapi.MemoryStats, src *runtimeapi.MemoryUsage) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = &statsapi.MemoryStats{Time: timestamp(src.Timestamp)}
+	}
+	f.fill(&(*dst).WorkingSetBytes, src.WorkingSetBytes)
+	f.fill(&(*dst).AvailableBytes, src.AvailableBytes)
+	f.fill(&(*dst).UsageBytes, src.UsageBytes)
+	f.fill(&(*dst).RSSBytes, src.RssBytes)
+	f.fill(&(*dst).PageFaults, src.PageFaults)
+	f.fill(&(*dst).MajorPageFaults, src.MajorPageFaults)
+}
+
+func (f *Filler) fillFs(dst **statsapi.FsStats, src *runtimeapi.FilesystemUsage) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = &statsapi.FsStats{Time: timestamp(src.Timestamp)}
+	}
+	f.fill(&(*dst).UsedBytes, src.UsedBytes)
+	f.fill(&(*dst).InodesUsed, src.InodesUsed)
+}
+
+// fill sets dst to the runtime's value, unless the summary has one and the
+// runtime's isn't preferred.
+func (f *Filler) fill(dst **uint64, src *runtimeapi.UInt64Value) {
+	if src == nil || (*dst != nil && !f.prefer) {
+		return
+	}
+	v := src.Value
+	*dst = &v
+}
+
+func timestamp(nanos int64) metav1.Time {
+	if nanos == 0 {
+		return metav1.Now()
+	}
+	return metav1.Unix(0, nanos)
+}
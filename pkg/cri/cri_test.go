@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package cri
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// runtime serves the stats of a pod with one container, with or without
+// ListPodSandboxStats.
+type runtime struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+	sandboxStats bool
+	fail         bool
+}
+
+func (r *runtime) container() *runtimeapi.ContainerStats {
+	return &runtimeapi.ContainerStats{
+		Attributes: &runtimeapi.ContainerAttributes{
+			Id:       "c1",
+			Metadata: &runtimeapi.ContainerMetadata{Name: "app"},
+			Labels:   map[string]string{podUIDLabel: "uid-1", containerNameLabel: "app"},
+		},
+		Cpu:           &runtimeapi.CpuUsage{Timestamp: 1, UsageNanoCores: &runtimeapi.UInt64Value{Value: 250}, UsageCoreNanoSeconds: &runtimeapi.UInt64Value{Value: 9000}},
+		Memory:        &runtimeapi.MemoryUsage{Timestamp: 1, WorkingSetBytes: &runtimeapi.UInt64Value{Value: 1024}},
+		WritableLayer: &runtimeapi.FilesystemUsage{Timestamp: 1, UsedBytes: &runtimeapi.UInt64Value{Value: 4096}, InodesUsed: &runtimeapi.UInt64Value{Value: 7}},
+	}
+}
+
+func (r *runtime) ListPodSandboxStats(ctx context.Context, req *runtimeapi.ListPodSandboxStatsRequest) (*runtimeapi.ListPodSandboxStatsResponse, error) {
+	if r.fail {
+		return nil, status.Error(codes.Unavailable, "runtime down")
+	}
+	if !r.sandboxStats {
+		return nil, status.Error(codes.Unimplemented, "not implemented")
+	}
+	return &runtimeapi.ListPodSandboxStatsResponse{Stats: []*runtimeapi.PodSandboxStats{{
+		Attributes: &runtimeapi.PodSandboxAttributes{Id: "p1", Metadata: &runtimeapi.PodSandboxMetadata{Name: "pod", Namespace: "ns", Uid: "uid-1"}},
+		Linux: &runtimeapi.LinuxPodSandboxStats{
+			Cpu:        &runtimeapi.CpuUsage{Timestamp: 1, UsageNanoCores: &runtimeapi.UInt64Value{Value: 300}},
+			Containers: []*runtimeapi.ContainerStats{r.container()},
+		},
+	}}}, nil
+}
+
+func (r *runtime) ListContainerStats(ctx context.Context, req *runtimeapi.ListContainerStatsRequest) (*runtimeapi.ListContainerStatsResponse, error) {
+	return &runtimeapi.ListContainerStatsResponse{Stats: []*runtimeapi.ContainerStats{r.container()}}, nil
+}
+
+// serve serves r on a unix socket, returning a filler of it in mode.
+func serve(t *testing.T, r *runtime, mode string) *Filler {
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed.
+	dir, err := os.MkdirTemp("", "cri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "cri.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(srv, r)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	f, err := NewFiller(logging.Nop(), "unix://"+path, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func uint64p(v uint64) *uint64 {
+	return &v
+}
+
+// summary returns a summary of the runtime's pod whose container reports
+// its usage but not its usage rate.
+func summary() *statsapi.Summary {
+	return &statsapi.Summary{Pods: []statsapi.PodStats{{
+		PodRef: statsapi.PodReference{Name: "pod", Namespace: "ns", UID: "uid-1"},
+		Containers: []statsapi.ContainerStats{{
+			Name: "app",
+			CPU:  &statsapi.CPUStats{UsageCoreNanoSeconds: uint64p(8000)},
+		}},
+	}}}
+}
+
+func TestFill(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		sandboxStats bool
+		mode         string
+		podCPU       bool
+		usage        uint64
+	}{
+		{"pod sandbox stats", true, ModeFallback, true, 8000},
+		{"container stats", false, ModeFallback, false, 8000},
+		{"prefer runtime", true, ModePrefer, true, 9000},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := serve(t, &runtime{sandboxStats: tc.sandboxStats}, tc.mode)
+			s := summary()
+			f.Fill(context.Background(), s)
+
+			pod := s.Pods[0]
+			if tc.podCPU != (pod.CPU != nil) {
+				t.Errorf("expected pod CPU stats: %v, got %+v", tc.podCPU, pod.CPU)
+			}
+			c := pod.Containers[0]
+			if c.CPU.UsageNanoCores == nil || *c.CPU.UsageNanoCores != 250 {
+				t.Errorf("expected the runtime's usageNanoCores, got %v", c.CPU.UsageNanoCores)
+			}
+			if *c.CPU.UsageCoreNanoSeconds != tc.usage {
+				t.Errorf("expected usageCoreNanoSeconds %d, got %d", tc.usage, *c.CPU.UsageCoreNanoSeconds)
+			}
+			if c.Memory == nil || *c.Memory.WorkingSetBytes != 1024 {
+				t.Errorf("expected the runtime's working set, got %+v", c.Memory)
+			}
+			if c.Rootfs == nil || *c.Rootfs.UsedBytes != 4096 || *c.Rootfs.InodesUsed != 7 {
+				t.Errorf("expected the runtime's writable layer, got %+v", c.Rootfs)
+			}
+		})
+	}
+}
+
+func TestFillError(t *testing.T) {
+	f := serve(t, &runtime{fail: true}, ModeFallback)
+	s := summary()
+	f.Fill(context.Background(), s)
+
+	if s.Pods[0].Containers[0].CPU.UsageNanoCores != nil {
+		t.Error("expected the summary to be left unchanged")
+	}
+
+	expected := `
+# HELP kubelet_summary_exporter_cri_errors_total Failed requests of the container runtime's stats
+# TYPE kubelet_summary_exporter_cri_errors_total counter
+kubelet_summary_exporter_cri_errors_total 1
+`
+	if err := testutil.CollectAndCompare(f, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+const (
+	panelWidth  = 12
+	panelHeight = 8
+	gridWidth   = 24
+)
+
+// filterLabels are the labels exposed as dashboard variables, in the order
+// they depend on each other.
+var filterLabels = []string{"node", "namespace", "pod"}
+
+type Dashboard struct {
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+	Tags          []string   `json:"tags"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          Time       `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type Time struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label,omitempty"`
+	Type       string      `json:"type"`
+	Query      interface{} `json:"query"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	AllValue   string      `json:"allValue,omitempty"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Panel struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	GridPos     GridPos     `json:"gridPos"`
+	Datasource  *Datasource `json:"datasource,omitempty"`
+	Targets     []Target    `json:"targets,omitempty"`
+	Collapsed   bool        `json:"collapsed,omitempty"`
+	Panels      []Panel     `json:"panels,omitempty"`
+}
+
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+// Generate builds a dashboard with one row per metric group and one time
+// series panel per metric, filtered by node, namespace and pod variables
+// wherever the metric carries those labels.
+func Generate(title string, metrics []scraper.MetricInfo) *Dashboard {
+	datasource := &Datasource{Type: "prometheus", UID: "${datasource}"}
+
+	d := &Dashboard{
+		Title:         title,
+		UID:           "kubelet-summary-exporter",
+		Tags:          []string{"kubelet-summary-exporter"},
+		SchemaVersion: 38,
+		Time:          Time{From: "now-1h", To: "now"},
+	}
+
+	d.Templating.List = append(d.Templating.List, Variable{
+		Name:  "datasource",
+		Label: "Data source",
+		Type:  "datasource",
+		Query: "prometheus",
+	})
+	for i, label := range filterLabels {
+		source := metricWithLabels(metrics, filterLabels[:i+1])
+		if source == "" {
+			continue
+		}
+
+		d.Templating.List = append(d.Templating.List, Variable{
+			Name:       label,
+			Type:       "query",
+			Datasource: datasource,
+			Query:      fmt.Sprintf("label_values(%s%s, %s)", source, selector(filterLabels[:i]), label),
+			Refresh:    2,
+			Multi:      true,
+			IncludeAll: true,
+			AllValue:   ".*",
+		})
+	}
+
+	id := 1
+	y := 0
+	for _, group := range groups(metrics) {
+		d.Panels = append(d.Panels, Panel{
+			ID:      id,
+			Type:    "row",
+			Title:   strings.ReplaceAll(group, "_", " "),
+			GridPos: GridPos{H: 1, W: gridWidth, X: 0, Y: y},
+		})
+		id++
+		y++
+
+		x := 0
+		for _, metric := range metrics {
+			if metric.Group != group {
+				continue
+			}
+
+			expr := metric.Name + selector(metric.Labels)
+			if metric.Counter {
+				expr = "rate(" + expr + "[$__rate_interval])"
+			}
+
+			d.Panels = append(d.Panels, Panel{
+				ID:          id,
+				Type:        "timeseries",
+				Title:       metric.Name,
+				Description: metric.Help,
+				GridPos:     GridPos{H: panelHeight, W: panelWidth, X: x, Y: y},
+				Datasource:  datasource,
+				Targets: []Target{{
+					RefID:        "A",
+					Expr:         expr,
+					LegendFormat: legend(metric.Labels),
+				}},
+			})
+			id++
+
+			x += panelWidth
+			if x >= gridWidth {
+				x = 0
+				y += panelHeight
+			}
+		}
+		if x != 0 {
+			y += panelHeight
+		}
+	}
+
+	return d
+}
+
+// JSON renders the dashboard in Grafana's import format.
+func (d *Dashboard) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// groups returns metric groups in the order they first appear.
+func groups(metrics []scraper.MetricInfo) []string {
+	seen := map[string]struct{}{}
+	var groups []string
+	for _, metric := range metrics {
+		if _, ok := seen[metric.Group]; ok {
+			continue
+		}
+		seen[metric.Group] = struct{}{}
+		groups = append(groups, metric.Group)
+	}
+	return groups
+}
+
+// metricWithLabels returns the first metric carrying all labels.
+func metricWithLabels(metrics []scraper.MetricInfo, labels []string) string {
+	for _, metric := range metrics {
+		if hasLabels(metric.Labels, labels) {
+			return metric.Name
+		}
+	}
+	return ""
+}
+
+func hasLabels(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// selector builds a label matcher restricting labels to their dashboard
+// variables.
+func selector(labels []string) string {
+	var matchers []string
+	for _, label := range filterLabels {
+		if hasLabels(labels, []string{label}) {
+			matchers = append(matchers, fmt.Sprintf("%s=~\"$%s\"", label, label))
+		}
+	}
+	if len(matchers) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(matchers, ", ") + "}"
+}
+
+func legend(labels []string) string {
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, fmt.Sprintf("{{%s}}", label))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package dashboard
+
+import (
+	"testing"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+)
+
+func TestGenerate(t *testing.T) {
+	metrics := []scraper.MetricInfo{
+		{Name: "kubelet_summary_node_fs_usage_bytes", Group: "node_fs", Labels: []string{"node"}},
+		{Name: "kubelet_summary_node_fs_limit_bytes", Group: "node_fs", Labels: []string{"node"}},
+		{Name: "kubelet_summary_pod_memory_usage_bytes", Group: "pod_memory", Labels: []string{"node", "namespace", "pod"}},
+	}
+
+	d := Generate("test", metrics)
+
+	var rows, panels int
+	for _, panel := range d.Panels {
+		switch panel.Type {
+		case "row":
+			rows++
+		case "timeseries":
+			panels++
+		}
+	}
+	if rows != 2 || panels != 3 {
+		t.Errorf("expected 2 rows and 3 panels, got %d rows and %d panels", rows, panels)
+	}
+
+	if got, want := d.Panels[len(d.Panels)-1].Targets[0].Expr, `kubelet_summary_pod_memory_usage_bytes{node=~"$node", namespace=~"$namespace", pod=~"$pod"}`; got != want {
+		t.Errorf("unexpected expression %q, want %q", got, want)
+	}
+
+	if got := len(d.Templating.List); got != 4 {
+		t.Errorf("expected datasource, node, namespace and pod variables, got %d", got)
+	}
+
+	if _, err := d.JSON(); err != nil {
+		t.Fatalf("failed to render dashboard: %+v", err)
+	}
+}
+
+func TestGenerateCounters(t *testing.T) {
+	d := Generate("test", []scraper.MetricInfo{
+		{Name: "kubelet_summary_node_cpu_usage_core_nano_seconds_total", Group: "node_cpu", Labels: []string{"node"}, Counter: true},
+	})
+
+	if got, want := d.Panels[len(d.Panels)-1].Targets[0].Expr, `rate(kubelet_summary_node_cpu_usage_core_nano_seconds_total{node=~"$node"}[$__rate_interval])`; got != want {
+		t.Errorf("unexpected expression %q, want %q", got, want)
+	}
+}
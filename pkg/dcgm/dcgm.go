@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package dcgm exports the GPU metrics of NVIDIA's DCGM exporter running on
+// the node as accelerator metrics keyed like the summary's, for the device
+// utilization, bandwidth and temperatures kubelet's accelerator stats lack.
+package dcgm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/utils"
+)
+
+// field is a DCGM field exported as an accelerator metric.
+type field struct {
+	name string
+	help string
+	// scale converts the field to the metric's unit.
+	scale float64
+}
+
+// fields are the DCGM fields exported, by the name dcgm-exporter gives them.
+var fields = map[string]field{
+	"DCGM_FI_PROF_SM_ACTIVE":    {"accelerator_sm_active_ratio", "Ratio of cycles at least one warp was resident on an SM, averaged over all SMs", 1},
+	"DCGM_FI_PROF_SM_OCCUPANCY": {"accelerator_sm_occupancy_ratio", "Ratio of warps resident on an SM to its maximum, averaged over all SMs", 1},
+	"DCGM_FI_DEV_GPU_UTIL":      {"accelerator_utilization_ratio", "Ratio of time a kernel was running on the device", 0.01},
+	"DCGM_FI_PROF_DRAM_ACTIVE":  {"accelerator_memory_bandwidth_utilization_ratio", "Ratio of cycles the device memory interface was sending or receiving data", 1},
+	"DCGM_FI_DEV_MEM_COPY_UTIL": {"accelerator_memory_copy_utilization_ratio", "Ratio of time device memory was read or written", 0.01},
+	"DCGM_FI_DEV_FB_USED":       {"accelerator_framebuffer_used_bytes", "Device memory used", 1 << 20},
+	"DCGM_FI_DEV_FB_FREE":       {"accelerator_framebuffer_free_bytes", "Device memory free", 1 << 20},
+	"DCGM_FI_DEV_SM_CLOCK":      {"accelerator_sm_clock_hertz", "SM clock frequency", 1e6},
+	"DCGM_FI_DEV_GPU_TEMP":      {"accelerator_temperature_celsius", "Device temperature", 1},
+	"DCGM_FI_DEV_MEMORY_TEMP":   {"accelerator_memory_temperature_celsius", "Device memory temperature", 1},
+	"DCGM_FI_DEV_POWER_USAGE":   {"accelerator_power_watts", "Device power draw", 1},
+}
+
+// labelNames label the accelerator metrics. id is the device's UUID, as in
+// the summary's accelerator metrics. The pod labels are empty for devices
+// not attached to a container.
+var labelNames = []string{"node", "id", "model", "namespace", "pod", "container"}
+
+// podLabels are dcgm-exporter's names of the pod labels, the current ones
+// first.
+var podLabels = map[string][]string{
+	"namespace": {"namespace", "pod_namespace"},
+	"pod":       {"pod", "pod_name"},
+	"container": {"container", "container_name"},
+}
+
+// Collector fetches the DCGM exporter's metrics on every Collect. A failed
+// fetch is logged and counted rather than failing the exporter's other
+// metrics.
+type Collector struct {
+	logger  logging.Logger
+	client  *http.Client
+	url     string
+	node    string
+	timeout time.Duration
+
+	descs  map[string]*prometheus.Desc
+	errors prometheus.Counter
+}
+
+// NewCollector returns a collector of the DCGM exporter at url, labeling its
+// metrics with node. Metric names are prefixed with namespace.
+func NewCollector(logger logging.Logger, url, node, namespace string, timeout time.Duration) *Collector {
+	c := &Collector{
+		logger:  logger,
+		client:  &http.Client{Transport: utils.NewTransport(nil)},
+		url:     url,
+		node:    node,
+		timeout: timeout,
+		descs:   map[string]*prometheus.Desc{},
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "kubelet_summary_exporter",
+			Subsystem: "dcgm",
+			Name:      "errors_total",
+			Help:      "Failed requests of the DCGM exporter",
+		}),
+	}
+	for name, f := range fields {
+		c.descs[name] = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", f.name), f.help, labelNames, nil)
+	}
+	return c
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+	c.errors.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	defer c.errors.Collect(ch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	families, err := c.fetch(ctx)
+	if err != nil {
+		c.errors.Inc()
+		c.logger.Warn("failed to fetch DCGM metrics", "url", c.url, "error", err)
+		return
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		if _, ok := fields[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// Series dcgm-exporter repeats, e.g. for a device shared by several
+	// containers without pod mapping, are exported once.
+	seen := map[string]struct{}{}
+	for _, name := range names {
+		f := fields[name]
+		for _, metric := range families[name].Metric {
+			value, ok := metricValue(metric)
+			if !ok {
+				continue
+			}
+
+			labels := c.labelValues(metric)
+			key := fmt.Sprint(name, labels)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			ch <- prometheus.MustNewConstMetric(c.descs[name], prometheus.GaugeValue, value*f.scale, labels...)
+		}
+	}
+}
+
+func (c *Collector) fetch(ctx context.Context) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
+
+// labelValues returns the values of labelNames for a dcgm-exporter series.
+func (c *Collector) labelValues(metric *dto.Metric) []string {
+	labels := map[string]string{}
+	for _, label := range metric.Label {
+		labels[label.GetName()] = label.GetValue()
+	}
+
+	values := []string{c.node, labels["UUID"], labels["modelName"]}
+	for _, name := range labelNames[3:] {
+		value := ""
+		for _, alias := range podLabels[name] {
+			if labels[alias] != "" {
+				value = labels[alias]
+				break
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// metricValue returns the value of a gauge or counter series; dcgm-exporter
+// exposes both.
+func metricValue(metric *dto.Metric) (float64, bool) {
+	switch {
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue(), true
+	case metric.Counter != nil:
+		return metric.Counter.GetValue(), true
+	case metric.Untyped != nil:
+		return metric.Untyped.GetValue(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package dcgm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// exposition is dcgm-exporter's output for two GPUs, one attached to a
+// container, with a field that isn't exported.
+const exposition = `# HELP DCGM_FI_DEV_GPU_TEMP GPU temperature (in C).
+# TYPE DCGM_FI_DEV_GPU_TEMP gauge
+DCGM_FI_DEV_GPU_TEMP{gpu="0",UUID="GPU-a",device="nvidia0",modelName="Tesla T4",Hostname="node-a",container="trainer",namespace="ml",pod="trainer-0"} 61
+DCGM_FI_DEV_GPU_TEMP{gpu="1",UUID="GPU-b",device="nvidia1",modelName="Tesla T4",Hostname="node-a"} 35
+# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="0",UUID="GPU-a",device="nvidia0",modelName="Tesla T4",Hostname="node-a",container="trainer",namespace="ml",pod="trainer-0"} 87
+# HELP DCGM_FI_DEV_FB_USED Framebuffer memory used (in MiB).
+# TYPE DCGM_FI_DEV_FB_USED gauge
+DCGM_FI_DEV_FB_USED{gpu="1",UUID="GPU-b",device="nvidia1",modelName="Tesla T4",Hostname="node-a",container_name="notebook",pod_namespace="lab",pod_name="nb-0"} 2
+# HELP DCGM_FI_DEV_XID_ERRORS Value of the last XID error encountered.
+# TYPE DCGM_FI_DEV_XID_ERRORS gauge
+DCGM_FI_DEV_XID_ERRORS{gpu="0",UUID="GPU-a",device="nvidia0",modelName="Tesla T4",Hostname="node-a"} 0
+`
+
+func TestCollector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, exposition)
+	}))
+	defer srv.Close()
+
+	c := NewCollector(logging.Nop(), srv.URL, "node-a", "kubelet_summary", time.Second)
+
+	expected := `
+# HELP kubelet_summary_accelerator_framebuffer_used_bytes Device memory used
+# TYPE kubelet_summary_accelerator_framebuffer_used_bytes gauge
+kubelet_summary_accelerator_framebuffer_used_bytes{container="notebook",id="GPU-b",model="Tesla T4",namespace="lab",node="node-a",pod="nb-0"} 2.097152e+06
+# HELP kubelet_summary_accelerator_temperature_celsius Device temperature
+# TYPE kubelet_summary_accelerator_temperature_celsius gauge
+kubelet_summary_accelerator_temperature_celsius{container="trainer",id="GPU-a",model="Tesla T4",namespace="ml",node="node-a",pod="trainer-0"} 61
+kubelet_summary_accelerator_temperature_celsius{container="",id="GPU-b",model="Tesla T4",namespace="",node="node-a",pod=""} 35
+# HELP kubelet_summary_accelerator_utilization_ratio Ratio of time a kernel was running on the device
+# TYPE kubelet_summary_accelerator_utilization_ratio gauge
+kubelet_summary_accelerator_utilization_ratio{container="trainer",id="GPU-a",model="Tesla T4",namespace="ml",node="node-a",pod="trainer-0"} 0.87
+# HELP kubelet_summary_exporter_dcgm_errors_total Failed requests of the DCGM exporter
+# TYPE kubelet_summary_exporter_dcgm_errors_total counter
+kubelet_summary_exporter_dcgm_errors_total 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewCollector(logging.Nop(), srv.URL, "node-a", "kubelet_summary", time.Second)
+
+	expected := `
+# HELP kubelet_summary_exporter_dcgm_errors_total Failed requests of the DCGM exporter
+# TYPE kubelet_summary_exporter_dcgm_errors_total counter
+kubelet_summary_exporter_dcgm_errors_total 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
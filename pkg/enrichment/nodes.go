@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/clientmetrics"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Nodes keeps a watch-backed cache of Node objects from the API server.
+type Nodes struct {
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+	lister   corelisters.NodeLister
+	synced   cache.InformerSynced
+}
+
+// NewNodes watches the node named nodeName, or every node when nodeName is
+// empty.
+func NewNodes(clientset kubernetes.Interface, nodeName string, resync time.Duration) *Nodes {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, resync,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			if nodeName != "" {
+				opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", nodeName).String()
+			}
+		}),
+	)
+
+	informer := factory.Core().V1().Nodes()
+
+	return &Nodes{
+		factory:  factory,
+		informer: informer.Informer(),
+		lister:   informer.Lister(),
+		synced:   informer.Informer().HasSynced,
+	}
+}
+
+// Instrument reports the watch to m. It must be called before Run.
+func (n *Nodes) Instrument(m *clientmetrics.Metrics) {
+	m.Informer("nodes", n.informer)
+}
+
+// Run starts the watch and blocks until the cache has synced or ctx is done.
+func (n *Nodes) Run(ctx context.Context) error {
+	n.factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), n.synced) {
+		return fmt.Errorf("timed out waiting for node cache to sync")
+	}
+
+	return nil
+}
+
+// Get returns the cached node, if known.
+func (n *Nodes) Get(name string) (*v1.Node, bool) {
+	node, err := n.lister.Get(name)
+	if err != nil {
+		return nil, false
+	}
+
+	return node, true
+}
+
+// List returns every cached node.
+func (n *Nodes) List() []*v1.Node {
+	nodes, err := n.lister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	return nodes
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/clientmetrics"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Pods keeps a watch-backed cache of the Pod objects scheduled to a node.
+type Pods struct {
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+	lister   corelisters.PodLister
+	synced   cache.InformerSynced
+}
+
+// NewPods watches the pods scheduled to the node named nodeName.
+func NewPods(clientset kubernetes.Interface, nodeName string, resync time.Duration) *Pods {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, resync,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+		}),
+	)
+
+	informer := factory.Core().V1().Pods()
+
+	return &Pods{
+		factory:  factory,
+		informer: informer.Informer(),
+		lister:   informer.Lister(),
+		synced:   informer.Informer().HasSynced,
+	}
+}
+
+// Instrument reports the watch to m. It must be called before Run.
+func (p *Pods) Instrument(m *clientmetrics.Metrics) {
+	m.Informer("pods", p.informer)
+}
+
+// Run starts the watch and blocks until the cache has synced or ctx is done.
+func (p *Pods) Run(ctx context.Context) error {
+	p.factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), p.synced) {
+		return fmt.Errorf("timed out waiting for pod cache to sync")
+	}
+
+	return nil
+}
+
+// List returns the cached pods.
+func (p *Pods) List() []*v1.Pod {
+	pods, err := p.lister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	return pods
+}
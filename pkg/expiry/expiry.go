@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package expiry tracks the series pushed by push sinks, which unlike a
+// scraped endpoint decide themselves when a series is gone.
+package expiry
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+// Tracker remembers when every series was last gathered. Series missing
+// from a gather, e.g. after a failed scrape, are pushed with their last
+// value until they have been missing for the TTL, then expire, e.g. once
+// their pod is deleted. A TTL of 0 expires them at once.
+type Tracker struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	series map[string]*series
+
+	expired prometheus.Counter
+}
+
+type series struct {
+	family *dto.MetricFamily
+	metric *dto.Metric
+	seen   time.Time
+}
+
+// New returns a tracker whose expirations are counted in the exporter's
+// metrics of the named sink, e.g. remote_write.
+func New(sink string, ttl time.Duration) *Tracker {
+	return &Tracker{
+		ttl:    ttl,
+		series: map[string]*series{},
+		expired: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "kubelet_summary_exporter",
+			Subsystem: sink,
+			Name:      "expired_series_total",
+			Help:      "Series no longer pushed after missing from gathers for the series TTL",
+		}),
+	}
+}
+
+// Apply records the series of families as gathered at now and returns them
+// with the series missing from them that are still within the TTL. Gathered
+// families are the caller's and may be changed.
+func (t *Tracker) Apply(families []*dto.MetricFamily, now time.Time) []*dto.MetricFamily {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	gathered := map[string]struct{}{}
+	for _, family := range families {
+		byName[family.GetName()] = family
+		// Only the family's name, help and type are kept, not its other
+		// series.
+		meta := &dto.MetricFamily{Name: family.Name, Help: family.Help, Type: family.Type}
+		for _, metric := range family.GetMetric() {
+			key := seriesKey(family.GetName(), metric.GetLabel())
+			gathered[key] = struct{}{}
+			t.series[key] = &series{family: meta, metric: metric, seen: now}
+		}
+	}
+
+	for key, s := range t.series {
+		if _, ok := gathered[key]; ok {
+			continue
+		}
+		if now.Sub(s.seen) >= t.ttl {
+			delete(t.series, key)
+			t.expired.Inc()
+			continue
+		}
+
+		family, ok := byName[s.family.GetName()]
+		if !ok {
+			family = &dto.MetricFamily{Name: s.family.Name, Help: s.family.Help, Type: s.family.Type}
+			byName[family.GetName()] = family
+			families = append(families, family)
+		}
+		if family.GetType() == s.family.GetType() {
+			family.Metric = append(family.Metric, s.metric)
+		}
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+	return families
+}
+
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	t.expired.Describe(ch)
+}
+
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	t.expired.Collect(ch)
+}
+
+// seriesKey identifies a series by its family and sorted labels.
+func seriesKey(name string, pairs []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, pair := range pairs {
+		b.WriteByte(model.SeparatorByte)
+		b.WriteString(pair.GetName())
+		b.WriteByte(model.SeparatorByte)
+		b.WriteString(pair.GetValue())
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package expiry
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func gauge(name string, values map[string]float64) *dto.MetricFamily {
+	family := &dto.MetricFamily{Name: proto.String(name), Help: proto.String(name), Type: dto.MetricType_GAUGE.Enum()}
+	for pod, value := range values {
+		family.Metric = append(family.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{{Name: proto.String("pod"), Value: proto.String(pod)}},
+			Gauge: &dto.Gauge{Value: proto.Float64(value)},
+		})
+	}
+	return family
+}
+
+// pushed returns the pods of the series of families by family name.
+func pushed(families []*dto.MetricFamily) map[string][]string {
+	pods := map[string][]string{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			pods[family.GetName()] = append(pods[family.GetName()], metric.GetLabel()[0].GetValue())
+		}
+	}
+	return pods
+}
+
+func TestTracker(t *testing.T) {
+	tracker := New("remote_write", time.Minute)
+	start := time.Unix(0, 0)
+
+	tracker.Apply([]*dto.MetricFamily{
+		gauge("pod_cpu", map[string]float64{"web": 1, "batch": 2}),
+		gauge("pod_memory", map[string]float64{"batch": 3}),
+	}, start)
+
+	// The batch pod is missing, but within the TTL.
+	got := pushed(tracker.Apply([]*dto.MetricFamily{gauge("pod_cpu", map[string]float64{"web": 1})}, start.Add(30*time.Second)))
+	if len(got["pod_cpu"]) != 2 || len(got["pod_memory"]) != 1 {
+		t.Errorf("expected missing series to be pushed within the TTL, got %v", got)
+	}
+
+	// The web pod was seen again, the batch pod is gone for good.
+	got = pushed(tracker.Apply([]*dto.MetricFamily{gauge("pod_cpu", map[string]float64{"web": 1})}, start.Add(time.Minute)))
+	if len(got["pod_cpu"]) != 1 || got["pod_cpu"][0] != "web" || len(got["pod_memory"]) != 0 {
+		t.Errorf("expected series missing for the TTL to expire, got %v", got)
+	}
+
+	expected := `
+# HELP kubelet_summary_exporter_remote_write_expired_series_total Series no longer pushed after missing from gathers for the series TTL
+# TYPE kubelet_summary_exporter_remote_write_expired_series_total counter
+kubelet_summary_exporter_remote_write_expired_series_total 2
+`
+	if err := testutil.CollectAndCompare(tracker, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTrackerNoTTL(t *testing.T) {
+	tracker := New("otlp", 0)
+	start := time.Unix(0, 0)
+
+	tracker.Apply([]*dto.MetricFamily{gauge("pod_cpu", map[string]float64{"web": 1, "batch": 2})}, start)
+	got := pushed(tracker.Apply([]*dto.MetricFamily{gauge("pod_cpu", map[string]float64{"web": 1})}, start.Add(time.Second)))
+	if len(got["pod_cpu"]) != 1 {
+		t.Errorf("expected missing series to expire at once, got %v", got)
+	}
+	if n := testutil.ToFloat64(tracker.expired); n != 1 {
+		t.Errorf("expected 1 expired series, got %v", n)
+	}
+}
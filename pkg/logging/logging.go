@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package logging defines the minimal logger the exporter depends on so
+// embedders can plug in their own logging library.
+package logging
+
+import (
+	"log/slog"
+
+	"go.uber.org/zap"
+)
+
+var _ Logger = (*slog.Logger)(nil)
+
+// Logger logs a message with alternating key/value pairs. Its method set
+// matches *slog.Logger, which can be used directly; logr users can go
+// through logr's slog bridge.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+}
+
+// NewZap adapts a zap logger.
+func NewZap(logger *zap.Logger) Logger {
+	return zapLogger{logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+type zapLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+func (l zapLogger) Debug(msg string, keysAndValues ...any) { l.sugar.Debugw(msg, keysAndValues...) }
+func (l zapLogger) Info(msg string, keysAndValues ...any)  { l.sugar.Infow(msg, keysAndValues...) }
+func (l zapLogger) Warn(msg string, keysAndValues ...any)  { l.sugar.Warnw(msg, keysAndValues...) }
+func (l zapLogger) Error(msg string, keysAndValues ...any) { l.sugar.Errorw(msg, keysAndValues...) }
+
+// Nop returns a logger that discards everything.
+func Nop() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// With returns a logger that adds keysAndValues to every message.
+func With(logger Logger, keysAndValues ...any) Logger {
+	return withLogger{logger: logger, keysAndValues: keysAndValues}
+}
+
+type withLogger struct {
+	logger        Logger
+	keysAndValues []any
+}
+
+func (l withLogger) with(keysAndValues []any) []any {
+	kvs := make([]any, 0, len(l.keysAndValues)+len(keysAndValues))
+	kvs = append(kvs, l.keysAndValues...)
+	return append(kvs, keysAndValues...)
+}
+
+func (l withLogger) Debug(msg string, keysAndValues ...any) {
+	l.logger.Debug(msg, l.with(keysAndValues)...)
+}
+
+func (l withLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Info(msg, l.with(keysAndValues)...)
+}
+
+func (l withLogger) Warn(msg string, keysAndValues ...any) {
+	l.logger.Warn(msg, l.with(keysAndValues)...)
+}
+
+func (l withLogger) Error(msg string, keysAndValues ...any) {
+	l.logger.Error(msg, l.with(keysAndValues)...)
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package merge
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// SourceLabel is added to series of a metric family that a less preferred
+// source exports under the same name as a preferred one.
+const SourceLabel = "source"
+
+// Source is a named set of metrics, typically one registry per collector
+// kind, so that collectors exporting the same metric names never collide in
+// a single registry.
+type Source struct {
+	Name     string
+	Gatherer prometheus.Gatherer
+	// DropDuplicates drops the source's families that a more preferred
+	// source exports too, instead of labeling their series.
+	DropDuplicates bool
+}
+
+// Gatherer merges the metrics of several sources. Sources are given in order
+// of preference: when a family is exported by more than one of them, the
+// most preferred keeps it unchanged and the others' series get a source
+// label, unless their source drops duplicates. Duplicates of a different
+// type can't share the family and are dropped.
+type Gatherer struct {
+	logger  logging.Logger
+	sources []Source
+	labels  []*dto.LabelPair
+
+	mu     sync.Mutex
+	warned map[string]struct{}
+}
+
+// New returns a Gatherer for sources, most preferred first.
+func New(logger logging.Logger, sources ...Source) *Gatherer {
+	return &Gatherer{
+		logger:  logger,
+		sources: sources,
+		warned:  map[string]struct{}{},
+	}
+}
+
+// AddLabels adds constant labels to every series gathered, e.g. the cluster
+// of the exporter, unless a series has the label set already.
+func (g *Gatherer) AddLabels(labels map[string]string) {
+	for name, value := range labels {
+		g.labels = append(g.labels, &dto.LabelPair{Name: stringPtr(name), Value: stringPtr(value)})
+	}
+}
+
+// contextGatherer is a gatherer whose scrapes can be bounded by a context.
+type contextGatherer interface {
+	GatherContext(ctx context.Context) ([]*dto.MetricFamily, error)
+}
+
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	return g.GatherContext(context.Background())
+}
+
+// GatherContext is Gather passing ctx to the sources supporting it.
+func (g *Gatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	var errs prometheus.MultiError
+	merged := map[string]*dto.MetricFamily{}
+
+	for _, source := range g.sources {
+		var families []*dto.MetricFamily
+		var err error
+		if cg, ok := source.Gatherer.(contextGatherer); ok {
+			families, err = cg.GatherContext(ctx)
+		} else {
+			families, err = source.Gatherer.Gather()
+		}
+		if err != nil {
+			errs.Append(err)
+		}
+
+		for _, family := range families {
+			existing, ok := merged[family.GetName()]
+			if !ok {
+				merged[family.GetName()] = family
+				continue
+			}
+
+			if source.DropDuplicates {
+				continue
+			}
+
+			if existing.GetType() != family.GetType() {
+				g.warnOnce(family.GetName(), source.Name, "dropping duplicate metric family of a different type")
+				continue
+			}
+
+			g.warnOnce(family.GetName(), source.Name, "labeling duplicate metric family with its source")
+			for _, metric := range family.GetMetric() {
+				metric.Label = append(metric.Label, &dto.LabelPair{
+					Name:  stringPtr(SourceLabel),
+					Value: stringPtr(source.Name),
+				})
+				sort.Sort(labelPairs(metric.Label))
+			}
+			existing.Metric = append(existing.Metric, family.GetMetric()...)
+		}
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(merged))
+	for _, family := range merged {
+		if len(g.labels) > 0 {
+			for _, metric := range family.GetMetric() {
+				metric.Label = g.withLabels(metric.Label)
+			}
+		}
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+
+	return families, errs.MaybeUnwrap()
+}
+
+// withLabels adds the constant labels a series doesn't have to its labels.
+// An empty label is the same as none to Prometheus and is set as well.
+func (g *Gatherer) withLabels(labels []*dto.LabelPair) []*dto.LabelPair {
+	added := false
+	for _, label := range g.labels {
+		i := labelIndex(labels, label.GetName())
+		switch {
+		case i < 0:
+			labels = append(labels, label)
+			added = true
+		case labels[i].GetValue() == "":
+			labels[i] = label
+		}
+	}
+	if added {
+		sort.Sort(labelPairs(labels))
+	}
+	return labels
+}
+
+func labelIndex(labels []*dto.LabelPair, name string) int {
+	for i, label := range labels {
+		if label.GetName() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Gatherer) warnOnce(family, source, msg string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := source + "/" + family
+	if _, ok := g.warned[key]; ok {
+		return
+	}
+	g.warned[key] = struct{}{}
+
+	g.logger.Warn(msg, "family", family, "source", source)
+}
+
+type labelPairs []*dto.LabelPair
+
+func (l labelPairs) Len() int           { return len(l) }
+func (l labelPairs) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l labelPairs) Less(i, j int) bool { return l[i].GetName() < l[j].GetName() }
+
+func stringPtr(s string) *string {
+	return &s
+}
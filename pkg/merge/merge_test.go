@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package merge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestGatherer(t *testing.T) {
+	summary := prometheus.NewRegistry()
+	cpu := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "container_cpu_usage", Help: "From the summary"}, []string{"pod"})
+	cpu.WithLabelValues("web").Set(1)
+	summary.MustRegister(cpu)
+
+	cadvisor := prometheus.NewRegistry()
+	cpuDup := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "container_cpu_usage", Help: "From cadvisor"}, []string{"pod"})
+	cpuDup.WithLabelValues("web").Set(2)
+	cadvisor.MustRegister(cpuDup)
+	memory := prometheus.NewCounter(prometheus.CounterOpts{Name: "memory_total", Help: "Counter"})
+	memory.Add(3)
+	cadvisor.MustRegister(memory)
+
+	resource := prometheus.NewRegistry()
+	memoryGauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "memory_total", Help: "Gauge"})
+	memoryGauge.Set(4)
+	resource.MustRegister(memoryGauge)
+
+	g := New(logging.Nop(),
+		Source{Name: "summary", Gatherer: summary},
+		Source{Name: "cadvisor", Gatherer: cadvisor},
+		Source{Name: "resource", Gatherer: resource},
+	)
+
+	expected := `
+# HELP container_cpu_usage From the summary
+# TYPE container_cpu_usage gauge
+container_cpu_usage{pod="web"} 1
+container_cpu_usage{pod="web",source="cadvisor"} 2
+# HELP memory_total Counter
+# TYPE memory_total counter
+memory_total 3
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGathererDropDuplicates(t *testing.T) {
+	summary := prometheus.NewRegistry()
+	cpu := prometheus.NewGauge(prometheus.GaugeOpts{Name: "container_cpu_usage", Help: "From the summary"})
+	cpu.Set(1)
+	summary.MustRegister(cpu)
+
+	resource := prometheus.NewRegistry()
+	cpuDup := prometheus.NewGauge(prometheus.GaugeOpts{Name: "container_cpu_usage", Help: "From resource"})
+	cpuDup.Set(2)
+	resource.MustRegister(cpuDup)
+	memory := prometheus.NewGauge(prometheus.GaugeOpts{Name: "container_memory", Help: "From resource"})
+	memory.Set(3)
+	resource.MustRegister(memory)
+
+	g := New(logging.Nop(),
+		Source{Name: "summary", Gatherer: summary},
+		Source{Name: "resource", Gatherer: resource, DropDuplicates: true},
+	)
+
+	expected := `
+# HELP container_cpu_usage From the summary
+# TYPE container_cpu_usage gauge
+container_cpu_usage 1
+# HELP container_memory From resource
+# TYPE container_memory gauge
+container_memory 3
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGathererLabels(t *testing.T) {
+	summary := prometheus.NewRegistry()
+	cpu := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "container_cpu_usage", Help: "From the summary"}, []string{"cluster", "pod"})
+	cpu.WithLabelValues("", "web").Set(1)
+	cpu.WithLabelValues("staging", "db").Set(2)
+	summary.MustRegister(cpu)
+
+	g := New(logging.Nop(), Source{Name: "summary", Gatherer: summary})
+	g.AddLabels(map[string]string{"cluster": "prod", "region": "eu"})
+
+	expected := `
+# HELP container_cpu_usage From the summary
+# TYPE container_cpu_usage gauge
+container_cpu_usage{cluster="prod",pod="web",region="eu"} 1
+container_cpu_usage{cluster="staging",pod="db",region="eu"} 2
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+type ctxGatherer struct {
+	ctx context.Context
+}
+
+func (g *ctxGatherer) Gather() ([]*dto.MetricFamily, error) {
+	return nil, nil
+}
+
+func (g *ctxGatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	g.ctx = ctx
+	return nil, nil
+}
+
+func TestGatherContext(t *testing.T) {
+	source := &ctxGatherer{}
+	g := New(logging.Nop(), Source{Name: "summary", Gatherer: source})
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "scrape")
+	if _, err := g.GatherContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.ctx != ctx {
+		t.Error("expected the context to be passed to the source")
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package nodeproxy requests kubelet endpoints through the api-server's node
+// proxy, for clusters where kubelet's port is firewalled from pods.
+package nodeproxy
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Proxy builds node proxy URLs and the transport authenticating to the
+// api-server. Requests of every node share its rate limit, so scraping many
+// kubelets doesn't overload the api-server.
+type Proxy struct {
+	server    *url.URL
+	transport http.RoundTripper
+}
+
+// New returns a proxy through the api-server of config, sending at most qps
+// requests per second with bursts of burst.
+func New(config *rest.Config, qps float32, burst int) (*Proxy, error) {
+	server, _, err := rest.DefaultServerURL(config.Host, "", schema.GroupVersion{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proxy{
+		server: server,
+		transport: &rateLimited{
+			RoundTripper: transport,
+			limiter:      flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		},
+	}, nil
+}
+
+// URL returns the URL of kubelet's path on node, which may carry kubelet's
+// port as in node:10250.
+func (p *Proxy) URL(node, kubeletPath string, query url.Values) string {
+	u := *p.server
+	u.Path = path.Join(u.Path, "/api/v1/nodes", node, "proxy", kubeletPath)
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// Transport returns the transport of requests through the proxy.
+func (p *Proxy) Transport() http.RoundTripper {
+	return p.transport
+}
+
+// rateLimited waits for the limiter before every request.
+type rateLimited struct {
+	http.RoundTripper
+	limiter flowcontrol.RateLimiter
+}
+
+func (r *rateLimited) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := r.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return r.RoundTripper.RoundTrip(req)
+}
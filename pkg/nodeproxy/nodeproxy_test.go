@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package nodeproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestProxy(t *testing.T) {
+	var path, authorization string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer apiServer.Close()
+
+	proxy, err := New(&rest.Config{Host: apiServer.URL, BearerToken: "api-token"}, 100, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := proxy.URL("node-a", "/stats/summary", url.Values{"only_cpu_and_memory": {"true"}})
+	if want := apiServer.URL + "/api/v1/nodes/node-a/proxy/stats/summary?only_cpu_and_memory=true"; u != want {
+		t.Errorf("expected %s, got %s", want, u)
+	}
+
+	client := &http.Client{Transport: proxy.Transport()}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if path != "/api/v1/nodes/node-a/proxy/stats/summary" || authorization != "Bearer api-token" {
+		t.Errorf("expected an authenticated request of the node's stats, got %s with %q", path, authorization)
+	}
+}
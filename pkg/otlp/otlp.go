@@ -0,0 +1,381 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package otlp pushes gathered metrics to an OpenTelemetry collector over
+// OTLP/HTTP, for clusters that ship metrics through a collector rather than
+// being scraped by Prometheus.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/expiry"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/utils"
+)
+
+// Resource attributes set from the options.
+const (
+	AttributeNode    = "k8s.node.name"
+	AttributeCluster = "k8s.cluster.name"
+)
+
+// Options configure a Pusher.
+type Options struct {
+	// Endpoint is the URL metrics are posted to, e.g.
+	// http://otel-collector:4318/v1/metrics.
+	Endpoint string
+	// Headers are added to every request, e.g. for authentication.
+	Headers map[string]string
+	// Interval is the time between pushes.
+	Interval time.Duration
+	// Timeout limits every push.
+	Timeout time.Duration
+	// Resource holds the attributes describing the source of every metric.
+	Resource map[string]string
+	// SeriesTTL is how long series missing from gathers are still pushed
+	// with their last value before they expire.
+	SeriesTTL time.Duration
+}
+
+// Pusher converts gathered metric families to OTLP and pushes them.
+type Pusher struct {
+	logger   logging.Logger
+	gatherer prometheus.Gatherer
+	options  Options
+	client   *http.Client
+	start    time.Time
+	expiry   *expiry.Tracker
+
+	mu     sync.Mutex
+	pushes map[string]float64
+
+	desc *prometheus.Desc
+}
+
+// NewPusher returns a pusher of the metrics of gatherer.
+func NewPusher(logger logging.Logger, gatherer prometheus.Gatherer, options Options) *Pusher {
+	return &Pusher{
+		logger:   logger,
+		gatherer: gatherer,
+		options:  options,
+		client:   &http.Client{Transport: utils.NewTransport(nil), Timeout: options.Timeout},
+		start:    time.Now(),
+		expiry:   expiry.New("otlp", options.SeriesTTL),
+		pushes:   map[string]float64{"success": 0, "failure": 0},
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "otlp", "pushes_total"),
+			"Pushes of metrics to the OTLP endpoint by result",
+			[]string{"result"},
+			nil),
+	}
+}
+
+// Run pushes every interval until ctx is done. Failed pushes are logged and
+// counted, and the next push sends current values again.
+func (p *Pusher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		err := p.Push(ctx)
+		if err != nil {
+			p.logger.Error("failed to push metrics over otlp", "endpoint", p.options.Endpoint, "error", err)
+		}
+
+		p.mu.Lock()
+		if err != nil {
+			p.pushes["failure"]++
+		} else {
+			p.pushes["success"]++
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Push gathers and pushes metrics once.
+func (p *Pusher) Push(ctx context.Context) error {
+	families, err := p.gatherer.Gather()
+	if len(families) == 0 && err != nil {
+		return err
+	}
+	if err != nil {
+		p.logger.Warn("pushing partially gathered metrics", "error", err)
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(p.request(p.expiry.Apply(families, now), now))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.options.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.options.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *Pusher) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.desc
+	p.expiry.Describe(ch)
+}
+
+func (p *Pusher) Collect(ch chan<- prometheus.Metric) {
+	p.expiry.Collect(ch)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, result := range []string{"success", "failure"} {
+		ch <- prometheus.MustNewConstMetric(p.desc, prometheus.CounterValue, p.pushes[result], result)
+	}
+}
+
+// The OTLP/HTTP JSON encoding of ExportMetricsServiceRequest. 64 bit
+// integers are strings, as in the protobuf JSON mapping.
+type (
+	exportRequest struct {
+		ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+	}
+	resourceMetrics struct {
+		Resource     resource       `json:"resource"`
+		ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+	}
+	resource struct {
+		Attributes []attribute `json:"attributes"`
+	}
+	scopeMetrics struct {
+		Scope   scope    `json:"scope"`
+		Metrics []metric `json:"metrics"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	attribute struct {
+		Key   string         `json:"key"`
+		Value attributeValue `json:"value"`
+	}
+	attributeValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	metric struct {
+		Name        string     `json:"name"`
+		Description string     `json:"description,omitempty"`
+		Gauge       *gauge     `json:"gauge,omitempty"`
+		Sum         *sum       `json:"sum,omitempty"`
+		Histogram   *histogram `json:"histogram,omitempty"`
+		Summary     *summary   `json:"summary,omitempty"`
+	}
+	gauge struct {
+		DataPoints []numberDataPoint `json:"dataPoints"`
+	}
+	sum struct {
+		DataPoints             []numberDataPoint `json:"dataPoints"`
+		AggregationTemporality int               `json:"aggregationTemporality"`
+		IsMonotonic            bool              `json:"isMonotonic"`
+	}
+	histogram struct {
+		DataPoints             []histogramDataPoint `json:"dataPoints"`
+		AggregationTemporality int                  `json:"aggregationTemporality"`
+	}
+	summary struct {
+		DataPoints []summaryDataPoint `json:"dataPoints"`
+	}
+	numberDataPoint struct {
+		Attributes        []attribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string      `json:"timeUnixNano"`
+		AsDouble          float64     `json:"asDouble"`
+	}
+	histogramDataPoint struct {
+		Attributes        []attribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		TimeUnixNano      string      `json:"timeUnixNano"`
+		Count             string      `json:"count"`
+		Sum               float64     `json:"sum"`
+		BucketCounts      []string    `json:"bucketCounts"`
+		ExplicitBounds    []float64   `json:"explicitBounds"`
+	}
+	summaryDataPoint struct {
+		Attributes     []attribute     `json:"attributes,omitempty"`
+		TimeUnixNano   string          `json:"timeUnixNano"`
+		Count          string          `json:"count"`
+		Sum            float64         `json:"sum"`
+		QuantileValues []quantileValue `json:"quantileValues"`
+	}
+	quantileValue struct {
+		Quantile float64 `json:"quantile"`
+		Value    float64 `json:"value"`
+	}
+)
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationCumulative = 2
+
+// request converts metric families gathered at now. Counters become
+// cumulative monotonic sums starting when the pusher was created, gauges
+// and untyped metrics become gauges. Values that JSON can't hold, like NaN,
+// are dropped.
+func (p *Pusher) request(families []*dto.MetricFamily, now time.Time) exportRequest {
+	start := nanos(p.start)
+
+	var metrics []metric
+	for _, family := range families {
+		m := metric{Name: family.GetName(), Description: family.GetHelp()}
+
+		for _, dm := range family.GetMetric() {
+			at := nanos(now)
+			if dm.TimestampMs != nil {
+				at = nanos(time.UnixMilli(dm.GetTimestampMs()))
+			}
+			attributes := labelAttributes(dm.GetLabel())
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				if m.Sum == nil {
+					m.Sum = &sum{AggregationTemporality: aggregationCumulative, IsMonotonic: true}
+				}
+				if value := dm.GetCounter().GetValue(); finite(value) {
+					m.Sum.DataPoints = append(m.Sum.DataPoints, numberDataPoint{attributes, start, at, value})
+				}
+			case dto.MetricType_HISTOGRAM:
+				if m.Histogram == nil {
+					m.Histogram = &histogram{AggregationTemporality: aggregationCumulative}
+				}
+				m.Histogram.DataPoints = append(m.Histogram.DataPoints, histogramPoint(dm.GetHistogram(), attributes, start, at))
+			case dto.MetricType_SUMMARY:
+				if m.Summary == nil {
+					m.Summary = &summary{}
+				}
+				m.Summary.DataPoints = append(m.Summary.DataPoints, summaryPoint(dm.GetSummary(), attributes, at))
+			default:
+				if m.Gauge == nil {
+					m.Gauge = &gauge{}
+				}
+				value := dm.GetGauge().GetValue()
+				if dm.Untyped != nil {
+					value = dm.GetUntyped().GetValue()
+				}
+				if finite(value) {
+					m.Gauge.DataPoints = append(m.Gauge.DataPoints, numberDataPoint{attributes, "", at, value})
+				}
+			}
+		}
+
+		metrics = append(metrics, m)
+	}
+
+	return exportRequest{ResourceMetrics: []resourceMetrics{{
+		Resource: resource{Attributes: mapAttributes(p.options.Resource)},
+		ScopeMetrics: []scopeMetrics{{
+			Scope:   scope{Name: "kubelet-summary-exporter"},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// histogramPoint converts Prometheus' cumulative buckets to OTLP's counts
+// per bucket, the last one counting observations above every bound.
+func histogramPoint(h *dto.Histogram, attributes []attribute, start, at string) histogramDataPoint {
+	point := histogramDataPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      at,
+		Count:             strconv.FormatUint(h.GetSampleCount(), 10),
+		Sum:               h.GetSampleSum(),
+		ExplicitBounds:    []float64{},
+	}
+
+	var previous uint64
+	for _, bucket := range h.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), +1) {
+			continue
+		}
+		point.ExplicitBounds = append(point.ExplicitBounds, bucket.GetUpperBound())
+		point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+		previous = bucket.GetCumulativeCount()
+	}
+	point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(h.GetSampleCount()-previous, 10))
+
+	return point
+}
+
+func summaryPoint(s *dto.Summary, attributes []attribute, at string) summaryDataPoint {
+	point := summaryDataPoint{
+		Attributes:     attributes,
+		TimeUnixNano:   at,
+		Count:          strconv.FormatUint(s.GetSampleCount(), 10),
+		Sum:            s.GetSampleSum(),
+		QuantileValues: []quantileValue{},
+	}
+	for _, q := range s.GetQuantile() {
+		if finite(q.GetValue()) {
+			point.QuantileValues = append(point.QuantileValues, quantileValue{q.GetQuantile(), q.GetValue()})
+		}
+	}
+
+	return point
+}
+
+func labelAttributes(labels []*dto.LabelPair) []attribute {
+	attributes := make([]attribute, 0, len(labels))
+	for _, label := range labels {
+		attributes = append(attributes, attribute{Key: label.GetName(), Value: attributeValue{label.GetValue()}})
+	}
+	return attributes
+}
+
+func mapAttributes(values map[string]string) []attribute {
+	attributes := make([]attribute, 0, len(values))
+	for key, value := range values {
+		attributes = append(attributes, attribute{Key: key, Value: attributeValue{value}})
+	}
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Key < attributes[j].Key })
+	return attributes
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func finite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestPush(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "kubelet_summary_node_memory_working_set_bytes", Help: "Working set"}, []string{"node"})
+	gauge.WithLabelValues("node-a").Set(1024)
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total", Help: "Requests"})
+	counter.Add(3)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Help: "Latency", Buckets: []float64{0.1, 1}})
+	for _, v := range []float64{0.05, 0.5, 0.5, 5} {
+		histogram.Observe(v)
+	}
+	registry.MustRegister(gauge, counter, histogram)
+
+	var body exportRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	p := NewPusher(logging.Nop(), registry, Options{
+		Endpoint: server.URL + "/v1/metrics",
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Timeout:  time.Second,
+		Resource: map[string]string{AttributeNode: "node-a", AttributeCluster: "prod"},
+	})
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Get("Content-Type") != "application/json" || header.Get("Authorization") != "Bearer token" {
+		t.Errorf("unexpected headers %v", header)
+	}
+
+	rm := body.ResourceMetrics[0]
+	wantResource := []attribute{
+		{AttributeCluster, attributeValue{"prod"}},
+		{AttributeNode, attributeValue{"node-a"}},
+	}
+	if !reflect.DeepEqual(rm.Resource.Attributes, wantResource) {
+		t.Errorf("expected resource %v, got %v", wantResource, rm.Resource.Attributes)
+	}
+
+	metrics := map[string]metric{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	g := metrics["kubelet_summary_node_memory_working_set_bytes"].Gauge
+	if g == nil || len(g.DataPoints) != 1 || g.DataPoints[0].AsDouble != 1024 ||
+		!reflect.DeepEqual(g.DataPoints[0].Attributes, []attribute{{"node", attributeValue{"node-a"}}}) {
+		t.Errorf("unexpected gauge %+v", g)
+	}
+
+	s := metrics["requests_total"].Sum
+	if s == nil || !s.IsMonotonic || s.AggregationTemporality != aggregationCumulative ||
+		s.DataPoints[0].AsDouble != 3 || s.DataPoints[0].StartTimeUnixNano == "" {
+		t.Errorf("unexpected sum %+v", s)
+	}
+
+	h := metrics["latency_seconds"].Histogram
+	if h == nil {
+		t.Fatal("expected a histogram")
+	}
+	point := h.DataPoints[0]
+	if point.Count != "4" || point.Sum != 6.05 ||
+		!reflect.DeepEqual(point.ExplicitBounds, []float64{0.1, 1}) ||
+		!reflect.DeepEqual(point.BucketCounts, []string{"1", "2", "1"}) {
+		t.Errorf("unexpected histogram point %+v", point)
+	}
+}
+
+func TestPushFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	p := NewPusher(logging.Nop(), prometheus.NewRegistry(), Options{Endpoint: server.URL, Timeout: time.Second})
+	if err := p.Push(context.Background()); err == nil {
+		t.Error("expected a push rejected by the endpoint to fail")
+	}
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package passthrough re-exports metrics kubelet serves in the Prometheus
+// text format itself, like /metrics/cadvisor, for data the stats summary
+// lacks, e.g. CPU throttling.
+package passthrough
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// Getter requests kubelet endpoints. *scraper.Scraper is one.
+type Getter interface {
+	GetKubelet(ctx context.Context, path string) (*http.Response, error)
+}
+
+// Options select and relabel the metrics re-exported.
+type Options struct {
+	// Path is kubelet's endpoint, e.g. /metrics/cadvisor.
+	Path string
+	// Allow holds regular expressions matching the names of the families
+	// kept, all of them when empty.
+	Allow []string
+	// DropLabels are removed from every series, e.g. cadvisor's cgroup id.
+	DropLabels []string
+	// Labels are added to every series not having them already.
+	Labels map[string]string
+	// Prefix is prepended to the names of the families, e.g. to tell them
+	// apart from families of the same name of another source.
+	Prefix string
+	// Timeout bounds each request to kubelet.
+	Timeout time.Duration
+}
+
+// Gatherer gathers the metrics of a kubelet endpoint on every Gather. A
+// failed request is logged and counted rather than returned, so it doesn't
+// fail the exporter's other metrics.
+type Gatherer struct {
+	logger     logging.Logger
+	getter     Getter
+	path       string
+	allow      *regexp.Regexp
+	dropLabels map[string]struct{}
+	labels     []*dto.LabelPair
+	prefix     string
+	timeout    time.Duration
+
+	errors prometheus.Counter
+}
+
+// New returns a gatherer of the endpoint of o on the kubelet of getter.
+func New(logger logging.Logger, getter Getter, o Options) (*Gatherer, error) {
+	g := &Gatherer{
+		logger:     logger,
+		getter:     getter,
+		path:       o.Path,
+		dropLabels: map[string]struct{}{},
+		prefix:     o.Prefix,
+		timeout:    o.Timeout,
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   "kubelet_summary_exporter",
+			Subsystem:   "passthrough",
+			Name:        "errors_total",
+			Help:        "Failed requests of a kubelet metrics endpoint passed through",
+			ConstLabels: prometheus.Labels{"path": o.Path},
+		}),
+	}
+
+	if len(o.Allow) > 0 {
+		allow, err := regexp.Compile("^(?:" + strings.Join(o.Allow, "|") + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow list: %w", err)
+		}
+		g.allow = allow
+	}
+
+	for _, label := range o.DropLabels {
+		g.dropLabels[label] = struct{}{}
+	}
+	for name, value := range o.Labels {
+		g.labels = append(g.labels, &dto.LabelPair{Name: stringPtr(name), Value: stringPtr(value)})
+	}
+
+	return g, nil
+}
+
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	return g.GatherContext(context.Background())
+}
+
+// GatherContext is Gather with the kubelet request bounded by ctx.
+func (g *Gatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+
+	parsed, err := g.fetch(ctx)
+	if err != nil {
+		g.errors.Inc()
+		g.logger.Warn("failed to fetch kubelet metrics", "path", g.path, "error", err)
+		return nil, nil
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for name, family := range parsed {
+		if g.allow != nil && !g.allow.MatchString(name) {
+			continue
+		}
+		g.relabel(family)
+		family.Name = stringPtr(g.prefix + name)
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+
+	return families, nil
+}
+
+func (g *Gatherer) fetch(ctx context.Context) (map[string]*dto.MetricFamily, error) {
+	resp, err := g.getter.GetKubelet(ctx, g.path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
+
+// relabel drops and adds the labels of the series of family. Series left
+// with the same labels after dropping some are dropped but for the first.
+func (g *Gatherer) relabel(family *dto.MetricFamily) {
+	seen := map[string]struct{}{}
+	metrics := family.Metric[:0]
+	for _, metric := range family.Metric {
+		labels := make([]*dto.LabelPair, 0, len(metric.Label)+len(g.labels))
+		for _, label := range metric.Label {
+			if _, ok := g.dropLabels[label.GetName()]; !ok {
+				labels = append(labels, label)
+			}
+		}
+		for _, label := range g.labels {
+			if !hasLabel(labels, label.GetName()) {
+				labels = append(labels, label)
+			}
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+		key := labelsKey(labels)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		metric.Label = labels
+		metrics = append(metrics, metric)
+	}
+	family.Metric = metrics
+}
+
+func (g *Gatherer) Describe(ch chan<- *prometheus.Desc) {
+	g.errors.Describe(ch)
+}
+
+func (g *Gatherer) Collect(ch chan<- prometheus.Metric) {
+	g.errors.Collect(ch)
+}
+
+func hasLabel(labels []*dto.LabelPair, name string) bool {
+	for _, label := range labels {
+		if label.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func labelsKey(labels []*dto.LabelPair) string {
+	var b strings.Builder
+	for _, label := range labels {
+		b.WriteString(label.GetName())
+		b.WriteByte(0)
+		b.WriteString(label.GetValue())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package passthrough
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+type getterFunc func(ctx context.Context, path string) (*http.Response, error)
+
+func (f getterFunc) GetKubelet(ctx context.Context, path string) (*http.Response, error) {
+	return f(ctx, path)
+}
+
+const cadvisor = `# HELP container_cpu_cfs_throttled_periods_total Number of throttled period intervals.
+# TYPE container_cpu_cfs_throttled_periods_total counter
+container_cpu_cfs_throttled_periods_total{container="app",id="/kubepods/pod1/a",name="a",namespace="default",pod="web"} 3
+container_cpu_cfs_throttled_periods_total{container="app",id="/kubepods/pod1/b",name="b",namespace="default",pod="web"} 4
+container_cpu_cfs_throttled_periods_total{container="db",id="/kubepods/pod2/c",name="c",namespace="default",pod="db",node="other"} 1
+# HELP container_memory_cache Number of bytes of page cache memory.
+# TYPE container_memory_cache gauge
+container_memory_cache{container="app",id="/kubepods/pod1/a",namespace="default",pod="web"} 1024
+`
+
+func TestGatherer(t *testing.T) {
+	var requested string
+	g, err := New(logging.Nop(), getterFunc(func(ctx context.Context, path string) (*http.Response, error) {
+		requested = path
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(cadvisor))}, nil
+	}), Options{
+		Path:       "/metrics/cadvisor",
+		Allow:      []string{"container_cpu_cfs_.*"},
+		DropLabels: []string{"id", "name"},
+		Labels:     map[string]string{"node": "node-a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP container_cpu_cfs_throttled_periods_total Number of throttled period intervals.
+# TYPE container_cpu_cfs_throttled_periods_total counter
+container_cpu_cfs_throttled_periods_total{container="app",namespace="default",node="node-a",pod="web"} 3
+container_cpu_cfs_throttled_periods_total{container="db",namespace="default",node="other",pod="db"} 1
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+	if requested != "/metrics/cadvisor" {
+		t.Errorf("expected /metrics/cadvisor to be requested, got %s", requested)
+	}
+}
+
+func TestGathererErrors(t *testing.T) {
+	g, err := New(logging.Nop(), getterFunc(func(ctx context.Context, path string) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), Options{Path: "/metrics/cadvisor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := g.Gather()
+	if err != nil || len(families) != 0 {
+		t.Errorf("expected no metrics and no error, got %v, %v", families, err)
+	}
+
+	expected := `
+# HELP kubelet_summary_exporter_passthrough_errors_total Failed requests of a kubelet metrics endpoint passed through
+# TYPE kubelet_summary_exporter_passthrough_errors_total counter
+kubelet_summary_exporter_passthrough_errors_total{path="/metrics/cadvisor"} 1
+`
+	if err := testutil.CollectAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := New(logging.Nop(), nil, Options{Allow: []string{"("}}); err == nil {
+		t.Error("expected an invalid allow list to fail")
+	}
+}
+
+func TestGathererPrefix(t *testing.T) {
+	g, err := New(logging.Nop(), getterFunc(func(ctx context.Context, path string) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(cadvisor))}, nil
+	}), Options{Path: "/metrics/resource", Allow: []string{"container_memory_.*"}, Prefix: "kubelet_resource_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP kubelet_resource_container_memory_cache Number of bytes of page cache memory.
+# TYPE kubelet_resource_container_memory_cache gauge
+kubelet_resource_container_memory_cache{container="app",id="/kubepods/pod1/a",namespace="default",pod="web"} 1024
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package psi exports the pressure stall information of the node and its
+// pods from a cgroup v2 mount, the saturation signal the stats summary
+// lacks.
+package psi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// resources are the resources with pressure files, e.g. cpu.pressure.
+var resources = []string{"cpu", "memory", "io"}
+
+// kind is a line of a pressure file.
+type kind struct {
+	line string
+	// name is the metric's, as node_exporter names them.
+	name string
+	help string
+}
+
+// kinds are the lines of a pressure file: the time some tasks were waiting,
+// and the time all of them were stalled.
+var kinds = []kind{
+	{"some", "waiting", "Total time some tasks were waiting on %s"},
+	{"full", "stalled", "Total time all tasks were stalled on %s"},
+}
+
+// podCgroup matches the cgroups kubelet creates for pods, e.g. pod<uid> with
+// the cgroupfs driver or kubepods-burstable-pod<uid>.slice with the systemd
+// one, which replaces the dashes of the UID by underscores.
+var podCgroup = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})(\.slice)?$`)
+
+// kubepods are the cgroups kubelet creates the pods' under, by driver.
+var kubepods = []string{"kubepods.slice", "kubepods"}
+
+// Collector reads the pressure files under a cgroup v2 mount on every
+// Collect. Files the kernel doesn't provide are skipped; other failures are
+// logged and counted rather than failing the exporter's other metrics.
+type Collector struct {
+	logger logging.Logger
+	root   string
+	node   string
+
+	nodeDescs map[string]*prometheus.Desc
+	podDescs  map[string]*prometheus.Desc
+	errors    prometheus.Counter
+}
+
+// NewCollector returns a collector of the cgroup v2 hierarchy mounted at
+// root, labeling its metrics with node. Metric names are prefixed with
+// namespace.
+func NewCollector(logger logging.Logger, root, node, namespace string) (*Collector, error) {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("%s is not a cgroup v2 mount: %w", root, err)
+	}
+
+	c := &Collector{
+		logger:    logger,
+		root:      root,
+		node:      node,
+		nodeDescs: map[string]*prometheus.Desc{},
+		podDescs:  map[string]*prometheus.Desc{},
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "kubelet_summary_exporter",
+			Subsystem: "psi",
+			Name:      "errors_total",
+			Help:      "Failed reads of cgroup pressure files",
+		}),
+	}
+	for _, resource := range resources {
+		for _, k := range kinds {
+			key := resource + " " + k.line
+			name := fmt.Sprintf("pressure_%s_%s_seconds_total", resource, k.name)
+			help := fmt.Sprintf(k.help, resource)
+			c.nodeDescs[key] = prometheus.NewDesc(prometheus.BuildFQName(namespace, "node", name), help+" on the node", []string{"node"}, nil)
+			c.podDescs[key] = prometheus.NewDesc(prometheus.BuildFQName(namespace, "pod", name), help+" in the pod", []string{"node", "uid"}, nil)
+		}
+	}
+	return c, nil
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.nodeDescs {
+		ch <- desc
+	}
+	for _, desc := range c.podDescs {
+		ch <- desc
+	}
+	c.errors.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	defer c.errors.Collect(ch)
+
+	c.collect(ch, c.root, c.nodeDescs, c.node)
+
+	pods, err := c.pods()
+	if err != nil {
+		c.errors.Inc()
+		c.logger.Warn("failed to list pod cgroups", "root", c.root, "error", err)
+		return
+	}
+	for _, pod := range pods {
+		c.collect(ch, pod.path, c.podDescs, c.node, pod.uid)
+	}
+}
+
+// collect exports the pressure files of the cgroup at dir.
+func (c *Collector) collect(ch chan<- prometheus.Metric, dir string, descs map[string]*prometheus.Desc, labels ...string) {
+	for _, resource := range resources {
+		totals, err := readPressure(filepath.Join(dir, resource+".pressure"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			c.errors.Inc()
+			c.logger.Warn("failed to read cgroup pressure", "cgroup", dir, "resource", resource, "error", err)
+			continue
+		}
+		for _, k := range kinds {
+			if total, ok := totals[k.line]; ok {
+				ch <- prometheus.MustNewConstMetric(descs[resource+" "+k.line], prometheus.CounterValue, total, labels...)
+			}
+		}
+	}
+}
+
+type pod struct {
+	uid  string
+	path string
+}
+
+// pods returns the cgroups of the pods under the kubepods cgroup, which
+// nests them by QoS class but for guaranteed pods.
+func (c *Collector) pods() ([]pod, error) {
+	var pods []pod
+	for _, name := range kubepods {
+		root := filepath.Join(c.root, name)
+		if _, err := os.Stat(root); err != nil {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			// Cgroups of pods deleted during the walk are skipped.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if m := podCgroup.FindStringSubmatch(d.Name()); m != nil {
+				pods = append(pods, pod{uid: strings.ReplaceAll(m[1], "_", "-"), path: path})
+				return filepath.SkipDir
+			}
+			// Pods are at most two levels down, under their QoS class.
+			if path != root && filepath.Dir(path) != root {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pods, nil
+}
+
+// readPressure returns the total stall time in seconds of each line of a
+// pressure file, e.g.
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=123456
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=23456
+//
+// whose totals are in microseconds.
+func readPressure(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	totals := map[string]float64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || (fields[0] != "some" && fields[0] != "full") {
+			continue
+		}
+		for _, field := range fields[1:] {
+			value, ok := strings.CutPrefix(field, "total=")
+			if !ok {
+				continue
+			}
+			micros, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s total %q", fields[0], value)
+			}
+			totals[fields[0]] = float64(micros) / 1e6
+		}
+	}
+	return totals, scanner.Err()
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package psi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pressure(some, full string) string {
+	s := "some avg10=1.00 avg60=0.50 avg300=0.10 total=" + some + "\n"
+	if full != "" {
+		s += "full avg10=0.00 avg60=0.00 avg300=0.00 total=" + full + "\n"
+	}
+	return s
+}
+
+func TestCollector(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "cgroup.controllers"), "cpu memory io\n")
+	write(t, filepath.Join(root, "cpu.pressure"), pressure("3000000", "1000000"))
+	write(t, filepath.Join(root, "memory.pressure"), pressure("500000", "250000"))
+
+	// A burstable pod with the systemd driver, and a guaranteed one without
+	// io pressure. The QoS class's own pressure isn't exported.
+	burstable := filepath.Join(root, "kubepods.slice", "kubepods-burstable.slice")
+	write(t, filepath.Join(burstable, "cpu.pressure"), pressure("9", "9"))
+	write(t, filepath.Join(burstable, "kubepods-burstable-pod0c1b6f7e_1b2c_4d5e_8f90_a1b2c3d4e5f6.slice", "io.pressure"), pressure("2000000", "1000000"))
+	write(t, filepath.Join(root, "kubepods.slice", "kubepods-pod11111111_2222_3333_4444_555555555555.slice", "cpu.pressure"), pressure("1500000", ""))
+
+	c, err := NewCollector(logging.Nop(), root, "node-a", "kubelet_summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP kubelet_summary_node_pressure_cpu_stalled_seconds_total Total time all tasks were stalled on cpu on the node
+# TYPE kubelet_summary_node_pressure_cpu_stalled_seconds_total counter
+kubelet_summary_node_pressure_cpu_stalled_seconds_total{node="node-a"} 1
+# HELP kubelet_summary_node_pressure_cpu_waiting_seconds_total Total time some tasks were waiting on cpu on the node
+# TYPE kubelet_summary_node_pressure_cpu_waiting_seconds_total counter
+kubelet_summary_node_pressure_cpu_waiting_seconds_total{node="node-a"} 3
+# HELP kubelet_summary_node_pressure_memory_stalled_seconds_total Total time all tasks were stalled on memory on the node
+# TYPE kubelet_summary_node_pressure_memory_stalled_seconds_total counter
+kubelet_summary_node_pressure_memory_stalled_seconds_total{node="node-a"} 0.25
+# HELP kubelet_summary_node_pressure_memory_waiting_seconds_total Total time some tasks were waiting on memory on the node
+# TYPE kubelet_summary_node_pressure_memory_waiting_seconds_total counter
+kubelet_summary_node_pressure_memory_waiting_seconds_total{node="node-a"} 0.5
+# HELP kubelet_summary_pod_pressure_cpu_waiting_seconds_total Total time some tasks were waiting on cpu in the pod
+# TYPE kubelet_summary_pod_pressure_cpu_waiting_seconds_total counter
+kubelet_summary_pod_pressure_cpu_waiting_seconds_total{node="node-a",uid="11111111-2222-3333-4444-555555555555"} 1.5
+# HELP kubelet_summary_pod_pressure_io_stalled_seconds_total Total time all tasks were stalled on io in the pod
+# TYPE kubelet_summary_pod_pressure_io_stalled_seconds_total counter
+kubelet_summary_pod_pressure_io_stalled_seconds_total{node="node-a",uid="0c1b6f7e-1b2c-4d5e-8f90-a1b2c3d4e5f6"} 1
+# HELP kubelet_summary_pod_pressure_io_waiting_seconds_total Total time some tasks were waiting on io in the pod
+# TYPE kubelet_summary_pod_pressure_io_waiting_seconds_total counter
+kubelet_summary_pod_pressure_io_waiting_seconds_total{node="node-a",uid="0c1b6f7e-1b2c-4d5e-8f90-a1b2c3d4e5f6"} 2
+# HELP kubelet_summary_exporter_psi_errors_total Failed reads of cgroup pressure files
+# TYPE kubelet_summary_exporter_psi_errors_total counter
+kubelet_summary_exporter_psi_errors_total 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCgroupfsDriver(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "cgroup.controllers"), "cpu memory io\n")
+	write(t, filepath.Join(root, "kubepods", "besteffort", "pod0c1b6f7e-1b2c-4d5e-8f90-a1b2c3d4e5f6", "memory.pressure"), pressure("1000000", "0"))
+
+	c, err := NewCollector(logging.Nop(), root, "node-a", "kubelet_summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP kubelet_summary_pod_pressure_memory_waiting_seconds_total Total time some tasks were waiting on memory in the pod
+# TYPE kubelet_summary_pod_pressure_memory_waiting_seconds_total counter
+kubelet_summary_pod_pressure_memory_waiting_seconds_total{node="node-a",uid="0c1b6f7e-1b2c-4d5e-8f90-a1b2c3d4e5f6"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "kubelet_summary_pod_pressure_memory_waiting_seconds_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNotCgroupV2(t *testing.T) {
+	if _, err := NewCollector(logging.Nop(), t.TempDir(), "node-a", "kubelet_summary"); err == nil {
+		t.Error("expected an error for a mount without cgroup.controllers")
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package redact hides workload identities from metrics shared with
+// tenants, so node level capacity data can be exposed without revealing
+// which namespaces and pods run next to theirs.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Mode is how labels are redacted.
+type Mode string
+
+const (
+	// Hash replaces label values with a keyed hash, so series of the same
+	// workload can still be grouped without naming it.
+	Hash Mode = "hash"
+	// Drop removes the series carrying redacted labels.
+	Drop Mode = "drop"
+)
+
+// DefaultLabels are the labels redacted unless configured otherwise.
+var DefaultLabels = []string{"namespace", "pod"}
+
+// Gatherer redacts the metrics of another Gatherer.
+type Gatherer struct {
+	gatherer prometheus.Gatherer
+	mode     Mode
+	labels   map[string]struct{}
+	key      []byte
+}
+
+// New redacts labels of the metrics gathered by gatherer. key keys the
+// hashes of the Hash mode; without it hashes of well known names could be
+// reversed by hashing candidates.
+func New(gatherer prometheus.Gatherer, mode Mode, labels []string, key []byte) (*Gatherer, error) {
+	if mode != Hash && mode != Drop {
+		return nil, fmt.Errorf("unknown redaction mode %q", mode)
+	}
+	if mode == Hash && len(key) == 0 {
+		return nil, fmt.Errorf("hash redaction needs a key")
+	}
+
+	g := &Gatherer{
+		gatherer: gatherer,
+		mode:     mode,
+		labels:   make(map[string]struct{}, len(labels)),
+		key:      key,
+	}
+	for _, label := range labels {
+		g.labels[label] = struct{}{}
+	}
+
+	return g, nil
+}
+
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+
+	redacted := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		metrics := make([]*dto.Metric, 0, len(family.Metric))
+		for _, metric := range family.Metric {
+			if metric = g.redact(metric); metric != nil {
+				metrics = append(metrics, metric)
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+
+		family.Metric = metrics
+		redacted = append(redacted, family)
+	}
+
+	return redacted, err
+}
+
+// redact hashes the redacted labels of metric, or returns nil when it is
+// dropped. Gathered metrics belong to the caller, so they are changed in
+// place.
+func (g *Gatherer) redact(metric *dto.Metric) *dto.Metric {
+	for _, label := range metric.Label {
+		if _, ok := g.labels[label.GetName()]; !ok || label.GetValue() == "" {
+			continue
+		}
+		if g.mode == Drop {
+			return nil
+		}
+
+		label.Value = stringPtr(g.hash(label.GetValue()))
+	}
+
+	return metric
+}
+
+// hash returns a short keyed hash of value. 64 bits keep collisions between
+// the names on a node unlikely.
+func (g *Gatherer) hash(value string) string {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package redact
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func registry() *prometheus.Registry {
+	nodeMemory := prometheus.NewGauge(prometheus.GaugeOpts{Name: "node_memory_bytes", ConstLabels: prometheus.Labels{"node": "node-a"}})
+	nodeMemory.Set(64)
+
+	podMemory := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pod_memory_bytes"}, []string{"node", "namespace", "pod"})
+	podMemory.WithLabelValues("node-a", "payments", "checkout").Set(1)
+	podMemory.WithLabelValues("node-a", "payments", "cart").Set(2)
+	podMemory.WithLabelValues("node-a", "", "").Set(3)
+
+	r := prometheus.NewRegistry()
+	r.MustRegister(nodeMemory, podMemory)
+	return r
+}
+
+func labels(family *dto.MetricFamily) []map[string]string {
+	var out []map[string]string
+	for _, metric := range family.Metric {
+		values := map[string]string{}
+		for _, label := range metric.Label {
+			values[label.GetName()] = label.GetValue()
+		}
+		out = append(out, values)
+	}
+	return out
+}
+
+func TestHash(t *testing.T) {
+	g, err := New(registry(), Hash, DefaultLabels, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) != 2 {
+		t.Fatalf("expected both families, got %d", len(families))
+	}
+
+	pods := labels(families[1])
+	if len(pods) != 3 {
+		t.Fatalf("expected every pod series to be kept, got %d", len(pods))
+	}
+	for _, values := range pods {
+		if values["node"] != "node-a" {
+			t.Errorf("expected the node label to be kept, got %q", values["node"])
+		}
+		if values["namespace"] == "payments" || values["pod"] == "checkout" || values["pod"] == "cart" {
+			t.Errorf("expected namespace and pod to be hashed, got %v", values)
+		}
+	}
+	// Series are sorted by label values, the one without names comes first.
+	if pods[1]["namespace"] != pods[2]["namespace"] {
+		t.Errorf("expected equal names to hash equally, got %v", pods)
+	}
+	if pods[1]["pod"] == pods[2]["pod"] {
+		t.Errorf("expected different names to hash differently, got %v", pods)
+	}
+
+	other, _ := New(registry(), Hash, DefaultLabels, []byte("other"))
+	otherFamilies, _ := other.Gather()
+	if labels(otherFamilies[1])[1]["pod"] == pods[1]["pod"] {
+		t.Errorf("expected hashes to depend on the key")
+	}
+}
+
+func TestDrop(t *testing.T) {
+	g, err := New(registry(), Drop, DefaultLabels, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(families) != 2 || families[0].GetName() != "node_memory_bytes" {
+		t.Fatalf("expected node metrics to be kept, got %v", families)
+	}
+	if pods := labels(families[1]); len(pods) != 1 || pods[0]["pod"] != "" {
+		t.Errorf("expected only the series without pod names to be kept, got %v", pods)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(registry(), Hash, DefaultLabels, nil); err == nil {
+		t.Errorf("expected hashing without a key to fail")
+	}
+	if _, err := New(registry(), "mask", DefaultLabels, nil); err == nil {
+		t.Errorf("expected an unknown mode to fail")
+	}
+}
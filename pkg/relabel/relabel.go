@@ -0,0 +1,323 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package relabel rewrites gathered metrics with rules modeled on
+// Prometheus' relabel_config, so operators can drop noisy series or rename
+// labels and families without forking the exporter.
+package relabel
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/server"
+	"sigs.k8s.io/yaml"
+)
+
+// Action is what a rule does with the series it matches.
+type Action string
+
+const (
+	// Replace sets the target label to the replacement, expanded with the
+	// regular expression's groups, when the source labels match. An empty
+	// result removes the label.
+	Replace Action = "replace"
+	// Keep drops the series whose source labels don't match.
+	Keep Action = "keep"
+	// Drop drops the series whose source labels match.
+	Drop Action = "drop"
+	// LabelMap copies the labels whose names match to the replacement,
+	// expanded with the name's groups.
+	LabelMap Action = "labelmap"
+)
+
+// Config is a relabeling rule. Its fields are named like Prometheus'
+// relabel_config, so rules can be copied from metric_relabel_configs; the
+// metric name is the __name__ label.
+type Config struct {
+	SourceLabels []string `json:"source_labels"`
+	// Separator joins the values of the source labels, ";" when empty.
+	Separator string `json:"separator"`
+	// Regex is matched against the joined values, anchored at both ends,
+	// "(.*)" when empty.
+	Regex       string `json:"regex"`
+	TargetLabel string `json:"target_label"`
+	// Replacement is "$1" when empty. Use Replace with a regex matching the
+	// empty string to remove a label.
+	Replacement *string `json:"replacement"`
+	// Action is Replace when empty.
+	Action Action `json:"action"`
+}
+
+// rule is a Config with its defaults applied and regex compiled.
+type rule struct {
+	sourceLabels []string
+	separator    string
+	regex        *regexp.Regexp
+	targetLabel  string
+	replacement  string
+	action       Action
+}
+
+// Parse parses a YAML list of rules.
+func Parse(data []byte) ([]Config, error) {
+	var configs []Config
+	if err := yaml.UnmarshalStrict(data, &configs); err != nil {
+		return nil, err
+	}
+
+	if _, err := compile(configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func compile(configs []Config) ([]rule, error) {
+	rules := make([]rule, 0, len(configs))
+	for i, config := range configs {
+		r := rule{
+			sourceLabels: config.SourceLabels,
+			separator:    config.Separator,
+			targetLabel:  config.TargetLabel,
+			replacement:  "$1",
+			action:       config.Action,
+		}
+		if r.separator == "" {
+			r.separator = ";"
+		}
+		if config.Replacement != nil {
+			r.replacement = *config.Replacement
+		}
+		if r.action == "" {
+			r.action = Replace
+		}
+
+		expr := config.Regex
+		if expr == "" {
+			expr = "(.*)"
+		}
+		regex, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		r.regex = regex
+
+		for _, label := range r.sourceLabels {
+			if !model.LabelName(label).IsValid() {
+				return nil, fmt.Errorf("rule %d: invalid source label %q", i, label)
+			}
+		}
+
+		switch r.action {
+		case Replace:
+			if !model.LabelName(r.targetLabel).IsValid() {
+				return nil, fmt.Errorf("rule %d: replace needs a valid target label, got %q", i, r.targetLabel)
+			}
+			if len(r.sourceLabels) == 0 {
+				return nil, fmt.Errorf("rule %d: replace needs source labels", i)
+			}
+		case Keep, Drop:
+			if len(r.sourceLabels) == 0 {
+				return nil, fmt.Errorf("rule %d: %s needs source labels", i, r.action)
+			}
+		case LabelMap:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown action %q", i, r.action)
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// Gatherer relabels the metrics of another Gatherer.
+type Gatherer struct {
+	gatherer prometheus.Gatherer
+	rules    []rule
+}
+
+// New relabels the metrics gathered by gatherer with configs, applied in
+// order.
+func New(gatherer prometheus.Gatherer, configs []Config) (*Gatherer, error) {
+	rules, err := compile(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gatherer{
+		gatherer: gatherer,
+		rules:    rules,
+	}, nil
+}
+
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	return g.GatherContext(context.Background())
+}
+
+// GatherContext is Gather passing ctx to the relabeled gatherer when it
+// supports it.
+func (g *Gatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	families, err := server.GatherContext(ctx, g.gatherer)
+	return g.relabel(families), err
+}
+
+// relabel applies the rules to every series. Series renamed into another
+// family join it when its type is the same and are dropped otherwise, as
+// the family can't hold them. A series left with the labels of one before
+// it in its family, e.g. by removing the only label telling them apart, is
+// dropped too, as Prometheus rejects the duplicate samples. Gathered metrics
+// belong to the caller, so they are changed in place.
+func (g *Gatherer) relabel(families []*dto.MetricFamily) []*dto.MetricFamily {
+	relabeled := map[string]*dto.MetricFamily{}
+	seen := map[string]struct{}{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel())+1)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			labels[model.MetricNameLabel] = family.GetName()
+
+			if !g.apply(labels) {
+				continue
+			}
+
+			name := labels[model.MetricNameLabel]
+			if !model.IsValidMetricName(model.LabelValue(name)) {
+				continue
+			}
+			delete(labels, model.MetricNameLabel)
+
+			target, ok := relabeled[name]
+			if !ok {
+				target = &dto.MetricFamily{
+					Name: stringPtr(name),
+					Help: family.Help,
+					Type: family.Type,
+				}
+				relabeled[name] = target
+			}
+			if target.GetType() != family.GetType() {
+				continue
+			}
+
+			metric.Label = labelPairs(labels)
+			key := seriesKey(name, metric.Label)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			target.Metric = append(target.Metric, metric)
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(relabeled))
+	for _, family := range relabeled {
+		if len(family.Metric) == 0 {
+			continue
+		}
+		// Relabeled series sort by their new labels, as the registry sorts
+		// gathered ones.
+		sort.Slice(family.Metric, func(i, j int) bool {
+			return seriesKey("", family.Metric[i].Label) < seriesKey("", family.Metric[j].Label)
+		})
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result
+}
+
+// apply runs the rules on the labels of a series, returning false when it
+// is dropped.
+func (g *Gatherer) apply(labels map[string]string) bool {
+	for _, r := range g.rules {
+		values := make([]string, len(r.sourceLabels))
+		for i, label := range r.sourceLabels {
+			values[i] = labels[label]
+		}
+		value := strings.Join(values, r.separator)
+
+		switch r.action {
+		case Keep:
+			if !r.regex.MatchString(value) {
+				return false
+			}
+		case Drop:
+			if r.regex.MatchString(value) {
+				return false
+			}
+		case Replace:
+			match := r.regex.FindStringSubmatchIndex(value)
+			if match == nil {
+				continue
+			}
+			replaced := string(r.regex.ExpandString(nil, r.replacement, value, match))
+			if replaced == "" {
+				delete(labels, r.targetLabel)
+			} else {
+				labels[r.targetLabel] = replaced
+			}
+		case LabelMap:
+			mapped := map[string]string{}
+			for name, value := range labels {
+				if r.regex.MatchString(name) {
+					mapped[r.regex.ReplaceAllString(name, r.replacement)] = value
+				}
+			}
+			for name, value := range mapped {
+				labels[name] = value
+			}
+		}
+	}
+
+	return true
+}
+
+// labelPairs returns labels as label pairs sorted by name, leaving out
+// empty and invalid ones.
+func labelPairs(labels map[string]string) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for name, value := range labels {
+		if value == "" || !model.LabelName(name).IsValid() {
+			continue
+		}
+		pairs = append(pairs, &dto.LabelPair{Name: stringPtr(name), Value: stringPtr(value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].GetName() < pairs[j].GetName()
+	})
+
+	return pairs
+}
+
+// seriesKey identifies a series by its family and sorted labels.
+func seriesKey(name string, pairs []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, pair := range pairs {
+		b.WriteByte(model.SeparatorByte)
+		b.WriteString(pair.GetName())
+		b.WriteByte(model.SeparatorByte)
+		b.WriteString(pair.GetValue())
+	}
+	return b.String()
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package relabel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func registry() *prometheus.Registry {
+	rx := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "node_network_rx_bytes", Help: "Received bytes."}, []string{"interface"})
+	rx.WithLabelValues("eth0").Set(1)
+	rx.WithLabelValues("veth1234").Set(2)
+	rx.WithLabelValues("lxc5678").Set(3)
+
+	memory := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pod_memory_bytes", Help: "Pod memory."}, []string{"namespace", "pod"})
+	memory.WithLabelValues("kube-system", "coredns").Set(4)
+	memory.WithLabelValues("payments", "checkout").Set(5)
+
+	r := prometheus.NewRegistry()
+	r.MustRegister(rx, memory)
+	return r
+}
+
+func TestRelabel(t *testing.T) {
+	configs, err := Parse([]byte(`
+- source_labels: [interface]
+  regex: (veth|lxc).*
+  action: drop
+- source_labels: [namespace]
+  regex: kube-(.*)
+  target_label: namespace
+  replacement: k8s-$1
+- regex: (pod)
+  replacement: workload_$1
+  action: labelmap
+- source_labels: [__name__]
+  regex: node_network_(.*)
+  target_label: __name__
+  replacement: node_net_$1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New(registry(), configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP node_net_rx_bytes Received bytes.
+# TYPE node_net_rx_bytes gauge
+node_net_rx_bytes{interface="eth0"} 1
+# HELP pod_memory_bytes Pod memory.
+# TYPE pod_memory_bytes gauge
+pod_memory_bytes{namespace="k8s-system",pod="coredns",workload_pod="coredns"} 4
+pod_memory_bytes{namespace="payments",pod="checkout",workload_pod="checkout"} 5
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeep(t *testing.T) {
+	g, err := New(registry(), []Config{{SourceLabels: []string{"__name__", "namespace"}, Regex: "pod_.*;payments", Action: Keep}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP pod_memory_bytes Pod memory.
+# TYPE pod_memory_bytes gauge
+pod_memory_bytes{namespace="payments",pod="checkout"} 5
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRemoveLabel(t *testing.T) {
+	empty := ""
+	g, err := New(registry(), []Config{{SourceLabels: []string{"pod"}, TargetLabel: "pod", Replacement: &empty}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP pod_memory_bytes Pod memory.
+# TYPE pod_memory_bytes gauge
+pod_memory_bytes{namespace="kube-system"} 4
+pod_memory_bytes{namespace="payments"} 5
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected), "pod_memory_bytes"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDuplicateSeries(t *testing.T) {
+	empty := ""
+	g, err := New(registry(), []Config{{SourceLabels: []string{"interface"}, Regex: "(veth|lxc).*", TargetLabel: "interface", Replacement: &empty}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both virtual interfaces lose their only label; the first is kept.
+	expected := `
+# HELP node_network_rx_bytes Received bytes.
+# TYPE node_network_rx_bytes gauge
+node_network_rx_bytes{interface="eth0"} 1
+node_network_rx_bytes 3
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected), "node_network_rx_bytes"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown action":   "- source_labels: [a]\n  action: hashmod\n",
+		"invalid regex":    "- source_labels: [a]\n  regex: (\n  action: drop\n",
+		"no target label":  "- source_labels: [a]\n",
+		"no source labels": "- action: keep\n",
+		"unknown field":    "- source_label: [a]\n  action: drop\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
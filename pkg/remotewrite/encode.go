@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type label struct {
+	name, value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// series converts metric families to time series of one sample, taken at
+// nowMs unless a metric has a timestamp of its own. Histograms and
+// summaries are split into the series Prometheus would scrape: buckets,
+// quantiles, a sum and a count.
+func series(families []*dto.MetricFamily, nowMs int64) []timeSeries {
+	var all []timeSeries
+	for _, family := range families {
+		name := family.GetName()
+		for _, m := range family.GetMetric() {
+			at := nowMs
+			if m.TimestampMs != nil {
+				at = m.GetTimestampMs()
+			}
+			add := func(name string, value float64, extra ...label) {
+				labels := make([]label, 0, len(m.GetLabel())+len(extra)+1)
+				labels = append(labels, label{"__name__", name})
+				for _, l := range m.GetLabel() {
+					labels = append(labels, label{l.GetName(), l.GetValue()})
+				}
+				labels = append(labels, extra...)
+				sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+				all = append(all, timeSeries{labels: labels, samples: []sample{{value, at}}})
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				infinite := false
+				for _, b := range h.GetBucket() {
+					infinite = infinite || math.IsInf(b.GetUpperBound(), +1)
+					add(name+"_bucket", float64(b.GetCumulativeCount()), label{"le", formatFloat(b.GetUpperBound())})
+				}
+				if !infinite {
+					add(name+"_bucket", float64(h.GetSampleCount()), label{"le", "+Inf"})
+				}
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, q.GetValue(), label{"quantile", formatFloat(q.GetQuantile())})
+				}
+				add(name+"_sum", s.GetSampleSum())
+				add(name+"_count", float64(s.GetSampleCount()))
+			}
+		}
+	}
+	return all
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// marshal encodes a prometheus.WriteRequest:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func marshal(all []timeSeries) []byte {
+	var b, ts, msg []byte
+	for _, s := range all {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.name)
+			msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, msg)
+		}
+		for _, smp := range s.samples {
+			msg = msg[:0]
+			msg = protowire.AppendTag(msg, 1, protowire.Fixed64Type)
+			msg = protowire.AppendFixed64(msg, math.Float64bits(smp.value))
+			msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+			msg = protowire.AppendVarint(msg, uint64(smp.timestamp))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, msg)
+		}
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	return b
+}
+
+// maxLiteral is the longest literal snappyBlock emits, the most a two byte
+// length holds.
+const maxLiteral = 1 << 16
+
+// snappyBlock frames data in the snappy block format remote_write requires,
+// as literals only. Receivers decode it like any snappy block; the payload
+// just isn't compressed, which saves the dependency on a snappy encoder.
+func snappyBlock(data []byte) []byte {
+	b := protowire.AppendVarint(make([]byte, 0, len(data)+len(data)/maxLiteral*3+13), uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxLiteral {
+			n = maxLiteral
+		}
+		switch l := n - 1; {
+		case l < 60:
+			b = append(b, byte(l)<<2)
+		case l < 1<<8:
+			b = append(b, 60<<2, byte(l))
+		default:
+			b = append(b, 61<<2, byte(l), byte(l>>8))
+		}
+		b = append(b, data[:n]...)
+		data = data[n:]
+	}
+	return b
+}
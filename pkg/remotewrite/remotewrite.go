@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package remotewrite pushes gathered metrics to a Prometheus remote_write
+// endpoint, for edge nodes without a Prometheus to scrape the exporter.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/expiry"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/utils"
+)
+
+// Options configure a Writer.
+type Options struct {
+	// URL is the remote_write endpoint, e.g.
+	// https://prometheus.example.com/api/v1/write.
+	URL string
+	// Interval is the time between pushes.
+	Interval time.Duration
+	// Timeout limits every push.
+	Timeout time.Duration
+	// SeriesTTL is how long series missing from gathers are still pushed
+	// with their last value before they expire.
+	SeriesTTL time.Duration
+
+	// BearerTokenFile holds the token sent with every push. It is re-read
+	// on every push, so rotated tokens are picked up.
+	BearerTokenFile string
+	// Username and the password held by PasswordFile authenticate pushes
+	// with basic auth.
+	Username     string
+	PasswordFile string
+
+	// CAFile verifies the endpoint's certificate instead of the system
+	// roots; CertFile and KeyFile are presented as client certificate.
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Writer pushes the metrics of a gatherer.
+type Writer struct {
+	logger   logging.Logger
+	gatherer prometheus.Gatherer
+	options  Options
+	client   *http.Client
+	expiry   *expiry.Tracker
+
+	mu     sync.Mutex
+	pushes map[string]float64
+
+	desc *prometheus.Desc
+}
+
+// New returns a writer of the metrics of gatherer. It fails if the TLS
+// files can't be loaded.
+func New(logger logging.Logger, gatherer prometheus.Gatherer, options Options) (*Writer, error) {
+	if options.BearerTokenFile != "" && options.Username != "" {
+		return nil, fmt.Errorf("bearer token and basic auth are mutually exclusive")
+	}
+
+	config := &tls.Config{InsecureSkipVerify: options.InsecureSkipVerify}
+	if options.CAFile != "" {
+		data, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ca: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("ca: no certificates in %s", options.CAFile)
+		}
+	}
+	if options.CertFile != "" || options.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return &Writer{
+		logger:   logger,
+		gatherer: gatherer,
+		options:  options,
+		client:   &http.Client{Transport: utils.NewTransport(config), Timeout: options.Timeout},
+		expiry:   expiry.New("remote_write", options.SeriesTTL),
+		pushes:   map[string]float64{"success": 0, "failure": 0},
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "remote_write", "pushes_total"),
+			"Pushes of metrics to the remote_write endpoint by result",
+			[]string{"result"},
+			nil),
+	}, nil
+}
+
+// Run pushes every interval until ctx is done. Failed pushes are logged and
+// counted; their samples are not retried, the next push sends current ones.
+func (w *Writer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		err := w.Push(ctx)
+		if err != nil {
+			w.logger.Error("failed to push metrics over remote_write", "url", w.options.URL, "error", err)
+		}
+
+		w.mu.Lock()
+		if err != nil {
+			w.pushes["failure"]++
+		} else {
+			w.pushes["success"]++
+		}
+		w.mu.Unlock()
+	}
+}
+
+// Push gathers and pushes metrics once.
+func (w *Writer) Push(ctx context.Context) error {
+	families, err := w.gatherer.Gather()
+	if len(families) == 0 && err != nil {
+		return err
+	}
+	if err != nil {
+		w.logger.Warn("pushing partially gathered metrics", "error", err)
+	}
+
+	now := time.Now()
+	body := snappyBlock(marshal(series(w.expiry.Apply(families, now), now.UnixMilli())))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "kubelet-summary-exporter")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	switch {
+	case w.options.BearerTokenFile != "":
+		token, err := readSecret(w.options.BearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case w.options.Username != "":
+		password, err := readSecret(w.options.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("password: %w", err)
+		}
+		req.SetBasicAuth(w.options.Username, password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func readSecret(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (w *Writer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.desc
+	w.expiry.Describe(ch)
+}
+
+func (w *Writer) Collect(ch chan<- prometheus.Metric) {
+	w.expiry.Collect(ch)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, result := range []string{"success", "failure"} {
+		ch <- prometheus.MustNewConstMetric(w.desc, prometheus.CounterValue, w.pushes[result], result)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestPush(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "kubelet_summary_node_memory_working_set_bytes", Help: "Working set"}, []string{"node"})
+	gauge.WithLabelValues("node-a").Set(1024)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Help: "Latency", Buckets: []float64{1}})
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+	registry.MustRegister(gauge, histogram)
+
+	token := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(token, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []timeSeries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		got = unmarshal(t, unsnappy(t, body))
+	}))
+	defer server.Close()
+
+	w, err := New(logging.Nop(), registry, Options{URL: server.URL, Timeout: time.Second, BearerTokenFile: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]label{
+		{{"__name__", "kubelet_summary_node_memory_working_set_bytes"}, {"node", "node-a"}},
+		{{"__name__", "latency_seconds_bucket"}, {"le", "1"}},
+		{{"__name__", "latency_seconds_bucket"}, {"le", "+Inf"}},
+		{{"__name__", "latency_seconds_sum"}},
+		{{"__name__", "latency_seconds_count"}},
+	}
+	values := []float64{1024, 1, 2, 5.5, 2}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d series, got %v", len(want), got)
+	}
+	for i, s := range got {
+		if !reflect.DeepEqual(s.labels, want[i]) || s.samples[0].value != values[i] || s.samples[0].timestamp == 0 {
+			t.Errorf("expected series %v = %v, got %v", want[i], values[i], s)
+		}
+	}
+}
+
+func TestSnappyBlock(t *testing.T) {
+	for _, n := range []int{0, 59, 60, 300, maxLiteral + 1, 3 * maxLiteral} {
+		data := bytes.Repeat([]byte{'x'}, n)
+		if decoded := unsnappy(t, snappyBlock(data)); !bytes.Equal(decoded, data) {
+			t.Errorf("round trip of %d bytes returned %d", n, len(decoded))
+		}
+	}
+}
+
+// unsnappy decodes the literals of a snappy block.
+func unsnappy(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	length, n := protowire.ConsumeVarint(b)
+	b = b[n:]
+	var data []byte
+	for len(b) > 0 {
+		tag := b[0]
+		if tag&3 != 0 {
+			t.Fatalf("unexpected copy tag %x", tag)
+		}
+		l, skip := int(tag>>2), 1
+		switch tag >> 2 {
+		case 60:
+			l, skip = int(b[1]), 2
+		case 61:
+			l, skip = int(b[1])|int(b[2])<<8, 3
+		}
+		data = append(data, b[skip:skip+l+1]...)
+		b = b[skip+l+1:]
+	}
+	if uint64(len(data)) != length {
+		t.Fatalf("expected %d bytes, got %d", length, len(data))
+	}
+	return data
+}
+
+func unmarshal(t *testing.T, b []byte) []timeSeries {
+	t.Helper()
+
+	fields := func(b []byte, f func(protowire.Number, protowire.Type, []byte)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			b = b[n:]
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			f(num, typ, b[:n])
+			b = b[n:]
+		}
+	}
+	bytesValue := func(b []byte) []byte {
+		v, _ := protowire.ConsumeBytes(b)
+		return v
+	}
+
+	var all []timeSeries
+	fields(b, func(_ protowire.Number, _ protowire.Type, ts []byte) {
+		var s timeSeries
+		fields(bytesValue(ts), func(num protowire.Number, _ protowire.Type, msg []byte) {
+			switch num {
+			case 1:
+				var l label
+				fields(bytesValue(msg), func(num protowire.Number, _ protowire.Type, v []byte) {
+					if num == 1 {
+						l.name = string(bytesValue(v))
+					} else {
+						l.value = string(bytesValue(v))
+					}
+				})
+				s.labels = append(s.labels, l)
+			case 2:
+				var smp sample
+				fields(bytesValue(msg), func(num protowire.Number, _ protowire.Type, v []byte) {
+					if num == 1 {
+						bits, _ := protowire.ConsumeFixed64(v)
+						smp.value = math.Float64frombits(bits)
+					} else {
+						ts, _ := protowire.ConsumeVarint(v)
+						smp.timestamp = int64(ts)
+					}
+				})
+				s.samples = append(s.samples, smp)
+			}
+		})
+		all = append(all, s)
+	})
+	return all
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	"sigs.k8s.io/yaml"
+)
+
+// Thresholds parameterize the generated alerts.
+type Thresholds struct {
+	// NodeFsUsage is the used/capacity ratio of the node filesystem to alert on.
+	NodeFsUsage float64
+	// InodesFree is the free/total inode ratio below which to alert.
+	InodesFree float64
+	// EphemeralStorageUsage is the used/capacity ratio of pod ephemeral storage to alert on.
+	EphemeralStorageUsage float64
+	// For is how long a condition must hold before the alert fires.
+	For time.Duration
+}
+
+type RuleGroups struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type prometheusRule struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   map[string]string `json:"metadata"`
+	Spec       RuleGroups        `json:"spec"`
+}
+
+const maintenanceMetric = "kubelet_summary_node_maintenance"
+
+type alert struct {
+	name    string
+	metrics []string
+	expr    string
+	summary string
+}
+
+// Generate returns alerting rules for the metrics in the registry. Alerts
+// whose source metrics are not exported are left out.
+func Generate(metrics []scraper.MetricInfo, t Thresholds) RuleGroups {
+	exported := map[string]struct{}{}
+	for _, metric := range metrics {
+		exported[metric.Name] = struct{}{}
+	}
+
+	alerts := []alert{
+		{
+			name:    "KubeletSummaryNodeFsNearlyFull",
+			metrics: []string{"kubelet_summary_node_fs_usage_bytes", "kubelet_summary_node_fs_limit_bytes"},
+			expr:    fmt.Sprintf("kubelet_summary_node_fs_usage_bytes / kubelet_summary_node_fs_limit_bytes > %g", t.NodeFsUsage),
+			summary: "Node filesystem on {{ $labels.node }} is {{ $value | humanizePercentage }} full",
+		},
+		{
+			name:    "KubeletSummaryNodeFsInodesExhausted",
+			metrics: []string{"kubelet_summary_node_fs_inodes_free", "kubelet_summary_node_fs_inodes"},
+			expr:    fmt.Sprintf("kubelet_summary_node_fs_inodes_free / kubelet_summary_node_fs_inodes < %g", t.InodesFree),
+			summary: "Node filesystem on {{ $labels.node }} has only {{ $value | humanizePercentage }} inodes free",
+		},
+		{
+			name:    "KubeletSummaryPodEphemeralStorageNearLimit",
+			metrics: []string{"kubelet_summary_pod_ephemeral_storage_usage_bytes", "kubelet_summary_pod_ephemeral_storage_limit_bytes"},
+			expr:    fmt.Sprintf("kubelet_summary_pod_ephemeral_storage_usage_bytes / kubelet_summary_pod_ephemeral_storage_limit_bytes > %g", t.EphemeralStorageUsage),
+			summary: "Ephemeral storage of pod {{ $labels.namespace }}/{{ $labels.pod }} is {{ $value | humanizePercentage }} used",
+		},
+		{
+			name:    "KubeletSummaryPodVolumeAbnormal",
+			metrics: []string{"kubelet_summary_pod_volume_health_status"},
+			expr:    "kubelet_summary_pod_volume_health_status == 1",
+			summary: "Volume {{ $labels.volume_name }} of pod {{ $labels.namespace }}/{{ $labels.pod }} is abnormal",
+		},
+	}
+
+	// Nodes being drained are expected to misbehave, don't page for them.
+	_, maintenance := exported[maintenanceMetric]
+
+	group := RuleGroup{Name: "kubelet-summary-exporter"}
+	for _, a := range alerts {
+		if !allExported(exported, a.metrics) {
+			continue
+		}
+
+		expr := a.expr
+		if maintenance {
+			expr = fmt.Sprintf("(%s) unless on (node) %s", expr, maintenanceMetric)
+		}
+
+		group.Rules = append(group.Rules, Rule{
+			Alert:       a.name,
+			Expr:        expr,
+			For:         model.Duration(t.For).String(),
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": a.summary},
+		})
+	}
+
+	return RuleGroups{Groups: []RuleGroup{group}}
+}
+
+// YAML renders the rules as a plain Prometheus rules file.
+func (g RuleGroups) YAML() ([]byte, error) {
+	return yaml.Marshal(g)
+}
+
+// PrometheusRuleYAML renders the rules as a prometheus-operator PrometheusRule.
+func (g RuleGroups) PrometheusRuleYAML(name, namespace string) ([]byte, error) {
+	metadata := map[string]string{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+
+	return yaml.Marshal(prometheusRule{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata:   metadata,
+		Spec:       g,
+	})
+}
+
+func allExported(exported map[string]struct{}, names []string) bool {
+	for _, name := range names {
+		if _, ok := exported[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scrapeconfig
+
+import (
+	"sync"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/targets"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NodeSelection lists the nodes matching the node selector of the applied
+// config.
+type NodeSelection struct {
+	nodes targets.NodeLister
+
+	mu       sync.RWMutex
+	selector labels.Selector
+}
+
+// NewNodeSelection selects from nodes, all of them until a selector is Set.
+func NewNodeSelection(nodes targets.NodeLister) *NodeSelection {
+	return &NodeSelection{nodes: nodes, selector: labels.Everything()}
+}
+
+// Set replaces the node selector, nil selects every node.
+func (n *NodeSelection) Set(selector labels.Selector) {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.selector = selector
+}
+
+// List returns the selected nodes.
+func (n *NodeSelection) List() []*v1.Node {
+	n.mu.RLock()
+	selector := n.selector
+	n.mu.RUnlock()
+
+	var selected []*v1.Node
+	for _, node := range n.nodes.List() {
+		if selector.Matches(labels.Set(node.Labels)) {
+			selected = append(selected, node)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package scrapeconfig reads exporter settings from ScrapeConfig objects, so
+// fleets managed through GitOps can change them without restarts.
+package scrapeconfig
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/scraper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Resource is the ScrapeConfig custom resource, defined in
+// config/crd/scrapeconfigs.yaml.
+var Resource = schema.GroupVersionResource{
+	Group:    "kubelet-summary-exporter.salesforce.com",
+	Version:  "v1alpha1",
+	Resource: "scrapeconfigs",
+}
+
+// Spec is the desired configuration of a ScrapeConfig object.
+type Spec struct {
+	// IncludeNamespaces, ExcludeNamespaces, IncludePods and ExcludePods are
+	// regular expressions, anchored like in Prometheus, narrowing the pods
+	// selected by the command line filters.
+	IncludeNamespaces string `json:"includeNamespaces,omitempty"`
+	ExcludeNamespaces string `json:"excludeNamespaces,omitempty"`
+	IncludePods       string `json:"includePods,omitempty"`
+	ExcludePods       string `json:"excludePods,omitempty"`
+
+	// PodLabels are the keys of the pod annotation labels that are
+	// exported, all of them when empty.
+	PodLabels []string `json:"podLabels,omitempty"`
+
+	// NodeSelector selects the discovered nodes that are scraped, all of
+	// them when nil.
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+}
+
+// Status is the observed state of a ScrapeConfig object.
+type Status struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Config is a validated Spec, ready to be applied.
+type Config struct {
+	PodFilter scraper.PodFilter
+	PodLabels []string
+	Nodes     labels.Selector
+}
+
+// Compile validates spec. Errors name the offending field.
+func (spec Spec) Compile() (Config, error) {
+	config := Config{
+		PodLabels: spec.PodLabels,
+		Nodes:     labels.Everything(),
+	}
+
+	for _, f := range []struct {
+		field string
+		expr  string
+		re    **regexp.Regexp
+	}{
+		{"spec.includeNamespaces", spec.IncludeNamespaces, &config.PodFilter.IncludeNamespaces},
+		{"spec.excludeNamespaces", spec.ExcludeNamespaces, &config.PodFilter.ExcludeNamespaces},
+		{"spec.includePods", spec.IncludePods, &config.PodFilter.IncludePods},
+		{"spec.excludePods", spec.ExcludePods, &config.PodFilter.ExcludePods},
+	} {
+		if f.expr == "" {
+			continue
+		}
+
+		re, err := regexp.Compile("^(?:" + f.expr + ")$")
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", f.field, err)
+		}
+		*f.re = re
+	}
+
+	for i, key := range spec.PodLabels {
+		if key == "" {
+			return Config{}, fmt.Errorf("spec.podLabels[%d]: empty key", i)
+		}
+	}
+
+	if spec.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.NodeSelector)
+		if err != nil {
+			return Config{}, fmt.Errorf("spec.nodeSelector: %w", err)
+		}
+		config.Nodes = selector
+	}
+
+	return config, nil
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scrapeconfig
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCompile(t *testing.T) {
+	config, err := Spec{
+		ExcludeNamespaces: "ci-.*",
+		PodLabels:         []string{"team"},
+		NodeSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "gpu"}},
+	}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !config.PodFilter.ExcludeNamespaces.MatchString("ci-pr-42") || config.PodFilter.ExcludeNamespaces.MatchString("shop-ci-1") {
+		t.Errorf("expected an anchored namespace filter")
+	}
+	if !config.Nodes.Matches(labels.Set{"pool": "gpu"}) || config.Nodes.Matches(labels.Set{"pool": "general"}) {
+		t.Errorf("expected the node selector to apply")
+	}
+
+	for _, tc := range []struct {
+		spec  Spec
+		field string
+	}{
+		{Spec{IncludePods: "web-("}, "spec.includePods"},
+		{Spec{PodLabels: []string{"team", ""}}, "spec.podLabels[1]"},
+		{Spec{NodeSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "pool", Operator: "Near"}}}}, "spec.nodeSelector"},
+	} {
+		if _, err := tc.spec.Compile(); err == nil || !strings.HasPrefix(err.Error(), tc.field) {
+			t.Errorf("expected an error about %s, got %v", tc.field, err)
+		}
+	}
+}
+
+func scrapeConfig(generation int64, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Resource.GroupVersion().String(),
+		"kind":       "ScrapeConfig",
+		"spec":       spec,
+	}}
+	obj.SetNamespace("monitoring")
+	obj.SetName("default")
+	obj.SetUID("1")
+	obj.SetGeneration(generation)
+	return obj
+}
+
+func TestWatcher(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{Resource: "ScrapeConfigList"},
+		scrapeConfig(1, map[string]interface{}{"excludeNamespaces": "ci-.*"}),
+	)
+	resource := client.Resource(Resource).Namespace("monitoring")
+
+	var applied []Config
+	w := &watcher{
+		logger: logging.Nop(),
+		client: resource,
+		name:   "default",
+		apply:  func(config Config) { applied = append(applied, config) },
+	}
+
+	condition := func() *metav1.Condition {
+		obj, err := resource.Get(ctx, "default", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _, _ := unstructured.NestedMap(obj.Object, "status")
+		var status Status
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &status); err != nil {
+			t.Fatal(err)
+		}
+		return meta.FindStatusCondition(status.Conditions, ConditionValid)
+	}
+
+	w.poll(ctx)
+	w.poll(ctx)
+	if len(applied) != 1 || applied[0].PodFilter.ExcludeNamespaces == nil {
+		t.Fatalf("expected the spec to be applied once, got %d configs", len(applied))
+	}
+	if c := condition(); c == nil || c.Status != metav1.ConditionTrue || c.ObservedGeneration != 1 {
+		t.Errorf("expected a true Valid condition for generation 1, got %+v", c)
+	}
+
+	if _, err := resource.Update(ctx, scrapeConfig(2, map[string]interface{}{"includePods": "web-("}), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w.poll(ctx)
+	if len(applied) != 1 {
+		t.Errorf("expected an invalid spec not to be applied")
+	}
+	if c := condition(); c == nil || c.Status != metav1.ConditionFalse || c.ObservedGeneration != 2 || !strings.Contains(c.Message, "spec.includePods") {
+		t.Errorf("expected a false Valid condition for generation 2, got %+v", c)
+	}
+
+	if _, err := resource.Update(ctx, scrapeConfig(3, map[string]interface{}{"unknownField": true}), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w.poll(ctx)
+	if c := condition(); c == nil || c.Status != metav1.ConditionFalse || !strings.Contains(c.Message, "unknownField") {
+		t.Errorf("expected unknown fields to be rejected, got %+v", c)
+	}
+
+	if err := resource.Delete(ctx, "default", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w.poll(ctx)
+	if len(applied) != 2 || applied[1].PodFilter.ExcludeNamespaces != nil {
+		t.Errorf("expected an empty config once the object is removed")
+	}
+}
+
+type staticNodes []*v1.Node
+
+func (n staticNodes) List() []*v1.Node {
+	return n
+}
+
+func TestNodeSelection(t *testing.T) {
+	selection := NewNodeSelection(staticNodes{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"pool": "gpu"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"pool": "general"}}},
+	})
+
+	if got := len(selection.List()); got != 2 {
+		t.Errorf("expected every node before a selector is set, got %d", got)
+	}
+
+	selection.Set(labels.SelectorFromSet(labels.Set{"pool": "gpu"}))
+	if nodes := selection.List(); len(nodes) != 1 || nodes[0].Name != "node-a" {
+		t.Errorf("expected only node-a to be selected, got %v", nodes)
+	}
+
+	selection.Set(nil)
+	if got := len(selection.List()); got != 2 {
+		t.Errorf("expected every node after clearing the selector, got %d", got)
+	}
+}
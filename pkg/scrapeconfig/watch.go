@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scrapeconfig
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// ConditionValid reports whether the object's spec was accepted.
+const ConditionValid = "Valid"
+
+// Watch polls the ScrapeConfig namespace/name every interval, until ctx is
+// done, and passes its config to apply whenever its spec changes. An invalid
+// spec is not applied, the previous config stays in effect and the object's
+// Valid condition says why. A missing object applies an empty config.
+func Watch(ctx context.Context, logger logging.Logger, client dynamic.Interface, namespace, name string, interval time.Duration, apply func(Config)) error {
+	w := &watcher{
+		logger: logger,
+		client: client.Resource(Resource).Namespace(namespace),
+		name:   name,
+		apply:  apply,
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type watcher struct {
+	logger logging.Logger
+	client dynamic.ResourceInterface
+	name   string
+	apply  func(Config)
+
+	// uid and generation identify the last handled spec, uid is empty when
+	// no object was found.
+	uid        types.UID
+	generation int64
+}
+
+func (w *watcher) poll(ctx context.Context) {
+	obj, err := w.client.Get(ctx, w.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if w.uid != "" {
+			w.logger.Warn("scrape config removed, clearing it", "name", w.name)
+			w.uid, w.generation = "", 0
+			w.apply(Config{})
+		}
+		return
+	}
+	if err != nil {
+		w.logger.Error("failed to get scrape config", "name", w.name, "error", err)
+		return
+	}
+
+	if obj.GetUID() == w.uid && obj.GetGeneration() == w.generation {
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: obj.GetGeneration(),
+		Reason:             "Applied",
+		Message:            "The spec is applied",
+	}
+
+	config, err := specOf(obj)
+	if err != nil {
+		w.logger.Error("invalid scrape config, keeping the previous one", "name", w.name, "generation", obj.GetGeneration(), "error", err)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = err.Error()
+	} else {
+		w.logger.Info("applying scrape config", "name", w.name, "generation", obj.GetGeneration())
+		w.apply(config)
+	}
+
+	if err := w.setCondition(ctx, obj, condition); err != nil {
+		// The condition is written again at the next change of the spec,
+		// unless another exporter writes it first.
+		w.logger.Warn("failed to update scrape config status", "name", w.name, "error", err)
+	}
+	w.uid, w.generation = obj.GetUID(), obj.GetGeneration()
+}
+
+// specOf decodes and compiles the object's spec.
+func specOf(obj *unstructured.Unstructured) (Config, error) {
+	var spec Spec
+	content, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return Config{}, fmt.Errorf("spec: %w", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(content, &spec, true); err != nil {
+		return Config{}, fmt.Errorf("spec: %w", err)
+	}
+
+	return spec.Compile()
+}
+
+// setCondition writes condition to the object's status, unless it already
+// holds it, as it does when another exporter got there first.
+func (w *watcher) setCondition(ctx context.Context, obj *unstructured.Unstructured, condition metav1.Condition) error {
+	var status Status
+	if content, ok, _ := unstructured.NestedMap(obj.Object, "status"); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &status); err != nil {
+			return fmt.Errorf("status: %w", err)
+		}
+	}
+
+	if current := meta.FindStatusCondition(status.Conditions, condition.Type); current != nil &&
+		current.Status == condition.Status &&
+		current.Reason == condition.Reason &&
+		current.Message == condition.Message &&
+		current.ObservedGeneration == condition.ObservedGeneration {
+		return nil
+	}
+
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return fmt.Errorf("status: %w", err)
+	}
+	obj = obj.DeepCopy()
+	obj.Object["status"] = content
+
+	_, err = w.client.UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
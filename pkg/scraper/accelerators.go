@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+type acceleratorKey struct {
+	id    string
+	model string
+	make  string
+}
+
+type acceleratorUsage struct {
+	memoryTotal uint64
+	memoryUsed  uint64
+	dutyCycle   uint64
+	containers  uint64
+}
+
+// emitNodeAccelerators exports every accelerator of the node once, summing
+// the memory used by the containers sharing it and averaging their duty
+// cycles. Attribution to containers is often incomplete, so per-device
+// views can't be built from the container series reliably. Every pod
+// counts, including the ones not exported.
+func (s *Scraper) emitNodeAccelerators(ch chan<- prometheus.Metric, summary *statsapi.Summary) {
+	usage := map[acceleratorKey]*acceleratorUsage{}
+	add := func(accelerators []statsapi.AcceleratorStats) {
+		for _, accelerator := range accelerators {
+			key := acceleratorKey{id: accelerator.ID, model: accelerator.Model, make: accelerator.Make}
+			u, ok := usage[key]
+			if !ok {
+				u = &acceleratorUsage{}
+				usage[key] = u
+			}
+
+			// Every container reports the memory of the whole device.
+			if accelerator.MemoryTotal > u.memoryTotal {
+				u.memoryTotal = accelerator.MemoryTotal
+			}
+			u.memoryUsed += accelerator.MemoryUsed
+			u.dutyCycle += accelerator.DutyCycle
+			u.containers++
+		}
+	}
+
+	for _, container := range summary.Node.SystemContainers {
+		add(container.Accelerators)
+	}
+	for _, pod := range summary.Pods {
+		for _, container := range pod.Containers {
+			add(container.Accelerators)
+		}
+	}
+
+	keys := make([]acceleratorKey, 0, len(usage))
+	for key := range usage {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].id != keys[j].id {
+			return keys[i].id < keys[j].id
+		}
+		if keys[i].model != keys[j].model {
+			return keys[i].model < keys[j].model
+		}
+		return keys[i].make < keys[j].make
+	})
+
+	nodeName := summary.Node.NodeName
+	for _, key := range keys {
+		u := usage[key]
+		dutyCycle := u.dutyCycle / u.containers
+		s.pushMetrics(ch, s.nodeAcceleratorMemoryTotal, &u.memoryTotal, nodeName, key.id, key.model, key.make)
+		s.pushMetrics(ch, s.nodeAcceleratorMemoryUsed, &u.memoryUsed, nodeName, key.id, key.model, key.make)
+		s.pushMetrics(ch, s.nodeAcceleratorDutyCycle, &dutyCycle, nodeName, key.id, key.model, key.make)
+		s.pushMetrics(ch, s.nodeAcceleratorContainers, &u.containers, nodeName, key.id, key.model, key.make)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestNodeAccelerators(t *testing.T) {
+	gpu := func(id string, used, dutyCycle uint64) statsapi.AcceleratorStats {
+		return statsapi.AcceleratorStats{Make: "nvidia", Model: "a100", ID: id, MemoryTotal: 40e9, MemoryUsed: used, DutyCycle: dutyCycle}
+	}
+
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{
+			{
+				PodRef: statsapi.PodReference{Namespace: "ml", Name: "train"},
+				Containers: []statsapi.ContainerStats{
+					{Name: "a", Accelerators: []statsapi.AcceleratorStats{gpu("gpu-0", 10e9, 80)}},
+					{Name: "b", Accelerators: []statsapi.AcceleratorStats{gpu("gpu-0", 5e9, 40), gpu("gpu-1", 1e9, 10)}},
+				},
+			},
+			{
+				PodRef:     statsapi.PodReference{Namespace: "excluded", Name: "infer"},
+				Containers: []statsapi.ContainerStats{{Name: "c", Accelerators: []statsapi.AcceleratorStats{gpu("gpu-1", 2e9, 30)}}},
+			},
+		},
+	}
+
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithPodFilter(PodFilter{ExcludeNamespaces: regexp.MustCompile(`^(?:excluded)$`)}))
+	ch := make(chan prometheus.Metric, 100)
+	s.emitNodeAccelerators(ch, summary)
+	close(ch)
+
+	values := map[string]map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatal(err)
+		}
+
+		var id string
+		for _, label := range m.Label {
+			if label.GetName() == "id" {
+				id = label.GetValue()
+			}
+		}
+		if values[id] == nil {
+			values[id] = map[string]float64{}
+		}
+		values[id][metric.Desc().String()] = m.GetGauge().GetValue()
+	}
+
+	expected := map[string]map[*prometheus.Desc]float64{
+		"gpu-0": {s.nodeAcceleratorMemoryTotal: 40e9, s.nodeAcceleratorMemoryUsed: 15e9, s.nodeAcceleratorDutyCycle: 60, s.nodeAcceleratorContainers: 2},
+		"gpu-1": {s.nodeAcceleratorMemoryTotal: 40e9, s.nodeAcceleratorMemoryUsed: 3e9, s.nodeAcceleratorDutyCycle: 20, s.nodeAcceleratorContainers: 2},
+	}
+	for id, descs := range expected {
+		for desc, value := range descs {
+			if got := values[id][desc.String()]; got != value {
+				t.Errorf("%s: expected %v for %s, got %v", id, value, desc, got)
+			}
+		}
+	}
+}
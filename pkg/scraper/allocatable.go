@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// emitNodeResources exports the node's allocatable and capacity resources in
+// the units of the summary's usage metrics, so utilization against them can
+// be computed from this exporter's metrics alone.
+func (s *Scraper) emitNodeResources(ch chan<- prometheus.Metric, nodeName string) {
+	if s.nodes == nil {
+		return
+	}
+
+	node, ok := s.nodes.Get(nodeName)
+	if !ok {
+		return
+	}
+	nodepool := s.nodepool(node)
+
+	for _, r := range []struct {
+		resources                    v1.ResourceList
+		cpu, memory, pods, ephemeral *prometheus.Desc
+	}{
+		{node.Status.Allocatable, s.nodeAllocatableCPUNanoCores, s.nodeAllocatableMemoryBytes, s.nodeAllocatablePods, s.nodeAllocatableEphemeralStorageBytes},
+		{node.Status.Capacity, s.nodeCapacityCPUNanoCores, s.nodeCapacityMemoryBytes, s.nodeCapacityPods, s.nodeCapacityEphemeralStorageBytes},
+	} {
+		if cpu, ok := r.resources[v1.ResourceCPU]; ok {
+			ch <- prometheus.MustNewConstMetric(r.cpu, prometheus.GaugeValue, float64(cpu.MilliValue())*1e6, nodeName, nodepool)
+		}
+
+		for _, q := range []struct {
+			desc *prometheus.Desc
+			name v1.ResourceName
+		}{
+			{r.memory, v1.ResourceMemory},
+			{r.pods, v1.ResourcePods},
+			{r.ephemeral, v1.ResourceEphemeralStorage},
+		} {
+			if quantity, ok := r.resources[q.name]; ok {
+				ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, float64(quantity.Value()), nodeName, nodepool)
+			}
+		}
+	}
+}
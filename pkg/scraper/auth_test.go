@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestTokenErrorsAreAuthErrors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for tokenPath, errType := range map[string]string{
+		filepath.Join(t.TempDir(), "missing"): "token read error",
+		empty:                                 "token empty",
+	} {
+		scraper := NewScraper(logging.Nop(), "127.0.0.1", tokenPath, time.Second)
+
+		expected := `
+# HELP kubelet_summary_exporter_auth_errors Errors authenticating to kubelet, separate from errors of kubelet itself
+# TYPE kubelet_summary_exporter_auth_errors counter
+`
+		// Every type is exported from the start, the others at 0.
+		for _, other := range []string{"forbidden", "token empty", "token read error", "unauthorized"} {
+			count := 0
+			if other == errType {
+				count = 1
+			}
+			expected += fmt.Sprintf("kubelet_summary_exporter_auth_errors{type=%q} %d\n", other, count)
+		}
+		expected += `# HELP kubelet_summary_exporter_errors Errors scraping kubelet stats summary by stage and status code
+# TYPE kubelet_summary_exporter_errors counter
+kubelet_summary_exporter_errors{code="",stage="parse"} 0
+kubelet_summary_exporter_errors{code="",stage="read"} 0
+kubelet_summary_exporter_errors{code="",stage="request"} 0
+`
+		err := testutil.CollectAndCompare(scraper, strings.NewReader(expected),
+			"kubelet_summary_exporter_auth_errors", "kubelet_summary_exporter_errors")
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestAuthErrorsConcurrentScrapes(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "127.0.0.1", filepath.Join(t.TempDir(), "missing"), time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testutil.CollectAndCount(scraper)
+		}()
+	}
+	wg.Wait()
+
+	// Collecting again to compare is the ninth failed scrape.
+	expected := `
+# HELP kubelet_summary_exporter_auth_errors Errors authenticating to kubelet, separate from errors of kubelet itself
+# TYPE kubelet_summary_exporter_auth_errors counter
+kubelet_summary_exporter_auth_errors{type="forbidden"} 0
+kubelet_summary_exporter_auth_errors{type="token empty"} 0
+kubelet_summary_exporter_auth_errors{type="token read error"} 9
+kubelet_summary_exporter_auth_errors{type="unauthorized"} 0
+`
+	if err := testutil.CollectAndCompare(scraper, strings.NewReader(expected), "kubelet_summary_exporter_auth_errors"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestErrorsByStage(t *testing.T) {
+	var requests atomic.Int32
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"node":`))
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second, WithTokenSource(staticToken("token")))
+	testutil.CollectAndCount(s)
+	testutil.CollectAndCount(s)
+
+	expected := `
+# HELP kubelet_summary_exporter_errors Errors scraping kubelet stats summary by stage and status code
+# TYPE kubelet_summary_exporter_errors counter
+kubelet_summary_exporter_errors{code="",stage="parse"} 1
+kubelet_summary_exporter_errors{code="",stage="read"} 0
+kubelet_summary_exporter_errors{code="",stage="request"} 0
+kubelet_summary_exporter_errors{code="503",stage="status"} 2
+`
+	// Collecting again to compare is the scrape failing to parse.
+	if err := testutil.CollectAndCompare(s, strings.NewReader(expected), "kubelet_summary_exporter_errors"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default error budget of optional collectors.
+const (
+	defaultCollectorMaxFailures = 5
+	defaultCollectorCooldown    = 10 * time.Minute
+)
+
+// breaker disables an optional collector for a cool-down period once it
+// failed too many times in a row, so one broken endpoint doesn't add
+// latency and log spam to every scrape.
+type breaker struct {
+	mu            sync.Mutex
+	maxFailures   int
+	cooldown      time.Duration
+	failures      int
+	disabledUntil time.Time
+}
+
+// allow reports whether the collector may run.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !now.Before(b.disabledUntil)
+}
+
+// record accounts the outcome of a collector run and returns true when the
+// failure exhausted the budget and the collector got disabled.
+func (b *breaker) record(err error, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return false
+	}
+
+	b.failures++
+	if b.failures < b.maxFailures {
+		return false
+	}
+
+	b.failures = 0
+	b.disabledUntil = now.Add(b.cooldown)
+	return true
+}
+
+// WithCollectorErrorBudget sets after how many consecutive failures an
+// optional collector is disabled, and for how long.
+func WithCollectorErrorBudget(maxFailures int, cooldown time.Duration) Option {
+	return func(s *Scraper) {
+		s.collectorMaxFailures = maxFailures
+		s.collectorCooldown = cooldown
+	}
+}
+
+// breaker returns the breaker of the named optional collector.
+func (s *Scraper) breaker(collector string) *breaker {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	if s.breakers == nil {
+		s.breakers = map[string]*breaker{}
+	}
+
+	b, ok := s.breakers[collector]
+	if !ok {
+		b = &breaker{maxFailures: s.collectorMaxFailures, cooldown: s.collectorCooldown}
+		s.breakers[collector] = b
+	}
+
+	return b
+}
+
+// emitCollectorStates exports whether each optional collector is currently
+// enabled.
+func (s *Scraper) emitCollectorStates(ch chan<- prometheus.Metric) {
+	s.breakersMu.Lock()
+	collectors := sortedKeys(s.breakers)
+	s.breakersMu.Unlock()
+
+	now := time.Now()
+	for _, collector := range collectors {
+		enabled := 0.0
+		if s.breaker(collector).allow(now) {
+			enabled = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(s.collectorEnabled, prometheus.GaugeValue, enabled, collector)
+	}
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := &breaker{maxFailures: 3, cooldown: time.Minute}
+	now := time.Unix(1000, 0)
+	failure := errors.New("boom")
+
+	b.record(failure, now)
+	b.record(failure, now)
+	b.record(nil, now)
+	b.record(failure, now)
+	if !b.allow(now) {
+		t.Fatalf("a success should reset the failure count")
+	}
+
+	b.record(failure, now)
+	if disabled := b.record(failure, now); !disabled {
+		t.Fatalf("expected the third consecutive failure to disable the collector")
+	}
+	if b.allow(now.Add(30 * time.Second)) {
+		t.Errorf("collector should be disabled during the cool-down")
+	}
+	if !b.allow(now.Add(time.Minute)) {
+		t.Errorf("collector should be enabled again after the cool-down")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Cache scrapes kubelet on its own interval and serves the metrics of the
+// last successful scrape, so concurrent or slow Prometheus scrapes don't
+// reach kubelet.
+type Cache struct {
+	scraper  *Scraper
+	interval time.Duration
+	maxAge   time.Duration
+
+	// scraping serializes scrapes of the loop and of Refresh.
+	scraping sync.Mutex
+
+	mu          sync.RWMutex
+	metrics     []prometheus.Metric
+	lastSuccess time.Time
+
+	age          *prometheus.Desc
+	intervalDesc *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Cache)(nil)
+
+// NewCache returns a cache of s, scraped every interval once running. While
+// scrapes fail, the last successful one is served until it is older than
+// maxAge; the failed scrape, and so its errors, are served then. A maxAge
+// of 0 serves the last successful scrape indefinitely.
+func NewCache(s *Scraper, interval, maxAge time.Duration) *Cache {
+	return &Cache{
+		scraper:  s,
+		interval: interval,
+		maxAge:   maxAge,
+		age: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "cache", "age_seconds"),
+			"Seconds since the served metrics were scraped successfully",
+			nil,
+			nil),
+		intervalDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "cache", "interval_seconds"),
+			"Seconds between the scrapes of kubelet whose metrics are served",
+			nil,
+			nil),
+	}
+}
+
+// Run scrapes right away and then every interval until ctx is done.
+func (c *Cache) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Refresh()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh scrapes kubelet now and caches the result.
+func (c *Cache) Refresh() {
+	c.scraping.Lock()
+	defer c.scraping.Unlock()
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+	go func() {
+		var metrics []prometheus.Metric
+		for metric := range ch {
+			metrics = append(metrics, metric)
+		}
+		done <- metrics
+	}()
+
+	err := c.scraper.Scrape(ch)
+	close(ch)
+	metrics := <-done
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case err == nil:
+		c.lastSuccess = now
+	case c.lastSuccess.IsZero(), c.maxAge > 0 && now.Sub(c.lastSuccess) > c.maxAge:
+	default:
+		return
+	}
+	c.metrics = metrics
+}
+
+func (c *Cache) Describe(ch chan<- *prometheus.Desc) {
+	c.scraper.Describe(ch)
+	ch <- c.age
+	ch <- c.intervalDesc
+}
+
+func (c *Cache) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, metric := range c.metrics {
+		ch <- metric
+	}
+	if !c.lastSuccess.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.age, prometheus.GaugeValue, time.Since(c.lastSuccess).Seconds())
+	}
+	ch <- prometheus.MustNewConstMetric(c.intervalDesc, prometheus.GaugeValue, c.interval.Seconds())
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestCache(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"node":{"nodeName":"node","runtime":{}}}`))
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second, WithTokenSource(staticToken("token")))
+
+	for _, tc := range []struct {
+		name    string
+		maxAge  time.Duration
+		steps   []bool
+		success string
+	}{
+		{name: "no successful scrape", steps: []bool{false}, success: "0"},
+		{name: "failure after success", steps: []bool{true, false}, success: "1"},
+		{name: "success too old", maxAge: time.Nanosecond, steps: []bool{true, false}, success: "0"},
+	} {
+		cache := NewCache(s, time.Minute, tc.maxAge)
+		for _, ok := range tc.steps {
+			healthy.Store(ok)
+			cache.Refresh()
+		}
+
+		requests.Store(0)
+		expected := `
+# HELP kubelet_summary_exporter_last_scrape_success Whether the last scrape of kubelet stats summary succeeded
+# TYPE kubelet_summary_exporter_last_scrape_success gauge
+kubelet_summary_exporter_last_scrape_success ` + tc.success + "\n"
+		for i := 0; i < 2; i++ {
+			if err := testutil.CollectAndCompare(cache, strings.NewReader(expected), "kubelet_summary_exporter_last_scrape_success"); err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+		}
+		if n := requests.Load(); n != 0 {
+			t.Errorf("%s: expected collecting to serve the cache, got %d kubelet requests", tc.name, n)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// canaryAssertion is an invariant of the summaries of healthy kubelets.
+type canaryAssertion struct {
+	name  string
+	check func(summary *statsapi.Summary) bool
+}
+
+// canaryAssertions catch kubelets that answer, but whose stats are silently
+// broken, e.g. while cadvisor or the runtime misbehave.
+var canaryAssertions = []canaryAssertion{
+	{"node_name", func(summary *statsapi.Summary) bool {
+		return summary.Node.NodeName != ""
+	}},
+	{"pods", func(summary *statsapi.Summary) bool {
+		// A node whose pods cgroup uses memory runs pods kubelet must report.
+		for _, container := range summary.Node.SystemContainers {
+			if container.Name == statsapi.SystemContainerPods && container.Memory != nil &&
+				container.Memory.WorkingSetBytes != nil && *container.Memory.WorkingSetBytes > 0 {
+				return len(summary.Pods) > 0
+			}
+		}
+		return true
+	}},
+	{"fs_capacity", func(summary *statsapi.Summary) bool {
+		fs := summary.Node.Fs
+		return fs != nil && fs.CapacityBytes != nil && *fs.CapacityBytes > 0
+	}},
+	{"image_fs_capacity", func(summary *statsapi.Summary) bool {
+		runtime := summary.Node.Runtime
+		if runtime == nil || runtime.ImageFs == nil {
+			return false
+		}
+		return runtime.ImageFs.CapacityBytes != nil && *runtime.ImageFs.CapacityBytes > 0
+	}},
+}
+
+// WithCanary checks every scraped summary against invariants of healthy
+// kubelets and exports which ones hold.
+func WithCanary() Option {
+	return func(s *Scraper) {
+		s.canary = newCanary()
+	}
+}
+
+type canary struct {
+	mu      sync.Mutex
+	failing map[string]bool
+
+	desc *prometheus.Desc
+}
+
+func newCanary() *canary {
+	return &canary{
+		failing: map[string]bool{},
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "canary", "success"),
+			"Whether the last scraped summary satisfied the canary assertion",
+			[]string{"assertion"},
+			nil),
+	}
+}
+
+// checkCanary exports the outcome of every assertion on summary, logging
+// assertions that start or stop failing.
+func (s *Scraper) checkCanary(ch chan<- prometheus.Metric, summary *statsapi.Summary) {
+	c := s.canary
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, assertion := range canaryAssertions {
+		ok := assertion.check(summary)
+		switch {
+		case !ok && !c.failing[assertion.name]:
+			s.logger.Warn("canary assertion failed", "assertion", assertion.name,
+				"hint", "kubelet answers but its stats look broken, check kubelet's and the container runtime's logs on the node")
+		case ok && c.failing[assertion.name]:
+			s.logger.Info("canary assertion passes again", "assertion", assertion.name)
+		}
+		c.failing[assertion.name] = !ok
+
+		value := 0.0
+		if ok {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, assertion.name)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestCanary(t *testing.T) {
+	capacity, workingSet := uint64(100<<30), uint64(1<<30)
+	healthy := func() *statsapi.Summary {
+		return &statsapi.Summary{
+			Node: statsapi.NodeStats{
+				NodeName: "node",
+				Fs:       &statsapi.FsStats{CapacityBytes: &capacity},
+				Runtime:  &statsapi.RuntimeStats{ImageFs: &statsapi.FsStats{CapacityBytes: &capacity}},
+				SystemContainers: []statsapi.ContainerStats{{
+					Name:   statsapi.SystemContainerPods,
+					Memory: &statsapi.MemoryStats{WorkingSetBytes: &workingSet},
+				}},
+			},
+			Pods: []statsapi.PodStats{{PodRef: statsapi.PodReference{Namespace: "default", Name: "web"}}},
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		summary func() *statsapi.Summary
+		failing string
+	}{
+		"healthy": {summary: healthy},
+		"empty node": {summary: func() *statsapi.Summary {
+			summary := healthy()
+			summary.Node.SystemContainers, summary.Pods = nil, nil
+			return summary
+		}},
+		"node name": {failing: "node_name", summary: func() *statsapi.Summary {
+			summary := healthy()
+			summary.Node.NodeName = ""
+			return summary
+		}},
+		"pods": {failing: "pods", summary: func() *statsapi.Summary {
+			summary := healthy()
+			summary.Pods = nil
+			return summary
+		}},
+		"fs capacity": {failing: "fs_capacity", summary: func() *statsapi.Summary {
+			summary := healthy()
+			summary.Node.Fs = &statsapi.FsStats{CapacityBytes: new(uint64)}
+			return summary
+		}},
+		"image fs capacity": {failing: "image_fs_capacity", summary: func() *statsapi.Summary {
+			summary := healthy()
+			summary.Node.Runtime = nil
+			return summary
+		}},
+	} {
+		s := NewScraper(logging.Nop(), "", "", time.Second, WithCanary())
+		summary := tc.summary()
+		collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.checkCanary(ch, summary) })
+
+		expected := "# HELP kubelet_summary_exporter_canary_success Whether the last scraped summary satisfied the canary assertion\n" +
+			"# TYPE kubelet_summary_exporter_canary_success gauge\n"
+		for _, assertion := range []string{"fs_capacity", "image_fs_capacity", "node_name", "pods"} {
+			value := "1"
+			if assertion == tc.failing {
+				value = "0"
+			}
+			expected += `kubelet_summary_exporter_canary_success{assertion="` + assertion + `"} ` + value + "\n"
+		}
+
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
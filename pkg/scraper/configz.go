@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// defaultImageGCHighThresholdPercent is the kubelet's default when configz
+// does not report a value.
+const defaultImageGCHighThresholdPercent = 85
+
+// configz is the subset of the kubelet's /configz response the exporter uses.
+type configz struct {
+	KubeletConfig struct {
+		ImageGCHighThresholdPercent *int32 `json:"imageGCHighThresholdPercent"`
+	} `json:"kubeletconfig"`
+}
+
+// imageGCConfig caches the kubelet's image garbage collection threshold,
+// which only changes with a kubelet restart.
+type imageGCConfig struct {
+	mu                   sync.Mutex
+	ttl                  time.Duration
+	fetched              time.Time
+	highThresholdPercent int32
+	known                bool
+}
+
+// WithImageGC enables image filesystem garbage collection headroom metrics
+// using thresholds read from the kubelet's /configz endpoint, refreshed
+// every ttl.
+func WithImageGC(ttl time.Duration) Option {
+	return func(s *Scraper) {
+		s.imageGC = &imageGCConfig{ttl: ttl}
+	}
+}
+
+func (c *imageGCConfig) threshold() (int32, bool) {
+	if c == nil {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.highThresholdPercent, c.known
+}
+
+// refreshImageGC re-reads /configz once the cached threshold is older than
+// its ttl. Failures keep the previous threshold and are retried on the next
+// scrape until the configz collector runs out of error budget.
+func (s *Scraper) refreshImageGC() {
+	if s.imageGC == nil {
+		return
+	}
+
+	s.imageGC.mu.Lock()
+	defer s.imageGC.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.imageGC.fetched) < s.imageGC.ttl {
+		return
+	}
+
+	b := s.breaker("configz")
+	if !b.allow(now) {
+		return
+	}
+
+	cfg, err := s.fetchConfigz()
+	if b.record(err, now) {
+		s.logger.Warn("disabling configz collector after repeated failures", "cooldown", s.collectorCooldown.String())
+	}
+	if err != nil {
+		s.logger.Warn("failed to read kubelet configz", "error", err)
+		return
+	}
+	s.imageGC.fetched = now
+
+	s.imageGC.highThresholdPercent = defaultImageGCHighThresholdPercent
+	if threshold := cfg.KubeletConfig.ImageGCHighThresholdPercent; threshold != nil {
+		s.imageGC.highThresholdPercent = *threshold
+	}
+	s.imageGC.known = true
+}
+
+func (s *Scraper) fetchConfigz() (*configz, error) {
+	resp, err := s.GetKubelet(context.Background(), "/configz")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var cfg configz
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// GetKubelet requests path on kubelet like the summary is requested: through
+// the node proxy if any, with the same token and connections. Responses
+// other than 200 are errors. It serves collectors of other kubelet
+// endpoints.
+func (s *Scraper) GetKubelet(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.kubeletURL(path, nil), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.authenticated() {
+		token, err := s.tokens().Token()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+// emitImageGC exports how far the image filesystem is from the kubelet's
+// image garbage collection threshold. The kubelet computes usage from
+// available bytes, so the same is done here.
+func (s *Scraper) emitImageGC(ch chan<- prometheus.Metric, nodeName string, imageFs *statsapi.FsStats) {
+	highThresholdPercent, ok := s.imageGC.threshold()
+	if !ok || imageFs == nil || imageFs.AvailableBytes == nil || imageFs.CapacityBytes == nil || *imageFs.CapacityBytes == 0 {
+		return
+	}
+
+	capacity := float64(*imageFs.CapacityBytes)
+	available := float64(*imageFs.AvailableBytes)
+	headroom := available - capacity*float64(100-highThresholdPercent)/100
+
+	imminent := 0.0
+	if headroom <= 0 {
+		imminent = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(s.nodeRuntimeImageFsGCHeadroomBytes, prometheus.GaugeValue, headroom, nodeName)
+	ch <- prometheus.MustNewConstMetric(s.nodeRuntimeImageFsGCImminent, prometheus.GaugeValue, imminent, nodeName)
+}
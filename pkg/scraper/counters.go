@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cumulativeSuffixes end the names of metrics whose kubelet values only ever
+// grow, until the container or node restarts.
+var cumulativeSuffixes = []string{
+	"_usage_core_nano_seconds",
+	"_page_faults",
+	"_rx_bytes",
+	"_tx_bytes",
+	"_rx_errors",
+	"_tx_errors",
+}
+
+func cumulative(name string) bool {
+	for _, suffix := range cumulativeSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCounters exposes cumulative values as counters named with a _total
+// suffix instead of gauges, so rate() and OpenMetrics consumers treat them
+// correctly. It will be the default in the next major version.
+func WithCounters() Option {
+	return func(s *Scraper) {
+		s.counters = true
+	}
+}
+
+// useCounters replaces the descriptors of cumulative metrics with counter
+// descriptors. pushMetrics emits through the replacements, so the fields
+// holding the original descriptors keep working.
+func (s *Scraper) useCounters() {
+	if !s.counters {
+		return
+	}
+
+	s.counterDescs = map[*prometheus.Desc]*prometheus.Desc{}
+	for i, info := range s.descs.infos {
+		if info.Group == "expressions" || !cumulative(info.Name) {
+			continue
+		}
+
+		counter := prometheus.NewDesc(info.Name+"_total", info.Help, info.Labels, nil)
+		s.counterDescs[s.descs.descs[i]] = counter
+		s.descs.descs[i] = counter
+		s.descs.infos[i].Name = info.Name + "_total"
+		s.descs.infos[i].Counter = true
+	}
+}
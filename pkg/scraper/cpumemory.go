@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithOnlyCPUAndMemory requests summaries with only_cpu_and_memory=true,
+// which kubelet serves without computing disk usage, and leaves out the
+// filesystem and volume metrics such summaries lack.
+func WithOnlyCPUAndMemory() Option {
+	return func(s *Scraper) {
+		s.onlyCPUAndMemory = true
+	}
+}
+
+// storageGroup tells the metric groups of filesystem stats, which kubelet
+// computes by walking the disks.
+func storageGroup(subsystem string) bool {
+	return strings.HasSuffix(subsystem, "_fs") ||
+		strings.HasSuffix(subsystem, "_logs") ||
+		subsystem == "pod_ephemeral_storage" ||
+		subsystem == "pod_volume"
+}
+
+// dropStorageDescs unregisters the descriptors of storage metrics with
+// WithOnlyCPUAndMemory, and stops the ones derived from other sources from
+// being emitted. It runs once all options have registered their
+// descriptors.
+func (s *Scraper) dropStorageDescs() {
+	if !s.onlyCPUAndMemory {
+		return
+	}
+
+	if s.statsQuery == nil {
+		s.statsQuery = url.Values{}
+	}
+	s.statsQuery.Set("only_cpu_and_memory", "true")
+
+	if s.disabledDescs == nil {
+		s.disabledDescs = map[*prometheus.Desc]struct{}{}
+	}
+	kept := &descRegistry{}
+	for i, info := range s.descs.infos {
+		if storageGroup(info.Group) {
+			s.disabledDescs[s.descs.descs[i]] = struct{}{}
+			continue
+		}
+		kept.descs = append(kept.descs, s.descs.descs[i])
+		kept.infos = append(kept.infos, info)
+	}
+	s.descs = kept
+}
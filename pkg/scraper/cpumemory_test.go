@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestOnlyCPUAndMemory(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "10.0.0.1", "", time.Second,
+		WithStatsPath("", map[string]string{"a": "b"}), WithOnlyCPUAndMemory())
+
+	if want := "https://10.0.0.1:10250/stats/summary?a=b&only_cpu_and_memory=true"; scraper.statsURL() != want {
+		t.Errorf("expected %s, got %s", want, scraper.statsURL())
+	}
+
+	storage := regexp.MustCompile(`kubelet_summary_(\w+_fs|\w+_logs|pod_ephemeral_storage|pod_volume)_`)
+	descs := make(chan *prometheus.Desc, 1000)
+	scraper.Describe(descs)
+	close(descs)
+	for desc := range descs {
+		if storage.MatchString(desc.String()) {
+			t.Errorf("%s is a storage metric but described", desc)
+		}
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	filtered, flush := scraper.filterDisabled(ch)
+	scraper.emit(filtered, syntheticSummary(time.Now()))
+	flush()
+	close(ch)
+
+	emitted := 0
+	for metric := range ch {
+		emitted++
+		if storage.MatchString(metric.Desc().String()) {
+			t.Errorf("%s is a storage metric but emitted", metric.Desc())
+		}
+	}
+	if emitted == 0 {
+		t.Error("expected cpu and memory metrics to be emitted")
+	}
+
+	for _, info := range scraper.Metrics() {
+		if strings.Contains(info.Name, "_fs_") {
+			t.Errorf("%s is a storage metric but listed in Metrics", info.Name)
+		}
+	}
+}
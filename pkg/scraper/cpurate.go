@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+// missingNanoCores returns the cumulative CPU usage of the containers of
+// pods that report it without usageNanoCores, which is derived from it
+// between summaries instead.
+func missingNanoCores(pods []*statsapi.PodStats) map[containerKey]cumulativeValue {
+	observed := map[containerKey]cumulativeValue{}
+	for _, pod := range pods {
+		for _, container := range pod.Containers {
+			cpu := container.CPU
+			if cpu == nil || cpu.UsageNanoCores != nil || cpu.UsageCoreNanoSeconds == nil {
+				continue
+			}
+			observed[containerKey{namespace: pod.PodRef.Namespace, pod: pod.PodRef.Name, container: container.Name}] = cumulativeValue{
+				value: *cpu.UsageCoreNanoSeconds,
+				time:  cpu.Time.Time,
+			}
+		}
+	}
+	return observed
+}
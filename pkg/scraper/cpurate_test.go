@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestMissingNanoCores(t *testing.T) {
+	usage, nanoCores := uint64(1e9), uint64(5e8)
+	at := metav1.Now()
+	pods := []*statsapi.PodStats{{
+		PodRef: statsapi.PodReference{Name: "web", Namespace: "default"},
+		Containers: []statsapi.ContainerStats{
+			{Name: "reported", CPU: &statsapi.CPUStats{Time: at, UsageNanoCores: &nanoCores, UsageCoreNanoSeconds: &usage}},
+			{Name: "missing", CPU: &statsapi.CPUStats{Time: at, UsageCoreNanoSeconds: &usage}},
+			{Name: "no cpu"},
+		},
+	}}
+
+	observed := missingNanoCores(pods)
+	if len(observed) != 1 {
+		t.Fatalf("expected only the container missing usageNanoCores, got %v", observed)
+	}
+	if got := observed[containerKey{namespace: "default", pod: "web", container: "missing"}]; got.value != usage || !got.time.Equal(at.Time) {
+		t.Errorf("expected the container's usage at its CPU time, got %+v", got)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithNativeHistograms additionally exposes histograms as native histograms
+// with the given bucket growth factor, e.g. 1.1. Native histograms are only
+// transferred over the protobuf exposition format and need Prometheus 2.40+
+// with the native-histograms feature enabled; the classic buckets are kept
+// for everyone else.
+func WithNativeHistograms(bucketFactor float64) Option {
+	return func(s *Scraper) {
+		s.nativeHistogramBucketFactor = bucketFactor
+	}
+}
+
+// newScrapeDuration returns the histogram of kubelet stats summary request
+// durations.
+func newScrapeDuration(nativeBucketFactor float64) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:                   "kubelet_summary_exporter",
+		Name:                        "scrape_duration_seconds",
+		Help:                        "Duration of requesting and parsing kubelet stats summary",
+		Buckets:                     []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		NativeHistogramBucketFactor: nativeBucketFactor,
+	})
+}
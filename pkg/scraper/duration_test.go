@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestNativeHistograms(t *testing.T) {
+	for _, tc := range []struct {
+		opts   []Option
+		native bool
+	}{
+		{},
+		{opts: []Option{WithNativeHistograms(1.1)}, native: true},
+	} {
+		scraper := NewScraper(logging.Nop(), "", "", time.Second, tc.opts...)
+		scraper.scrapeDuration.Observe(0.2)
+
+		var m dto.Metric
+		if err := scraper.scrapeDuration.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+
+		if native := m.GetHistogram().Schema != nil; native != tc.native {
+			t.Errorf("expected native histogram %t, got %t", tc.native, native)
+		}
+		if len(m.GetHistogram().GetBucket()) == 0 {
+			t.Errorf("expected classic buckets to be kept")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+)
+
+// DynamicConfig holds settings that change while the exporter runs, e.g.
+// when read from a ScrapeConfig object. They narrow the static options: a
+// pod is only exported when it passes both pod filters.
+type DynamicConfig struct {
+	mu        sync.RWMutex
+	podFilter PodFilter
+	podLabels map[string]struct{}
+}
+
+// NewDynamicConfig returns a config that doesn't constrain anything until it
+// is Set.
+func NewDynamicConfig() *DynamicConfig {
+	return &DynamicConfig{}
+}
+
+// Set replaces the pod filter and the allowlist of pod annotation label
+// keys. An empty allowlist allows every key.
+func (c *DynamicConfig) Set(filter PodFilter, podLabels []string) {
+	var allowed map[string]struct{}
+	if len(podLabels) > 0 {
+		allowed = make(map[string]struct{}, len(podLabels))
+		for _, key := range podLabels {
+			allowed["label_"+sanitizeLabelName(key)] = struct{}{}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.podFilter = filter
+	c.podLabels = allowed
+}
+
+// WithDynamicConfig applies the pod filter of config on every scrape.
+func WithDynamicConfig(config *DynamicConfig) Option {
+	return func(s *Scraper) {
+		s.dynamic = config
+	}
+}
+
+// keep reports whether the pod's metrics are exported. A nil config keeps
+// every pod.
+func (c *DynamicConfig) keep(namespace, pod string) bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.podFilter.keep(namespace, pod)
+}
+
+// podLabelAllowed reports whether the sanitized annotation label is
+// exported. A nil config allows every label.
+func (c *DynamicConfig) podLabelAllowed(name string) bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.podLabels == nil {
+		return true
+	}
+	_, ok := c.podLabels[name]
+	return ok
+}
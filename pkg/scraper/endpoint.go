@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/nodeproxy"
+)
+
+const (
+	defaultStatsPath = "/stats/summary"
+	defaultScheme    = "https"
+	defaultPort      = "10250"
+)
+
+// WithEndpoint requests kubelet over scheme on port instead of https on
+// 10250, e.g. over http on the read-only port 10255. No token is sent over
+// http. Targets with a port of their own keep it.
+func WithEndpoint(scheme string, port int) Option {
+	return func(s *Scraper) {
+		if scheme != "" {
+			s.scheme = scheme
+		}
+		if port != 0 {
+			s.port = strconv.Itoa(port)
+		}
+	}
+}
+
+// CheckEndpoint fails for endpoints WithEndpoint can't request.
+func CheckEndpoint(scheme string, port int) error {
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q, expected http or https", scheme)
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port %d out of range", port)
+	}
+	return nil
+}
+
+// WithStatsPath requests the summary from path instead of /stats/summary and
+// adds query to the request, for kubelets fronted by a reverse proxy.
+func WithStatsPath(path string, query map[string]string) Option {
+	return func(s *Scraper) {
+		if path != "" {
+			s.statsPath = "/" + strings.TrimPrefix(path, "/")
+		}
+
+		if len(query) > 0 {
+			s.statsQuery = url.Values{}
+			for key, value := range query {
+				s.statsQuery.Set(key, value)
+			}
+		}
+	}
+}
+
+// WithMaxSummaryBytes fails scrapes of summaries larger than n bytes, to
+// protect the exporter from pathological responses. 0 disables the limit.
+func WithMaxSummaryBytes(n int64) Option {
+	return func(s *Scraper) {
+		s.maxSummaryBytes = n
+	}
+}
+
+// WithNodeProxy requests kubelet through the api-server's node proxy, with
+// the target being the node's name. The proxy authenticates to the
+// api-server, so no kubelet token is sent.
+func WithNodeProxy(proxy *nodeproxy.Proxy) Option {
+	return func(s *Scraper) {
+		s.nodeProxy = proxy
+	}
+}
+
+// statsURL returns the URL of the kubelet stats summary.
+func (s *Scraper) statsURL() string {
+	return s.kubeletURL(s.statsPath, s.statsQuery)
+}
+
+// kubeletURL returns the URL of path on kubelet, through the node proxy if
+// there is one.
+func (s *Scraper) kubeletURL(path string, query url.Values) string {
+	if s.nodeProxy != nil {
+		return s.nodeProxy.URL(s.targetIP, path, query)
+	}
+
+	u := url.URL{
+		Scheme:   s.scheme,
+		Host:     s.kubeletHost(),
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// kubeletHost returns the target with kubelet's port unless the target has a
+// port of its own.
+func (s *Scraper) kubeletHost() string {
+	if _, _, err := net.SplitHostPort(s.targetIP); err == nil {
+		return s.targetIP
+	}
+
+	return net.JoinHostPort(s.targetIP, s.port)
+}
+
+// authenticated reports whether requests to kubelet carry a kubelet token,
+// which is never sent in the clear nor to the node proxy.
+func (s *Scraper) authenticated() bool {
+	return s.scheme != "http" && s.nodeProxy == nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/nodeproxy"
+	"k8s.io/client-go/rest"
+)
+
+func TestStatsURL(t *testing.T) {
+	for _, tc := range []struct {
+		target string
+		opts   []Option
+		want   string
+	}{
+		{
+			want: "https://10.0.0.1:10250/stats/summary",
+		},
+		{
+			target: "10.0.0.2:10255",
+			want:   "https://10.0.0.2:10255/stats/summary",
+		},
+		{
+			target: "fd00::1",
+			want:   "https://[fd00::1]:10250/stats/summary",
+		},
+		{
+			opts: []Option{WithStatsPath("proxy/kubelet/stats/summary", map[string]string{"only_cpu_and_memory": "true", "a": "b c"})},
+			want: "https://10.0.0.1:10250/proxy/kubelet/stats/summary?a=b+c&only_cpu_and_memory=true",
+		},
+		{
+			opts: []Option{WithEndpoint("http", 10255)},
+			want: "http://10.0.0.1:10255/stats/summary",
+		},
+		{
+			target: "10.0.0.2:4194",
+			opts:   []Option{WithEndpoint("http", 10255)},
+			want:   "http://10.0.0.2:4194/stats/summary",
+		},
+	} {
+		target := tc.target
+		if target == "" {
+			target = "10.0.0.1"
+		}
+
+		scraper := NewScraper(logging.Nop(), target, "", time.Second, tc.opts...)
+		if got := scraper.statsURL(); got != tc.want {
+			t.Errorf("expected %s, got %s", tc.want, got)
+		}
+	}
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		scheme string
+		port   int
+		ok     bool
+	}{
+		{scheme: "https", port: 10250, ok: true},
+		{scheme: "http", port: 10255, ok: true},
+		{scheme: "ftp", port: 21},
+		{scheme: "https", port: 0},
+		{scheme: "https", port: 70000},
+	} {
+		if err := CheckEndpoint(tc.scheme, tc.port); (err == nil) != tc.ok {
+			t.Errorf("%s:%d: expected ok=%v, got %v", tc.scheme, tc.port, tc.ok, err)
+		}
+	}
+}
+
+func TestNodeProxyURL(t *testing.T) {
+	proxy, err := nodeproxy.New(&rest.Config{Host: "https://api.example.com:6443"}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scraper := NewScraper(logging.Nop(), "node-a", "", time.Second, WithNodeProxy(proxy), WithStatsPath("", map[string]string{"a": "b"}))
+	if got, want := scraper.statsURL(), "https://api.example.com:6443/api/v1/nodes/node-a/proxy/stats/summary?a=b"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if scraper.authenticated() {
+		t.Error("expected no kubelet token to be sent to the api-server")
+	}
+}
@@ -0,0 +1,283 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"sigs.k8s.io/yaml"
+)
+
+// Expression defines an additional metric extracted from the raw summary
+// JSON with jq-like paths, for fields the exporter doesn't know about yet.
+//
+// Paths are made of .field, [N] (index) and, in Each only, [] (every
+// element) steps. Each selects the elements to export one series for, e.g.
+// ".pods[].containers[]". Value and label paths are resolved against the
+// selected element first and then against the elements enclosing it, so
+// ".name" is the container's name and ".podRef.name" its pod's name.
+type Expression struct {
+	Name   string            `json:"name"`
+	Help   string            `json:"help"`
+	Each   string            `json:"each"`
+	Value  string            `json:"value"`
+	Labels map[string]string `json:"labels"`
+
+	each   path
+	value  path
+	labels []string
+	paths  []path
+	desc   *prometheus.Desc
+}
+
+// ParseExpressions parses and validates a YAML list of expressions.
+func ParseExpressions(data []byte) ([]*Expression, error) {
+	var exprs []*Expression
+	if err := yaml.UnmarshalStrict(data, &exprs); err != nil {
+		return nil, err
+	}
+
+	for _, expr := range exprs {
+		if err := expr.compile(); err != nil {
+			return nil, fmt.Errorf("expression %q: %w", expr.Name, err)
+		}
+	}
+
+	return exprs, nil
+}
+
+func (e *Expression) compile() error {
+	var err error
+
+	if !model.IsValidMetricName(model.LabelValue(prometheus.BuildFQName(metricNamespace, "", e.Name))) {
+		return fmt.Errorf("invalid metric name")
+	}
+
+	if e.each, err = compilePath(e.Each, true); err != nil {
+		return fmt.Errorf("each: %w", err)
+	}
+	if e.value, err = compilePath(e.Value, false); err != nil {
+		return fmt.Errorf("value: %w", err)
+	}
+
+	e.labels = sortedKeys(e.Labels)
+	e.paths = make([]path, len(e.labels))
+	for i, label := range e.labels {
+		if !model.LabelName(label).IsValid() || label == "node" {
+			return fmt.Errorf("invalid label name %q", label)
+		}
+		if e.paths[i], err = compilePath(e.Labels[label], false); err != nil {
+			return fmt.Errorf("label %s: %w", label, err)
+		}
+	}
+
+	return nil
+}
+
+// WithExpressions exports the metrics defined by exprs, which must come from
+// ParseExpressions.
+func WithExpressions(exprs []*Expression) Option {
+	return func(s *Scraper) {
+		for _, expr := range exprs {
+			help := expr.Help
+			if help == "" {
+				help = fmt.Sprintf("Value of %s for each %s", expr.Value, expr.Each)
+			}
+
+			expr.desc = s.descs.add("", expr.Name, help, append([]string{"node"}, expr.labels...))
+			s.descs.infos[len(s.descs.infos)-1].Group = "expressions"
+			s.expressions = append(s.expressions, expr)
+		}
+	}
+}
+
+// emitExpressions evaluates the configured expressions against the raw
+// summary.
+func (s *Scraper) emitExpressions(ch chan<- prometheus.Metric, nodeName string, body []byte) {
+	if len(s.expressions) == 0 {
+		return
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		s.logger.Warn("failed to decode summary for expressions", "error", err)
+		return
+	}
+
+	for _, expr := range s.expressions {
+		for _, scope := range expr.each.iterate([]any{doc}) {
+			value, ok := metricValue(resolve(scope, expr.value))
+			if !ok {
+				continue
+			}
+
+			labelValues := make([]string, 0, len(expr.paths)+1)
+			labelValues = append(labelValues, nodeName)
+			for _, p := range expr.paths {
+				labelValues = append(labelValues, labelValue(resolve(scope, p)))
+			}
+
+			ch <- prometheus.MustNewConstMetric(expr.desc, prometheus.GaugeValue, value, labelValues...)
+		}
+	}
+}
+
+// step is one element of a path: a field name, an index, or every element
+// when iterate is set.
+type step struct {
+	field   string
+	index   int
+	isIndex bool
+	iterate bool
+}
+
+type path []step
+
+func compilePath(expr string, allowIterate bool) (path, error) {
+	if !strings.HasPrefix(expr, ".") {
+		return nil, fmt.Errorf("path %q must start with .", expr)
+	}
+
+	var p path
+	rest := expr
+	for rest != "" && rest != "." {
+		switch {
+		case strings.HasPrefix(rest, "[]"):
+			if !allowIterate {
+				return nil, fmt.Errorf("path %q: [] is only allowed in each", expr)
+			}
+			p = append(p, step{iterate: true})
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q: unterminated [", expr)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("path %q: invalid index %q", expr, rest[1:end])
+			}
+			p = append(p, step{index: index, isIndex: true})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %q: empty field name", expr)
+			}
+			p = append(p, step{field: rest[:end]})
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", expr, rest)
+		}
+	}
+
+	return p, nil
+}
+
+// iterate walks the path from the innermost element of scope and returns a
+// scope for every selected element, i.e. scope followed by every value
+// visited on the way to it.
+func (p path) iterate(scope []any) [][]any {
+	scopes := [][]any{scope}
+
+	for _, st := range p {
+		var next [][]any
+		for _, sc := range scopes {
+			current := sc[len(sc)-1]
+
+			if !st.iterate {
+				if v, ok := st.apply(current); ok {
+					next = append(next, append(sc[:len(sc):len(sc)], v))
+				}
+				continue
+			}
+
+			elements, _ := current.([]any)
+			for _, element := range elements {
+				next = append(next, append(sc[:len(sc):len(sc)], element))
+			}
+		}
+		scopes = next
+	}
+
+	return scopes
+}
+
+func (st step) apply(v any) (any, bool) {
+	if st.isIndex {
+		elements, ok := v.([]any)
+		if !ok || st.index >= len(elements) {
+			return nil, false
+		}
+		return elements[st.index], true
+	}
+
+	fields, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	field, ok := fields[st.field]
+	return field, ok
+}
+
+// get follows a path without [] steps.
+func (p path) get(v any) (any, bool) {
+	for _, st := range p {
+		var ok bool
+		if v, ok = st.apply(v); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// resolve looks the path up in the innermost element of scope that has it.
+func resolve(scope []any, p path) any {
+	for i := len(scope) - 1; i >= 0; i-- {
+		if v, ok := p.get(scope[i]); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func metricValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func labelValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+const expressionsSummary = `{
+  "node": {"nodeName": "node-a", "futureStats": {"psiSomeAvg10": 1.5}},
+  "pods": [
+    {
+      "podRef": {"name": "web", "namespace": "shop"},
+      "containers": [
+        {"name": "app", "futureStats": {"throttled": true}},
+        {"name": "sidecar", "futureStats": {}}
+      ]
+    }
+  ]
+}`
+
+// expressionsCollector emits the expressions of a scraper for a fixed body.
+type expressionsCollector struct {
+	scraper *Scraper
+}
+
+func (c expressionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, expr := range c.scraper.expressions {
+		ch <- expr.desc
+	}
+}
+
+func (c expressionsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.scraper.emitExpressions(ch, "node-a", []byte(expressionsSummary))
+}
+
+func TestExpressions(t *testing.T) {
+	exprs, err := ParseExpressions([]byte(`
+- name: node_psi_some_avg10
+  each: .node
+  value: .futureStats.psiSomeAvg10
+- name: container_throttled
+  help: Whether the container is throttled
+  each: .pods[].containers[]
+  value: .futureStats.throttled
+  labels:
+    namespace: .podRef.namespace
+    pod: .podRef.name
+    container: .name
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scraper := NewScraper(logging.Nop(), "", "", time.Second, WithExpressions(exprs))
+
+	expected := `
+# HELP kubelet_summary_container_throttled Whether the container is throttled
+# TYPE kubelet_summary_container_throttled gauge
+kubelet_summary_container_throttled{container="app",namespace="shop",node="node-a",pod="web"} 1
+# HELP kubelet_summary_node_psi_some_avg10 Value of .futureStats.psiSomeAvg10 for each .node
+# TYPE kubelet_summary_node_psi_some_avg10 gauge
+kubelet_summary_node_psi_some_avg10{node="node-a"} 1.5
+`
+	if err := testutil.CollectAndCompare(expressionsCollector{scraper}, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseExpressionsErrors(t *testing.T) {
+	for _, config := range []string{
+		"- {name: x, each: pods, value: .a}",
+		"- {name: x, each: .pods[], value: .containers[]}",
+		"- {name: x, each: .pods[, value: .a}",
+		"- {name: x-y, each: .pods[], value: .a}",
+		"- {name: x, each: .pods[], value: .a, labels: {node: .b}}",
+		"- {name: x, each: .pods[], value: .a, unknown: 1}",
+	} {
+		if _, err := ParseExpressions([]byte(config)); err == nil {
+			t.Errorf("expected %s to be rejected", config)
+		}
+	}
+}
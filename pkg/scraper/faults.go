@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Faults are failures injected into kubelet requests so monitoring teams can
+// check that their alerts catch exporter failure modes. Not for production.
+type Faults struct {
+	// Latency is added to every request.
+	Latency time.Duration
+	// FailRatio is the share of requests, between 0 and 1, that fail.
+	FailRatio float64
+	// Stale replays the first successful response of each URL forever.
+	Stale bool
+}
+
+func (f Faults) enabled() bool {
+	return f.Latency > 0 || f.FailRatio > 0 || f.Stale
+}
+
+// WithFaults injects faults into every kubelet request.
+func WithFaults(faults Faults) Option {
+	return func(s *Scraper) {
+		if faults.enabled() {
+			s.faults = &faultTransport{faults: faults, stale: map[string][]byte{}}
+		}
+	}
+}
+
+var errInjectedFault = errors.New("injected fault")
+
+// faultTransport wraps the transport of kubelet requests with the faults.
+type faultTransport struct {
+	faults Faults
+
+	mu    sync.Mutex
+	stale map[string][]byte
+}
+
+// wrap applies the faults to requests sent through next, sharing the stale
+// responses with every other wrapped transport.
+func (t *faultTransport) wrap(next http.RoundTripper) http.RoundTripper {
+	return &faultTransportInstance{faultTransport: t, next: next}
+}
+
+type faultTransportInstance struct {
+	*faultTransport
+	next http.RoundTripper
+}
+
+func (t *faultTransportInstance) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.faults.Latency > 0 {
+		select {
+		case <-time.After(t.faults.Latency):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	//nolint:gosec // Fault injection doesn't need a secure random source.
+	if t.faults.FailRatio > 0 && rand.Float64() < t.faults.FailRatio {
+		return nil, errInjectedFault
+	}
+
+	if !t.faults.Stale {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	t.mu.Lock()
+	body, ok := t.stale[key]
+	t.mu.Unlock()
+	if ok {
+		return staleResponse(req, body), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.stale[key] = body
+	t.mu.Unlock()
+
+	return staleResponse(req, body), nil
+}
+
+func staleResponse(req *http.Request, body []byte) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestFaults(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, "response %d", calls)
+	}))
+	defer server.Close()
+
+	get := func(scraper *Scraper) (string, error) {
+		resp, err := scraper.httpClient.Get(server.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	stale := NewScraper(logging.Nop(), "", "", time.Second, WithFaults(Faults{Stale: true}))
+	for i := 0; i < 3; i++ {
+		body, err := get(stale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "response 1" {
+			t.Errorf("expected the first response to be replayed, got %q", body)
+		}
+	}
+
+	failing := NewScraper(logging.Nop(), "", "", time.Second, WithFaults(Faults{FailRatio: 1}))
+	if _, err := get(failing); !errors.Is(err, errInjectedFault) {
+		t.Errorf("expected an injected fault, got %v", err)
+	}
+
+	slow := NewScraper(logging.Nop(), "", "", time.Second, WithFaults(Faults{Latency: 50 * time.Millisecond}))
+	start := time.Now()
+	if _, err := get(slow); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected injected latency, took %s", elapsed)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"regexp"
+)
+
+// PodFilter selects the pods whose metrics are exported. A nil expression
+// doesn't constrain anything; excludes win over includes.
+type PodFilter struct {
+	IncludeNamespaces *regexp.Regexp
+	ExcludeNamespaces *regexp.Regexp
+	IncludePods       *regexp.Regexp
+	ExcludePods       *regexp.Regexp
+}
+
+// WithPodFilter drops the pod, container, volume and network metrics of
+// pods not selected by filter, e.g. of high-churn CI namespaces.
+func WithPodFilter(filter PodFilter) Option {
+	return func(s *Scraper) {
+		s.podFilter = filter
+	}
+}
+
+// keep reports whether the pod's metrics are exported.
+func (f PodFilter) keep(namespace, pod string) bool {
+	switch {
+	case f.ExcludeNamespaces != nil && f.ExcludeNamespaces.MatchString(namespace):
+		return false
+	case f.ExcludePods != nil && f.ExcludePods.MatchString(pod):
+		return false
+	case f.IncludeNamespaces != nil && !f.IncludeNamespaces.MatchString(namespace):
+		return false
+	case f.IncludePods != nil && !f.IncludePods.MatchString(pod):
+		return false
+	}
+	return true
+}
+
+// InterfaceFilter selects the network interfaces whose metrics are
+// exported. A nil expression doesn't constrain anything; excludes win over
+// includes.
+type InterfaceFilter struct {
+	Include *regexp.Regexp
+	Exclude *regexp.Regexp
+}
+
+// WithInterfaceFilter drops the node and pod interface metrics of interfaces
+// not selected by filter, e.g. of the veth, cali and lxc interfaces of every
+// pod on the node. Pods still count all their interfaces.
+func WithInterfaceFilter(filter InterfaceFilter) Option {
+	return func(s *Scraper) {
+		s.interfaceFilter = filter
+	}
+}
+
+// keep reports whether the interface's metrics are exported.
+func (f InterfaceFilter) keep(name string) bool {
+	switch {
+	case f.Exclude != nil && f.Exclude.MatchString(name):
+		return false
+	case f.Include != nil && !f.Include.MatchString(name):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPodFilter(t *testing.T) {
+	filter := PodFilter{
+		IncludeNamespaces: regexp.MustCompile(`^(?:shop|ci-.*)$`),
+		ExcludeNamespaces: regexp.MustCompile(`^(?:ci-nightly)$`),
+		ExcludePods:       regexp.MustCompile(`^(?:.*-canary-.*)$`),
+	}
+
+	for _, tc := range []struct {
+		namespace, pod string
+		keep           bool
+	}{
+		{"shop", "web-1", true},
+		{"ci-pr-42", "build-1", true},
+		{"ci-nightly", "build-1", false},
+		{"kube-system", "coredns-1", false},
+		{"shop", "web-canary-1", false},
+	} {
+		if got := filter.keep(tc.namespace, tc.pod); got != tc.keep {
+			t.Errorf("%s/%s: expected keep %v, got %v", tc.namespace, tc.pod, tc.keep, got)
+		}
+	}
+
+	if !(PodFilter{}).keep("any", "pod") {
+		t.Errorf("an empty filter should keep every pod")
+	}
+}
+
+func TestDynamicConfig(t *testing.T) {
+	var unset *DynamicConfig
+	if !unset.keep("any", "pod") || !unset.podLabelAllowed("label_team") {
+		t.Errorf("a nil config should keep every pod and label")
+	}
+
+	config := NewDynamicConfig()
+	config.Set(PodFilter{ExcludeNamespaces: regexp.MustCompile(`^(?:ci-.*)$`)}, []string{"cost-center"})
+
+	if config.keep("ci-pr-42", "build-1") || !config.keep("shop", "web-1") {
+		t.Errorf("expected the set filter to apply")
+	}
+	if !config.podLabelAllowed("label_cost_center") || config.podLabelAllowed("label_team") {
+		t.Errorf("expected the allowlist to match sanitized label names")
+	}
+
+	config.Set(PodFilter{}, nil)
+	if !config.keep("ci-pr-42", "build-1") || !config.podLabelAllowed("label_team") {
+		t.Errorf("expected an empty config to keep every pod and label")
+	}
+}
+
+func TestInterfaceFilter(t *testing.T) {
+	filter := InterfaceFilter{
+		Include: regexp.MustCompile(`^(?:eth.*|ens.*|veth.*)$`),
+		Exclude: regexp.MustCompile(`^(?:veth.*|lxc.*)$`),
+	}
+
+	for name, keep := range map[string]bool{
+		"eth0":     true,
+		"ens5":     true,
+		"veth1234": false,
+		"lxc5678":  false,
+		"cali0123": false,
+	} {
+		if got := filter.keep(name); got != keep {
+			t.Errorf("%s: expected keep %v, got %v", name, keep, got)
+		}
+	}
+
+	if !(InterfaceFilter{}).keep("veth1234") {
+		t.Errorf("an empty filter should keep every interface")
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// FreshnessSLO is an objective for the share of intervals at whose end the
+// last stats of a target were no older than MaxAge, e.g. 99% of 30s
+// intervals with stats at most 60s old.
+type FreshnessSLO struct {
+	Objective float64
+	Interval  time.Duration
+	// MaxAge defaults to twice the interval.
+	MaxAge time.Duration
+}
+
+// Validate checks that the objective is a ratio below 1, which leaves an
+// error budget to burn.
+func (slo FreshnessSLO) Validate() error {
+	if slo.Objective <= 0 || slo.Objective >= 1 {
+		return fmt.Errorf("freshness objective %v is not between 0 and 1", slo.Objective)
+	}
+	if slo.Interval <= 0 {
+		return fmt.Errorf("freshness interval %s is not positive", slo.Interval)
+	}
+	if slo.MaxAge < 0 {
+		return fmt.Errorf("freshness max age %s is negative", slo.MaxAge)
+	}
+	return nil
+}
+
+// freshnessWindows are the windows burn rates are exported for, the short
+// and long windows of the usual multiwindow burn rate alerts.
+var freshnessWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// WithFreshnessSLO tracks the compliance of every target with slo and
+// exports the burn rate of its error budget over freshnessWindows.
+func WithFreshnessSLO(slo FreshnessSLO) Option {
+	return func(s *Scraper) {
+		if slo.MaxAge == 0 {
+			slo.MaxAge = 2 * slo.Interval
+		}
+		s.freshness = newFreshnessTracker(slo, time.Now())
+	}
+}
+
+// freshnessTracker classifies every interval of a target as fresh or stale
+// once it ended, based on when stats were last scraped successfully.
+// Intervals are classified lazily on scrapes; a success only happens during
+// a scrape, so the classification doesn't depend on when that is.
+type freshnessTracker struct {
+	slo FreshnessSLO
+
+	mu          sync.Mutex
+	lastSuccess time.Time
+	next        time.Time
+	// stale holds the last intervals' outcomes, as a ring ending before head.
+	stale  []bool
+	head   int
+	filled int
+	total  map[string]float64
+
+	objective *prometheus.Desc
+	intervals *prometheus.Desc
+	burnRate  *prometheus.Desc
+}
+
+// newFreshnessTracker starts tracking at now. A new target gets MaxAge to
+// produce its first stats.
+func newFreshnessTracker(slo FreshnessSLO, now time.Time) *freshnessTracker {
+	size := int(freshnessWindows[len(freshnessWindows)-1] / slo.Interval)
+	if size < 1 {
+		size = 1
+	}
+
+	return &freshnessTracker{
+		slo:         slo,
+		lastSuccess: now,
+		next:        now.Add(slo.Interval),
+		stale:       make([]bool, size),
+		total:       map[string]float64{"fresh": 0, "stale": 0},
+		objective: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "slo", "freshness_objective"),
+			"Share of intervals that must end with fresh stats",
+			nil,
+			nil),
+		intervals: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "slo", "freshness_intervals_total"),
+			"Intervals that ended with fresh or stale stats",
+			[]string{"result"},
+			nil),
+		burnRate: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "slo", "freshness_burn_rate"),
+			"Rate at which the freshness error budget burns over a window, 1 exhausts it exactly by the end of the SLO period",
+			[]string{"window"},
+			nil),
+	}
+}
+
+// advance classifies the intervals that ended by now.
+func (t *freshnessTracker) advance(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for !t.next.After(now) {
+		stale := t.lastSuccess.Before(t.next.Add(-t.slo.MaxAge))
+		if stale {
+			t.total["stale"]++
+		} else {
+			t.total["fresh"]++
+		}
+
+		t.stale[t.head] = stale
+		t.head = (t.head + 1) % len(t.stale)
+		if t.filled < len(t.stale) {
+			t.filled++
+		}
+		t.next = t.next.Add(t.slo.Interval)
+	}
+}
+
+// succeeded records stats scraped at now.
+func (t *freshnessTracker) succeeded(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastSuccess = now
+}
+
+// burnRates returns the burn rate over each window that has classified
+// intervals. Windows longer than the tracked history use all of it.
+func (t *freshnessTracker) burnRates() map[time.Duration]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rates := map[time.Duration]float64{}
+	for _, window := range freshnessWindows {
+		n := int(window / t.slo.Interval)
+		if n > t.filled {
+			n = t.filled
+		}
+		if n == 0 {
+			continue
+		}
+
+		stale := 0
+		for i := 1; i <= n; i++ {
+			if t.stale[(t.head-i+len(t.stale))%len(t.stale)] {
+				stale++
+			}
+		}
+		rates[window] = float64(stale) / float64(n) / (1 - t.slo.Objective)
+	}
+	return rates
+}
+
+func (t *freshnessTracker) describe(ch chan<- *prometheus.Desc) {
+	ch <- t.objective
+	ch <- t.intervals
+	ch <- t.burnRate
+}
+
+func (t *freshnessTracker) collect(ch chan<- prometheus.Metric, now time.Time) {
+	t.advance(now)
+
+	ch <- prometheus.MustNewConstMetric(t.objective, prometheus.GaugeValue, t.slo.Objective)
+
+	t.mu.Lock()
+	for _, result := range []string{"fresh", "stale"} {
+		ch <- prometheus.MustNewConstMetric(t.intervals, prometheus.CounterValue, t.total[result], result)
+	}
+	t.mu.Unlock()
+
+	rates := t.burnRates()
+	for _, window := range freshnessWindows {
+		if rate, ok := rates[window]; ok {
+			ch <- prometheus.MustNewConstMetric(t.burnRate, prometheus.GaugeValue, rate, model.Duration(window).String())
+		}
+	}
+}
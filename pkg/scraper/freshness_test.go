@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreshnessTracker(t *testing.T) {
+	start := time.Now()
+	interval := 30 * time.Second
+	tracker := newFreshnessTracker(FreshnessSLO{Objective: 0.9, Interval: interval, MaxAge: 2 * interval}, start)
+
+	// Scrapes succeed for 5 minutes, then fail for another 5.
+	for i := 1; i <= 20; i++ {
+		now := start.Add(time.Duration(i) * interval)
+		tracker.advance(now)
+		if i <= 10 {
+			tracker.succeeded(now)
+		}
+	}
+
+	// The first failing intervals are still fresh, the last success is
+	// at most 60s old at their end.
+	if fresh, stale := tracker.total["fresh"], tracker.total["stale"]; fresh != 12 || stale != 8 {
+		t.Errorf("expected 12 fresh and 8 stale intervals, got %v and %v", fresh, stale)
+	}
+
+	rates := tracker.burnRates()
+	// 8 of the last 10 intervals are stale: 0.8 / 0.1.
+	if rate := rates[5*time.Minute]; rate < 7.99 || rate > 8.01 {
+		t.Errorf("expected a 5m burn rate of 8, got %v", rate)
+	}
+	// The longer windows cover the 20 intervals tracked so far.
+	if rate := rates[time.Hour]; rate < 3.99 || rate > 4.01 {
+		t.Errorf("expected a 1h burn rate of 4, got %v", rate)
+	}
+}
+
+func TestFreshnessSLOValidate(t *testing.T) {
+	for _, slo := range []FreshnessSLO{
+		{Objective: 1, Interval: time.Second},
+		{Objective: 0.99},
+		{Objective: 0.99, Interval: time.Second, MaxAge: -time.Second},
+	} {
+		if err := slo.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", slo)
+		}
+	}
+
+	if err := (FreshnessSLO{Objective: 0.99, Interval: time.Second}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
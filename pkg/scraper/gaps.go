@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons of gaps between scrapes.
+const (
+	// gapPause is a long time without scrapes, e.g. while the exporter was
+	// frozen.
+	gapPause = "pause"
+	// gapClockJump is the wall clock moving away from the monotonic clock,
+	// e.g. after a suspend or a clock step.
+	gapClockJump = "clock_jump"
+)
+
+// processStart anchors the monotonic clock readings of the gap detector.
+var processStart = time.Now()
+
+// WithGapDetection detects gaps longer than maxGap between scrapes and
+// restarts rates derived from consecutive summaries after them, rather than
+// exporting rates averaged over the gap. A maxGap of 0 disables detection.
+func WithGapDetection(maxGap time.Duration) Option {
+	return func(s *Scraper) {
+		if maxGap > 0 {
+			s.gaps = newGapDetector(maxGap)
+		}
+	}
+}
+
+// gapDetector compares consecutive scrapes on the wall and monotonic clocks.
+type gapDetector struct {
+	maxGap time.Duration
+
+	mu         sync.Mutex
+	lastWall   time.Time
+	lastUptime time.Duration
+	seen       bool
+	reset      bool
+	total      map[string]float64
+
+	desc *prometheus.Desc
+}
+
+func newGapDetector(maxGap time.Duration) *gapDetector {
+	return &gapDetector{
+		maxGap: maxGap,
+		total:  map[string]float64{gapPause: 0, gapClockJump: 0},
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "gaps_total"),
+			"Gaps between scrapes after which derived rates were restarted, by reason",
+			[]string{"reason"},
+			nil),
+	}
+}
+
+// observe records a scrape at wall time wall, uptime into the process on
+// the monotonic clock, and returns the reason of the gap before it, if any.
+func (d *gapDetector) observe(wall time.Time, uptime time.Duration) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	defer func() {
+		d.lastWall, d.lastUptime, d.seen = wall, uptime, true
+	}()
+	if !d.seen {
+		return ""
+	}
+
+	elapsed := uptime - d.lastUptime
+	skew := wall.Sub(d.lastWall) - elapsed
+	if skew < 0 {
+		skew = -skew
+	}
+
+	reason := ""
+	switch {
+	case skew > d.maxGap:
+		reason = gapClockJump
+	case elapsed > d.maxGap:
+		reason = gapPause
+	default:
+		return ""
+	}
+
+	d.total[reason]++
+	d.reset = true
+	return reason
+}
+
+// takeReset reports whether derived rates must restart, once per gap.
+func (d *gapDetector) takeReset() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	reset := d.reset
+	d.reset = false
+	return reset
+}
+
+func (d *gapDetector) collect(ch chan<- prometheus.Metric) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, reason := range []string{gapPause, gapClockJump} {
+		ch <- prometheus.MustNewConstMetric(d.desc, prometheus.CounterValue, d.total[reason], reason)
+	}
+}
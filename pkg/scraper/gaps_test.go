@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGapDetector(t *testing.T) {
+	d := newGapDetector(5 * time.Minute)
+	wall := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	uptime := time.Hour
+
+	for _, tc := range []struct {
+		name            string
+		wall, monotonic time.Duration
+		reason          string
+	}{
+		{name: "first scrape"},
+		{name: "regular scrape", wall: 30 * time.Second, monotonic: 30 * time.Second},
+		{name: "frozen", wall: 20 * time.Minute, monotonic: 20 * time.Minute, reason: gapPause},
+		{name: "regular scrape", wall: 30 * time.Second, monotonic: 30 * time.Second},
+		{name: "suspended", wall: 2 * time.Hour, monotonic: 30 * time.Second, reason: gapClockJump},
+		{name: "clock stepped back", wall: -time.Hour, monotonic: 30 * time.Second, reason: gapClockJump},
+		{name: "small drift", wall: 40 * time.Second, monotonic: 30 * time.Second},
+	} {
+		wall, uptime = wall.Add(tc.wall), uptime+tc.monotonic
+		if reason := d.observe(wall, uptime); reason != tc.reason {
+			t.Errorf("%s: expected gap %q, got %q", tc.name, tc.reason, reason)
+		}
+	}
+
+	if d.total[gapPause] != 1 || d.total[gapClockJump] != 2 {
+		t.Errorf("expected 1 pause and 2 clock jumps, got %v", d.total)
+	}
+	if !d.takeReset() || d.takeReset() {
+		t.Error("expected derived rates to restart once")
+	}
+}
+
+func TestRateTrackerReset(t *testing.T) {
+	tracker := newRateTracker()
+	key := containerKey{namespace: "default", pod: "web", container: "app"}
+	start := time.Now()
+
+	tracker.update(map[containerKey]cumulativeValue{key: {value: 100, time: start}})
+	tracker.reset()
+	rates := tracker.update(map[containerKey]cumulativeValue{key: {value: 10100, time: start.Add(time.Hour)}})
+	if _, ok := rates[key]; ok {
+		t.Errorf("expected no rate right after a reset, got %v", rates[key])
+	}
+
+	rates = tracker.update(map[containerKey]cumulativeValue{key: {value: 10200, time: start.Add(time.Hour + 10*time.Second)}})
+	if rates[key] != 10 {
+		t.Errorf("expected 10 bytes per second, got %v", rates[key])
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Gatherer gathers a Scraper on its own, so the scrapes of GatherContext
+// can be bounded by a context, which prometheus.Collector doesn't carry.
+type Gatherer struct {
+	scraper  *Scraper
+	registry *prometheus.Registry
+}
+
+// NewGatherer returns a gatherer of s.
+func NewGatherer(s *Scraper) (*Gatherer, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(s); err != nil {
+		return nil, err
+	}
+	return &Gatherer{scraper: s, registry: registry}, nil
+}
+
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	return g.registry.Gather()
+}
+
+// GatherContext gathers with the kubelet request bounded by ctx.
+func (g *Gatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	return g.scraper.GatherContext(ctx)
+}
+
+// GatherContext gathers s with the kubelet request bounded by ctx, in a
+// registry of its own checked like the one s was registered with. It lets
+// registries of one Scraper, like those of targets, bound their scrapes.
+func (s *Scraper) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(boundCollector{scraper: s, ctx: ctx}); err != nil {
+		return nil, err
+	}
+	return registry.Gather()
+}
+
+// boundCollector scrapes with a context.
+type boundCollector struct {
+	scraper *Scraper
+	ctx     context.Context
+}
+
+func (c boundCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.scraper.Describe(ch)
+}
+
+func (c boundCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = c.scraper.ScrapeContext(c.ctx, ch)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector groups, which can be disabled as a whole.
+const (
+	GroupNode             = "node"
+	GroupSystemContainers = "system-containers"
+	GroupPods             = "pods"
+	GroupContainers       = "containers"
+	GroupVolumes          = "volumes"
+	GroupNetwork          = "network"
+	GroupAccelerators     = "accelerators"
+)
+
+// Groups lists every collector group.
+var Groups = []string{
+	GroupNode,
+	GroupSystemContainers,
+	GroupPods,
+	GroupContainers,
+	GroupVolumes,
+	GroupNetwork,
+	GroupAccelerators,
+}
+
+// collectorGroup returns the collector group of a metric group (a metric's
+// subsystem), or an empty string for metrics that can't be disabled.
+func collectorGroup(subsystem string) string {
+	switch {
+	case subsystem == "expressions":
+		return ""
+	case strings.HasSuffix(subsystem, "_accelerator"):
+		return GroupAccelerators
+	case strings.HasSuffix(subsystem, "_interface"):
+		return GroupNetwork
+	case subsystem == "pod_volume":
+		return GroupVolumes
+	case strings.HasPrefix(subsystem, "node_system_container"):
+		return GroupSystemContainers
+	case strings.HasPrefix(subsystem, "container"):
+		return GroupContainers
+	case strings.HasPrefix(subsystem, "pod"), subsystem == "namespace":
+		return GroupPods
+	default:
+		return GroupNode
+	}
+}
+
+// WithDisabledGroups stops exporting the metrics of the given collector
+// groups, e.g. per-container metrics that dominate cardinality in large
+// clusters.
+func WithDisabledGroups(groups []string) Option {
+	return func(s *Scraper) {
+		for _, group := range groups {
+			if s.disabledGroups == nil {
+				s.disabledGroups = map[string]struct{}{}
+			}
+			s.disabledGroups[group] = struct{}{}
+		}
+	}
+}
+
+// disableGroups records the descriptors of the disabled groups. It runs
+// once all options have registered their descriptors.
+func (s *Scraper) disableGroups() {
+	if len(s.disabledGroups) == 0 {
+		return
+	}
+
+	s.disabledDescs = map[*prometheus.Desc]struct{}{}
+	for i, info := range s.descs.infos {
+		if _, ok := s.disabledGroups[collectorGroup(info.Group)]; ok {
+			s.disabledDescs[s.descs.descs[i]] = struct{}{}
+		}
+	}
+}
+
+func (s *Scraper) groupEnabled(info MetricInfo) bool {
+	_, disabled := s.disabledGroups[collectorGroup(info.Group)]
+	return !disabled
+}
+
+// filterDisabled returns a channel forwarding to ch every metric that isn't
+// in a disabled group, and a function that must be called once nothing more
+// is sent to it. Without disabled groups, ch is returned as is.
+func (s *Scraper) filterDisabled(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	if len(s.disabledDescs) == 0 {
+		return ch, func() {}
+	}
+
+	filtered := make(chan prometheus.Metric, 64)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range filtered {
+			if _, disabled := s.disabledDescs[metric.Desc()]; !disabled {
+				ch <- metric
+			}
+		}
+	}()
+
+	return filtered, func() {
+		close(filtered)
+		<-done
+	}
+}
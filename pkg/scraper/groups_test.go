@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestCollectorGroup(t *testing.T) {
+	for subsystem, want := range map[string]string{
+		"node_cpu":                          GroupNode,
+		"node_runtime_image_fs":             GroupNode,
+		"node_interface":                    GroupNetwork,
+		"pod_interface":                     GroupNetwork,
+		"node_system_container_memory":      GroupSystemContainers,
+		"node_system_container_accelerator": GroupAccelerators,
+		"container_fs":                      GroupContainers,
+		"container_accelerator":             GroupAccelerators,
+		"pod_memory":                        GroupPods,
+		"pod_volume":                        GroupVolumes,
+		"namespace":                         GroupPods,
+		"namespace_cpu":                     GroupNode,
+		"expressions":                       "",
+	} {
+		if got := collectorGroup(subsystem); got != want {
+			t.Errorf("%s: expected %q, got %q", subsystem, want, got)
+		}
+	}
+}
+
+func TestDisabledGroups(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", time.Second, WithDisabledGroups([]string{GroupContainers, GroupVolumes}))
+
+	ch := make(chan prometheus.Metric, 1000)
+	filtered, flush := scraper.filterDisabled(ch)
+	scraper.emit(filtered, syntheticSummary(time.Now()))
+	flush()
+	close(ch)
+
+	fqName := regexp.MustCompile(`fqName: "([^"]+)"`)
+	emitted := map[string]bool{}
+	for metric := range ch {
+		emitted[fqName.FindStringSubmatch(metric.Desc().String())[1]] = true
+	}
+
+	for name := range emitted {
+		container := strings.HasPrefix(name, "kubelet_summary_container_") && !strings.Contains(name, "_accelerator_")
+		if container || strings.HasPrefix(name, "kubelet_summary_pod_volume_") {
+			t.Errorf("%s is in a disabled group", name)
+		}
+	}
+	for _, name := range []string{
+		"kubelet_summary_pod_memory_working_set_bytes",
+		"kubelet_summary_node_cpu_usage_nano_cores",
+		"kubelet_summary_container_accelerator_duty_cycle",
+	} {
+		if !emitted[name] {
+			t.Errorf("expected %s to be emitted", name)
+		}
+	}
+
+	for _, info := range scraper.Metrics() {
+		if g := collectorGroup(info.Group); g == GroupContainers || g == GroupVolumes {
+			t.Errorf("%s is in a disabled group but listed in Metrics", info.Name)
+		}
+	}
+}
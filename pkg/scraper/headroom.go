@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// NodeSource provides Node objects from the API server.
+type NodeSource interface {
+	Get(name string) (*v1.Node, bool)
+}
+
+// WithNodes enables metrics that combine the summary with the Node object,
+// such as allocatable headroom. The nodepool label is taken from the first
+// of nodepoolLabels present on the node.
+func WithNodes(nodes NodeSource, nodepoolLabels []string) Option {
+	return func(s *Scraper) {
+		s.nodes = nodes
+		s.nodepoolLabels = nodepoolLabels
+	}
+}
+
+// nodepool returns the node's pool according to the configured labels.
+func (s *Scraper) nodepool(node *v1.Node) string {
+	for _, label := range s.nodepoolLabels {
+		if pool, ok := node.Labels[label]; ok {
+			return pool
+		}
+	}
+	return ""
+}
+
+// emitHeadroom exports how much of the node's allocatable resources is not
+// used by pods. Usage is taken from the "pods" system container (the cgroup
+// holding all pods), since allocatable already excludes system reservations.
+func (s *Scraper) emitHeadroom(ch chan<- prometheus.Metric, summary *statsapi.Summary) {
+	if s.nodes == nil {
+		return
+	}
+
+	nodeName := summary.Node.NodeName
+	node, ok := s.nodes.Get(nodeName)
+	if !ok {
+		return
+	}
+	nodepool := s.nodepool(node)
+
+	cpu, memory := summary.Node.CPU, summary.Node.Memory
+	for _, container := range summary.Node.SystemContainers {
+		if container.Name == statsapi.SystemContainerPods {
+			cpu, memory = container.CPU, container.Memory
+			break
+		}
+	}
+
+	if allocatable, ok := node.Status.Allocatable[v1.ResourceCPU]; ok && cpu != nil && cpu.UsageNanoCores != nil {
+		headroom := float64(allocatable.MilliValue())*1e6 - float64(*cpu.UsageNanoCores)
+		ch <- prometheus.MustNewConstMetric(s.nodeHeadroomCPUNanoCores, prometheus.GaugeValue, headroom, nodeName, nodepool)
+	}
+
+	if allocatable, ok := node.Status.Allocatable[v1.ResourceMemory]; ok && memory != nil && memory.WorkingSetBytes != nil {
+		headroom := float64(allocatable.Value()) - float64(*memory.WorkingSetBytes)
+		ch <- prometheus.MustNewConstMetric(s.nodeHeadroomMemoryBytes, prometheus.GaugeValue, headroom, nodeName, nodepool)
+	}
+}
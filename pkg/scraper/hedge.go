@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// WithHedging sends a second, hedged request when kubelet hasn't answered
+// within delay (or right away when the first one failed) and uses whichever
+// succeeds first. A delay of 0 disables hedging.
+func WithHedging(delay time.Duration) Option {
+	return func(s *Scraper) {
+		s.hedgeDelay = delay
+	}
+}
+
+type hedgeResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+}
+
+// cancelOnClose releases the winning request's context once its body is
+// consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// do sends req, hedging it if enabled.
+func (s *Scraper) do(req *http.Request) (*http.Response, error) {
+	client := s.httpClient
+	if s.hedgeDelay <= 0 {
+		return client.Do(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	attempt := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		n := len(cancels)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			resp, err := client.Do(req.Clone(ctx))
+			results <- hedgeResult{attempt: n, resp: resp, err: err}
+		}()
+	}
+
+	attempt()
+	inflight := 1
+	hedge := func() {
+		inflight++
+		atomic.AddUint64(&s.hedgedRequests, 1)
+		attempt()
+	}
+
+	timer := time.NewTimer(s.hedgeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) == 1 {
+				hedge()
+			}
+		case result := <-results:
+			inflight--
+			if result.err == nil {
+				// Abort the request that lost the race.
+				for i, cancel := range cancels {
+					if i != result.attempt {
+						cancel()
+					}
+				}
+				go discard(results, inflight)
+
+				result.resp.Body = cancelOnClose{ReadCloser: result.resp.Body, cancel: cancels[result.attempt]}
+				return result.resp, nil
+			}
+
+			cancels[result.attempt]()
+			if len(cancels) == 1 {
+				hedge()
+			} else if inflight == 0 {
+				return nil, result.err
+			}
+		}
+	}
+}
+
+// discard releases the responses of the requests that lost the race.
+func discard(results <-chan hedgeResult, inflight int) {
+	for i := 0; i < inflight; i++ {
+		if result := <-results; result.err == nil {
+			result.resp.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestHedging(t *testing.T) {
+	var calls int32
+	stall := make(chan struct{})
+	defer close(stall)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-stall:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		_, _ = w.Write([]byte("hedged"))
+	}))
+	defer server.Close()
+
+	scraper := NewScraper(logging.Nop(), "", "", 5*time.Second, WithHedging(10*time.Millisecond))
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := scraper.do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "hedged" {
+		t.Errorf("expected the hedged response, got %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the hedged request to win quickly, took %s", elapsed)
+	}
+	if scraper.hedgedRequests != 1 {
+		t.Errorf("expected 1 hedged request, got %d", scraper.hedgedRequests)
+	}
+}
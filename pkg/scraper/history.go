@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// RecentUsagePath is where the recent container usage is served.
+const RecentUsagePath = "/api/v1/recent-usage"
+
+// usageHistory keeps the container usage seen by recent scrapes, bounded by
+// a time window and a number of samples per container.
+type usageHistory struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxSamples int
+	containers map[containerKey][]usageSample
+}
+
+type usageSample struct {
+	time            time.Time
+	cpuNanoCores    *uint64
+	workingSetBytes *uint64
+}
+
+// WithUsageHistory keeps the CPU and working set memory of every container
+// over window (at most maxSamples per container) to serve usage percentiles for
+// right-sizing at RecentUsagePath.
+func WithUsageHistory(window time.Duration, maxSamples int) Option {
+	return func(s *Scraper) {
+		if window <= 0 || maxSamples <= 0 {
+			return
+		}
+
+		s.history = &usageHistory{
+			window:     window,
+			maxSamples: maxSamples,
+			containers: map[containerKey][]usageSample{},
+		}
+	}
+}
+
+// record adds the usage of a container at the time kubelet took its stats,
+// or now when they have none. Stats no newer than the container's last
+// sample, served again from kubelet's cache or seen by another scrape of
+// the same summary, are skipped so they don't weigh twice.
+func (h *usageHistory) record(key containerKey, cpu *statsapi.CPUStats, memory *statsapi.MemoryStats, now time.Time) {
+	if h == nil {
+		return
+	}
+
+	var sample usageSample
+	if cpu != nil {
+		sample.time = cpu.Time.Time
+		sample.cpuNanoCores = cpu.UsageNanoCores
+	}
+	if memory != nil {
+		if sample.time.IsZero() {
+			sample.time = memory.Time.Time
+		}
+		sample.workingSetBytes = memory.WorkingSetBytes
+	}
+	if sample.time.IsZero() {
+		sample.time = now
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := h.containers[key]
+	if len(samples) > 0 && !sample.time.After(samples[len(samples)-1].time) {
+		return
+	}
+	samples = append(samples, sample)
+	if len(samples) > h.maxSamples {
+		samples = samples[len(samples)-h.maxSamples:]
+	}
+	h.containers[key] = samples
+}
+
+// prune forgets samples older than the window, and with them containers
+// that are gone.
+func (h *usageHistory) prune(now time.Time) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-h.window)
+	for key, samples := range h.containers {
+		i := sort.Search(len(samples), func(i int) bool {
+			return samples[i].time.After(cutoff)
+		})
+		if i == len(samples) {
+			delete(h.containers, key)
+			continue
+		}
+		h.containers[key] = samples[i:]
+	}
+}
+
+// Percentiles summarizes the samples of one resource.
+type Percentiles struct {
+	P50 uint64 `json:"p50"`
+	P90 uint64 `json:"p90"`
+	P95 uint64 `json:"p95"`
+	P99 uint64 `json:"p99"`
+	Max uint64 `json:"max"`
+}
+
+// ContainerUsage is the recent usage of a container.
+type ContainerUsage struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// Samples is the number of stats the usage was seen in.
+	Samples int       `json:"samples"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	// CPUNanoCores and MemoryWorkingSetBytes are nil when kubelet didn't
+	// report them.
+	CPUNanoCores          *Percentiles `json:"cpuNanoCores,omitempty"`
+	MemoryWorkingSetBytes *Percentiles `json:"memoryWorkingSetBytes,omitempty"`
+}
+
+// RecentUsage returns usage percentiles per container, ordered by
+// namespace, pod and container.
+func (s *Scraper) RecentUsage() []ContainerUsage {
+	h := s.history
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	usage := make([]ContainerUsage, 0, len(h.containers))
+	for _, key := range sortedContainerKeys(h.containers) {
+		samples := h.containers[key]
+
+		var cpu, memory []uint64
+		for _, sample := range samples {
+			if sample.cpuNanoCores != nil {
+				cpu = append(cpu, *sample.cpuNanoCores)
+			}
+			if sample.workingSetBytes != nil {
+				memory = append(memory, *sample.workingSetBytes)
+			}
+		}
+
+		usage = append(usage, ContainerUsage{
+			Namespace:             key.namespace,
+			Pod:                   key.pod,
+			Container:             key.container,
+			Samples:               len(samples),
+			From:                  samples[0].time,
+			To:                    samples[len(samples)-1].time,
+			CPUNanoCores:          percentiles(cpu),
+			MemoryWorkingSetBytes: percentiles(memory),
+		})
+	}
+
+	return usage
+}
+
+// RecentUsageHandler serves RecentUsage as JSON.
+func (s *Scraper) RecentUsageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.RecentUsage())
+	})
+}
+
+// percentiles computes nearest-rank percentiles of values.
+func percentiles(values []uint64) *Percentiles {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	rank := func(p float64) uint64 {
+		i := int(math.Ceil(p*float64(len(values)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(values) {
+			i = len(values) - 1
+		}
+		return values[i]
+	}
+
+	return &Percentiles{
+		P50: rank(0.5),
+		P90: rank(0.9),
+		P95: rank(0.95),
+		P99: rank(0.99),
+		Max: values[len(values)-1],
+	}
+}
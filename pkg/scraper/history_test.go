@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestUsageHistory(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", time.Second, WithUsageHistory(time.Hour, 100))
+	h := scraper.history
+
+	start := time.Unix(0, 0)
+	app := containerKey{namespace: "shop", pod: "web", container: "app"}
+	gone := containerKey{namespace: "shop", pod: "old", container: "app"}
+
+	h.record(gone, &statsapi.CPUStats{UsageNanoCores: new(uint64)}, nil, start)
+	for i := uint64(1); i <= 100; i++ {
+		cpu, memory := i, 1000*i
+		h.record(app,
+			&statsapi.CPUStats{UsageNanoCores: &cpu},
+			&statsapi.MemoryStats{WorkingSetBytes: &memory},
+			start.Add(time.Duration(i)*time.Minute))
+	}
+	h.prune(start.Add(100 * time.Minute))
+
+	usage := scraper.RecentUsage()
+	if len(usage) != 1 {
+		t.Fatalf("expected only the recent container, got %+v", usage)
+	}
+
+	got := usage[0]
+	if got.Container != "app" || got.Pod != "web" {
+		t.Errorf("unexpected container %+v", got)
+	}
+	if got.Samples != 60 {
+		t.Errorf("expected the last hour of samples, got %d", got.Samples)
+	}
+	if want := (Percentiles{P50: 70, P90: 94, P95: 97, P99: 100, Max: 100}); *got.CPUNanoCores != want {
+		t.Errorf("expected cpu %+v, got %+v", want, *got.CPUNanoCores)
+	}
+	if got.MemoryWorkingSetBytes.Max != 100000 {
+		t.Errorf("expected memory max 100000, got %d", got.MemoryWorkingSetBytes.Max)
+	}
+}
+
+func TestUsageHistorySameStats(t *testing.T) {
+	at := metav1.NewTime(time.Now().Add(-10 * time.Second))
+	cpu, memory := uint64(5e8), uint64(1<<20)
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{{
+			PodRef: statsapi.PodReference{Namespace: "shop", Name: "web"},
+			Containers: []statsapi.ContainerStats{{
+				Name:   "app",
+				CPU:    &statsapi.CPUStats{Time: at, UsageNanoCores: &cpu},
+				Memory: &statsapi.MemoryStats{Time: at, WorkingSetBytes: &memory},
+			}},
+		}},
+	}
+	provider := summaryFunc(func(context.Context) (*statsapi.Summary, error) { return summary, nil })
+	scraper := NewScraper(logging.Nop(), "", "", time.Second, WithSummaryProvider(provider), WithUsageHistory(time.Hour, 100))
+
+	// Kubelet serving cached stats, or two Prometheus servers scraping the
+	// same summary.
+	testutil.CollectAndCount(scraper)
+	testutil.CollectAndCount(scraper)
+
+	usage := scraper.RecentUsage()
+	if len(usage) != 1 {
+		t.Fatalf("expected one container, got %+v", usage)
+	}
+	if usage[0].Samples != 1 || !usage[0].From.Equal(at.Time) {
+		t.Errorf("expected one sample at the stats' time, got %d from %v", usage[0].Samples, usage[0].From)
+	}
+}
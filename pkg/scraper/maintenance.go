@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// maintenanceTaints map taints set by node lifecycle tooling while draining
+// a node to the maintenance reason exported for them.
+var maintenanceTaints = map[string]string{
+	"ToBeDeletedByClusterAutoscaler": "cluster-autoscaler",
+	"karpenter.sh/disrupted":         "karpenter",
+	"karpenter.sh/disruption":        "karpenter",
+}
+
+// WithMaintenanceSuppression stops exporting per-pod series while the node
+// is cordoned or being drained, so pod level alerts stay quiet during routine
+// node rotations. Node level series are still exported.
+func WithMaintenanceSuppression() Option {
+	return func(s *Scraper) {
+		s.suppressInMaintenance = true
+	}
+}
+
+// maintenanceReason returns why the node is in maintenance, or an empty
+// string if it isn't.
+func maintenanceReason(node *v1.Node) string {
+	for _, taint := range node.Spec.Taints {
+		if reason, ok := maintenanceTaints[taint.Key]; ok {
+			return reason
+		}
+	}
+
+	if node.Spec.Unschedulable {
+		return "cordoned"
+	}
+
+	return ""
+}
+
+// emitMaintenance exports whether the node is in maintenance and reports
+// whether per-pod series are to be suppressed.
+func (s *Scraper) emitMaintenance(ch chan<- prometheus.Metric, nodeName string) bool {
+	if s.nodes == nil {
+		return false
+	}
+
+	node, ok := s.nodes.Get(nodeName)
+	if !ok {
+		return false
+	}
+
+	reason := maintenanceReason(node)
+	if reason == "" {
+		return false
+	}
+
+	ch <- prometheus.MustNewConstMetric(s.nodeMaintenance, prometheus.GaugeValue, 1, nodeName, s.nodepool(node), reason)
+
+	return s.suppressInMaintenance
+}
@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMaintenanceReason(t *testing.T) {
+	for want, spec := range map[string]v1.NodeSpec{
+		"":                   {},
+		"cordoned":           {Unschedulable: true},
+		"karpenter":          {Unschedulable: true, Taints: []v1.Taint{{Key: "karpenter.sh/disrupted", Effect: v1.TaintEffectNoSchedule}}},
+		"cluster-autoscaler": {Taints: []v1.Taint{{Key: "ToBeDeletedByClusterAutoscaler", Effect: v1.TaintEffectNoSchedule}}},
+	} {
+		if got := maintenanceReason(&v1.Node{Spec: spec}); got != want {
+			t.Errorf("expected %q for %+v, got %q", want, spec, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// memoryTracker measures how much a scrape allocates and the highest heap
+// size seen at the end of a scrape, which grow with the number of pods on the
+// node long before the exporter hits its memory limit.
+type memoryTracker struct {
+	mu        sync.Mutex
+	allocated uint64
+	highWater uint64
+}
+
+// begin returns the allocation counter at the start of a scrape.
+func (m *memoryTracker) begin() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return stats.TotalAlloc
+}
+
+// end records the allocations since begin and the current heap size. With
+// concurrent scrapes the allocations of both are counted.
+func (m *memoryTracker) end(begin uint64) (allocated, highWater uint64) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.allocated = stats.TotalAlloc - begin
+	if stats.HeapAlloc > m.highWater {
+		m.highWater = stats.HeapAlloc
+	}
+
+	return m.allocated, m.highWater
+}
+
+// emitMemory exports the allocations of the scrape that started at begin.
+func (s *Scraper) emitMemory(ch chan<- prometheus.Metric, begin uint64) {
+	allocated, highWater := s.memory.end(begin)
+
+	ch <- prometheus.MustNewConstMetric(s.scrapeAllocatedBytes, prometheus.GaugeValue, float64(allocated))
+	ch <- prometheus.MustNewConstMetric(s.scrapeHeapHighWaterBytes, prometheus.GaugeValue, float64(highWater))
+}
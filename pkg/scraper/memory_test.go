@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"runtime"
+	"testing"
+)
+
+var sink []byte
+
+func TestMemoryTracker(t *testing.T) {
+	m := &memoryTracker{}
+
+	begin := m.begin()
+	sink = make([]byte, 1<<20)
+	allocated, highWater := m.end(begin)
+
+	if allocated < 1<<20 {
+		t.Errorf("expected at least 1MiB allocated, got %d", allocated)
+	}
+	if highWater == 0 {
+		t.Fatalf("expected a heap high-water mark")
+	}
+
+	sink = nil
+	runtime.GC()
+	if _, after := m.end(m.begin()); after < highWater {
+		t.Errorf("high-water mark dropped from %d to %d", highWater, after)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// WithNamespaceAggregates exports the CPU usage and working set of the
+// node's pods summed by namespace, for clusters that can't afford per-pod
+// series. The aggregates belong to the node collector group, so they are
+// still exported with the pod and container groups disabled.
+func WithNamespaceAggregates() Option {
+	return func(s *Scraper) {
+		s.namespaceAggregates = true
+	}
+}
+
+// namespaceUsage is the usage of the pods of a namespace, nil until a pod
+// reports it.
+type namespaceUsage struct {
+	cpuNanoCores    *uint64
+	workingSetBytes *uint64
+}
+
+func addUsage(sum **uint64, value *uint64) {
+	if value == nil {
+		return
+	}
+	if *sum == nil {
+		*sum = new(uint64)
+	}
+	**sum += *value
+}
+
+// emitNamespaceAggregates exports the usage of pods summed by namespace.
+// Every pod is counted, including those left out by namespace sampling.
+func (s *Scraper) emitNamespaceAggregates(ch chan<- prometheus.Metric, nodeName string, pods []*statsapi.PodStats) {
+	if !s.namespaceAggregates {
+		return
+	}
+
+	namespaces := map[string]*namespaceUsage{}
+	for _, pod := range pods {
+		usage, ok := namespaces[pod.PodRef.Namespace]
+		if !ok {
+			usage = &namespaceUsage{}
+			namespaces[pod.PodRef.Namespace] = usage
+		}
+		if pod.CPU != nil {
+			addUsage(&usage.cpuNanoCores, pod.CPU.UsageNanoCores)
+		}
+		if pod.Memory != nil {
+			addUsage(&usage.workingSetBytes, pod.Memory.WorkingSetBytes)
+		}
+	}
+
+	for _, namespace := range sortedKeys(namespaces) {
+		usage := namespaces[namespace]
+		s.pushMetrics(ch, s.namespaceCPUUsageNanoCores, usage.cpuNanoCores, nodeName, namespace)
+		s.pushMetrics(ch, s.namespaceMemoryWorkingSetBytes, usage.workingSetBytes, nodeName, namespace)
+	}
+}
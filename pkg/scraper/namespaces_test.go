@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestNamespaceAggregates(t *testing.T) {
+	value := func(v uint64) *uint64 { return &v }
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithNamespaceAggregates(), WithDisabledGroups([]string{GroupPods, GroupContainers}))
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{
+			{
+				PodRef: statsapi.PodReference{Namespace: "default", Name: "web"},
+				CPU:    &statsapi.CPUStats{UsageNanoCores: value(100)},
+				Memory: &statsapi.MemoryStats{WorkingSetBytes: value(1000)},
+			},
+			{
+				PodRef: statsapi.PodReference{Namespace: "default", Name: "worker"},
+				CPU:    &statsapi.CPUStats{UsageNanoCores: value(50)},
+			},
+			{
+				PodRef: statsapi.PodReference{Namespace: "kube-system", Name: "proxy"},
+			},
+		},
+	}
+	collector := collectorFunc(func(ch chan<- prometheus.Metric) {
+		filtered, flush := s.filterDisabled(ch)
+		s.emit(filtered, summary)
+		flush()
+	})
+
+	expected := `
+# HELP kubelet_summary_namespace_cpu_usage_nano_cores CPU usage in nanocores of the namespace's pods on the node
+# TYPE kubelet_summary_namespace_cpu_usage_nano_cores gauge
+kubelet_summary_namespace_cpu_usage_nano_cores{namespace="default",node="node"} 150
+# HELP kubelet_summary_namespace_memory_working_set_bytes Working set bytes in memory of the namespace's pods on the node
+# TYPE kubelet_summary_namespace_memory_working_set_bytes gauge
+kubelet_summary_namespace_memory_working_set_bytes{namespace="default",node="node"} 1000
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubelet_summary_namespace_cpu_usage_nano_cores", "kubelet_summary_namespace_memory_working_set_bytes", "kubelet_summary_pod_cpu_usage_nano_cores"); err != nil {
+		t.Error(err)
+	}
+}
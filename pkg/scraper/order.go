@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sort"
+
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// sortSummary orders every list of the summary by name so that metrics are
+// emitted in the same order for the same summary, whatever order kubelet
+// reported it in.
+func sortSummary(summary *statsapi.Summary) {
+	sortContainers(summary.Node.SystemContainers)
+	if summary.Node.Network != nil {
+		sortInterfaces(summary.Node.Network.Interfaces)
+	}
+
+	sort.SliceStable(summary.Pods, func(i, j int) bool {
+		a, b := summary.Pods[i].PodRef, summary.Pods[j].PodRef
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+
+		sortContainers(pod.Containers)
+		sort.SliceStable(pod.VolumeStats, func(i, j int) bool {
+			return pod.VolumeStats[i].Name < pod.VolumeStats[j].Name
+		})
+		if pod.Network != nil {
+			sortInterfaces(pod.Network.Interfaces)
+		}
+	}
+}
+
+func sortContainers(containers []statsapi.ContainerStats) {
+	sort.SliceStable(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+
+	for i := range containers {
+		accelerators := containers[i].Accelerators
+		sort.SliceStable(accelerators, func(i, j int) bool {
+			return accelerators[i].ID < accelerators[j].ID
+		})
+	}
+}
+
+func sortInterfaces(interfaces []statsapi.InterfaceStats) {
+	sort.SliceStable(interfaces, func(i, j int) bool {
+		return interfaces[i].Name < interfaces[j].Name
+	})
+}
+
+func sortedContainerKeys[V any](m map[containerKey]V) []containerKey {
+	keys := make([]containerKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.pod != b.pod {
+			return a.pod < b.pod
+		}
+		return a.container < b.container
+	})
+
+	return keys
+}
+
+func sortedVolumeKeys[V any](m map[volumeKey]V) []volumeKey {
+	keys := make([]volumeKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.pod != b.pod {
+			return a.pod < b.pod
+		}
+		return a.volume < b.volume
+	})
+
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
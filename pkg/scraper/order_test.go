@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestEmitOrderIsDeterministic(t *testing.T) {
+	pod := func(name string, containers ...string) statsapi.PodStats {
+		v := uint64(1)
+		stats := statsapi.PodStats{PodRef: statsapi.PodReference{Namespace: "default", Name: name}}
+		for _, container := range containers {
+			stats.Containers = append(stats.Containers, statsapi.ContainerStats{
+				Name: container,
+				CPU:  &statsapi.CPUStats{UsageNanoCores: &v},
+			})
+		}
+		return stats
+	}
+
+	emitted := func(pods ...statsapi.PodStats) []string {
+		scraper := NewScraper(logging.Nop(), "", "", time.Second)
+		summary := &statsapi.Summary{Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}}, Pods: pods}
+
+		ch := make(chan prometheus.Metric, 100)
+		scraper.emit(ch, summary)
+		close(ch)
+
+		var series []string
+		for metric := range ch {
+			var m dto.Metric
+			if err := metric.Write(&m); err != nil {
+				t.Fatal(err)
+			}
+			series = append(series, metric.Desc().String()+m.String())
+		}
+		return series
+	}
+
+	ordered := emitted(pod("a", "x", "y"), pod("b", "x", "y"))
+	shuffled := emitted(pod("b", "y", "x"), pod("a", "y", "x"))
+
+	if len(ordered) == 0 {
+		t.Fatalf("expected metrics to be emitted")
+	}
+	if !reflect.DeepEqual(ordered, shuffled) {
+		t.Errorf("emission order depends on the order of the summary:\n%v\n%v", ordered, shuffled)
+	}
+}
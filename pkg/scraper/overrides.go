@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"sigs.k8s.io/yaml"
+)
+
+// MetricOverride changes how a metric is described, e.g. to link an
+// internal runbook from the help text Grafana shows.
+type MetricOverride struct {
+	// Help replaces the metric's help text when set.
+	Help string `json:"help"`
+	// ConstLabels are added to every series of the metric.
+	ConstLabels map[string]string `json:"constLabels"`
+}
+
+// ParseMetricOverrides parses YAML mapping metric names to overrides.
+func ParseMetricOverrides(data []byte) (map[string]MetricOverride, error) {
+	var overrides map[string]MetricOverride
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return nil, err
+	}
+
+	for name, override := range overrides {
+		for label := range override.ConstLabels {
+			if !model.LabelName(label).IsValid() || strings.HasPrefix(label, "__") {
+				return nil, fmt.Errorf("metric %q: invalid label name %q", name, label)
+			}
+		}
+	}
+
+	return overrides, nil
+}
+
+// WithMetricOverrides applies overrides to the descriptors of the named
+// metrics. With WithCounters, cumulative metrics can be named with or
+// without their _total suffix.
+func WithMetricOverrides(overrides map[string]MetricOverride) Option {
+	return func(s *Scraper) {
+		s.metricOverrides = overrides
+	}
+}
+
+// overrideDescs rebuilds the overridden descriptors in place, so the fields,
+// replacements and filters referring to them keep working. Overrides that
+// don't apply are reported by SelfCheck.
+func (s *Scraper) overrideDescs() {
+	if len(s.metricOverrides) == 0 {
+		return
+	}
+
+	applied := map[string]struct{}{}
+	for i, info := range s.descs.infos {
+		name := info.Name
+		override, ok := s.metricOverrides[name]
+		if !ok && info.Counter {
+			name = strings.TrimSuffix(info.Name, "_total")
+			override, ok = s.metricOverrides[name]
+		}
+		if !ok {
+			continue
+		}
+		applied[name] = struct{}{}
+
+		if err := checkConstLabels(info, override.ConstLabels); err != nil {
+			s.overrideErrs = append(s.overrideErrs, err)
+			continue
+		}
+
+		help := info.Help
+		if override.Help != "" {
+			help = override.Help
+		}
+
+		*s.descs.descs[i] = *prometheus.NewDesc(info.Name, help, info.Labels, override.ConstLabels)
+		s.descs.infos[i].Help = help
+		s.descs.infos[i].ConstLabels = override.ConstLabels
+	}
+
+	var unknown []string
+	for name := range s.metricOverrides {
+		if _, ok := applied[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		s.overrideErrs = append(s.overrideErrs, fmt.Errorf("overrides of unknown metrics: %s", strings.Join(unknown, ", ")))
+	}
+}
+
+// checkConstLabels fails when constant labels would clash with the labels
+// the metric already has.
+func checkConstLabels(info MetricInfo, labels map[string]string) error {
+	for _, label := range info.Labels {
+		if _, ok := labels[label]; ok {
+			return fmt.Errorf("override of %s: constant label %q is already a label of the metric", info.Name, label)
+		}
+	}
+	return nil
+}
+
+// overrideError returns the problems found applying metric overrides.
+func (s *Scraper) overrideError() error {
+	return errors.Join(s.overrideErrs...)
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestMetricOverrides(t *testing.T) {
+	overrides, err := ParseMetricOverrides([]byte(`
+kubelet_summary_node_cpu_usage_nano_cores:
+  help: CPU usage in nanocores, see https://runbooks.example.com/cpu
+  constLabels:
+    runbook: https://runbooks.example.com/cpu
+kubelet_summary_node_cpu_usage_core_nano_seconds:
+  constLabels:
+    team: compute
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithCounters(), WithMetricOverrides(overrides))
+	if err := s.SelfCheck(); err != nil {
+		t.Fatal(err)
+	}
+
+	cores, seconds := uint64(1000), uint64(2000)
+	summary := &statsapi.Summary{Node: statsapi.NodeStats{
+		NodeName: "node",
+		CPU:      &statsapi.CPUStats{Time: metav1.Now(), UsageNanoCores: &cores, UsageCoreNanoSeconds: &seconds},
+		Runtime:  &statsapi.RuntimeStats{},
+	}}
+	collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.emit(ch, summary) })
+
+	expected := `
+# HELP kubelet_summary_node_cpu_usage_core_nano_seconds_total CPU nanoseconds used
+# TYPE kubelet_summary_node_cpu_usage_core_nano_seconds_total counter
+kubelet_summary_node_cpu_usage_core_nano_seconds_total{node="node",team="compute"} 2000
+# HELP kubelet_summary_node_cpu_usage_nano_cores CPU usage in nanocores, see https://runbooks.example.com/cpu
+# TYPE kubelet_summary_node_cpu_usage_nano_cores gauge
+kubelet_summary_node_cpu_usage_nano_cores{node="node",runbook="https://runbooks.example.com/cpu"} 1000
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubelet_summary_node_cpu_usage_nano_cores", "kubelet_summary_node_cpu_usage_core_nano_seconds_total"); err != nil {
+		t.Error(err)
+	}
+
+	for _, info := range s.Metrics() {
+		if info.Name == "kubelet_summary_node_cpu_usage_nano_cores" && !strings.Contains(info.Help, "runbooks") {
+			t.Errorf("expected the overridden help in the metric infos, got %q", info.Help)
+		}
+	}
+}
+
+func TestMetricOverridesErrors(t *testing.T) {
+	if _, err := ParseMetricOverrides([]byte("kubelet_summary_node_cpu_usage_nano_cores:\n  constLabels:\n    bad-label: x\n")); err == nil {
+		t.Error("expected an invalid label name to be rejected")
+	}
+
+	for name, override := range map[string]MetricOverride{
+		"kubelet_summary_unknown":                   {Help: "unknown"},
+		"kubelet_summary_node_cpu_usage_nano_cores": {ConstLabels: map[string]string{"node": "clash"}},
+	} {
+		s := NewScraper(logging.Nop(), "", "", time.Second, WithMetricOverrides(map[string]MetricOverride{name: override}))
+		if err := s.SelfCheck(); err == nil {
+			t.Errorf("expected the override of %s to be reported", name)
+		}
+	}
+}
+
+// collectorFunc collects with a function; it is unchecked.
+type collectorFunc func(ch chan<- prometheus.Metric)
+
+func (f collectorFunc) Describe(chan<- *prometheus.Desc) {}
+
+func (f collectorFunc) Collect(ch chan<- prometheus.Metric) { f(ch) }
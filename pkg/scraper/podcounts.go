@@ -0,0 +1,28 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// emitPodCounts exports how many containers, volumes and interfaces a pod
+// has, which drive the cardinality of the per-container, per-volume and
+// per-interface metrics.
+func (s *Scraper) emitPodCounts(ch chan<- prometheus.Metric, nodeName string, pod statsapi.PodStats) {
+	namespace, podName := pod.PodRef.Namespace, pod.PodRef.Name
+
+	containers, volumes := uint64(len(pod.Containers)), uint64(len(pod.VolumeStats))
+	s.pushMetrics(ch, s.podContainerCount, &containers, nodeName, namespace, podName)
+	s.pushMetrics(ch, s.podVolumeCount, &volumes, nodeName, namespace, podName)
+
+	if pod.Network != nil {
+		interfaces := uint64(len(pod.Network.Interfaces))
+		s.pushMetrics(ch, s.podInterfaceCount, &interfaces, nodeName, namespace, podName)
+	}
+}
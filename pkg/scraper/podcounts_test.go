@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestPodCounts(t *testing.T) {
+	s := NewScraper(logging.Nop(), "", "", time.Second)
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{
+			{
+				PodRef:      statsapi.PodReference{Namespace: "default", Name: "web"},
+				Containers:  []statsapi.ContainerStats{{Name: "app"}, {Name: "sidecar"}},
+				VolumeStats: []statsapi.VolumeStats{{Name: "data"}},
+				Network: &statsapi.NetworkStats{Interfaces: []statsapi.InterfaceStats{
+					{Name: "eth0"}, {Name: "net1"},
+				}},
+			},
+			{
+				PodRef: statsapi.PodReference{Namespace: "kube-system", Name: "proxy"},
+			},
+		},
+	}
+	collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.emit(ch, summary) })
+
+	expected := `
+# HELP kubelet_summary_pod_containers Count of containers in pod
+# TYPE kubelet_summary_pod_containers gauge
+kubelet_summary_pod_containers{namespace="default",node="node",pod="web"} 2
+kubelet_summary_pod_containers{namespace="kube-system",node="node",pod="proxy"} 0
+# HELP kubelet_summary_pod_interfaces Count of network interfaces in pod
+# TYPE kubelet_summary_pod_interfaces gauge
+kubelet_summary_pod_interfaces{namespace="default",node="node",pod="web"} 2
+# HELP kubelet_summary_pod_volumes Count of volumes with stats in pod
+# TYPE kubelet_summary_pod_volumes gauge
+kubelet_summary_pod_volumes{namespace="default",node="node",pod="web"} 1
+kubelet_summary_pod_volumes{namespace="kube-system",node="node",pod="proxy"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubelet_summary_pod_containers", "kubelet_summary_pod_volumes", "kubelet_summary_pod_interfaces"); err != nil {
+		t.Error(err)
+	}
+}
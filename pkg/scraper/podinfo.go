@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WithPodInfo enables kubelet_summary_pod_info and
+// kubelet_summary_container_info from the pod specs of pods, so usage can be
+// grouped by the workload owning the pods.
+func WithPodInfo(pods PodSource) Option {
+	return func(s *Scraper) {
+		s.pods = pods
+		s.infoMetrics = true
+	}
+}
+
+// emitPodInfo exports the info metrics of pod, if its spec is known.
+func (s *Scraper) emitPodInfo(ch chan<- prometheus.Metric, nodeName string, pod *v1.Pod) {
+	if pod == nil || !s.infoMetrics {
+		return
+	}
+
+	ownerKind, ownerName := podOwner(pod)
+	ch <- prometheus.MustNewConstMetric(s.podInfo, prometheus.GaugeValue, 1,
+		nodeName, pod.Namespace, pod.Name, string(pod.UID), ownerKind, ownerName, string(pod.Status.QOSClass))
+
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			ch <- prometheus.MustNewConstMetric(s.containerInfo, prometheus.GaugeValue, 1,
+				nodeName, pod.Namespace, pod.Name, container.Name, container.Image)
+		}
+	}
+}
+
+// podOwner returns the workload controlling pod. Pods of a ReplicaSet
+// created by a Deployment are attributed to the Deployment, whose name the
+// ReplicaSet's carries followed by the pod template hash.
+func podOwner(pod *v1.Pod) (kind, name string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "", ""
+	}
+
+	if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; owner.Kind == "ReplicaSet" && hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+		return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+	}
+	return owner.Kind, owner.Name
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestPodInfo(t *testing.T) {
+	controller := true
+	pods := staticPods{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns", Name: "web-7d4b9c-x2x4z", UID: "uid-1",
+				Labels:          map[string]string{"pod-template-hash": "7d4b9c"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d4b9c", Controller: &controller}},
+			},
+			Spec:   v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "web:1.2"}}},
+			Status: v1.PodStatus{QOSClass: v1.PodQOSBurstable},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns", Name: "db-0", UID: "uid-2",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+			},
+			Spec:   v1.PodSpec{Containers: []v1.Container{{Name: "postgres", Image: "postgres:15"}}},
+			Status: v1.PodStatus{QOSClass: v1.PodQOSGuaranteed},
+		},
+	}
+
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithPodInfo(pods))
+
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{
+			{PodRef: statsapi.PodReference{Namespace: "ns", Name: "web-7d4b9c-x2x4z", UID: "uid-1"}},
+			{PodRef: statsapi.PodReference{Namespace: "ns", Name: "db-0", UID: "uid-2"}},
+			// Pods missing from the watch have no info.
+			{PodRef: statsapi.PodReference{Namespace: "ns", Name: "new", UID: "uid-3"}},
+		},
+	}
+	collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.emit(ch, summary) })
+
+	expected := `
+# HELP kubelet_summary_container_info Information about a container of the pod's spec
+# TYPE kubelet_summary_container_info gauge
+kubelet_summary_container_info{container="app",image="web:1.2",namespace="ns",node="node",pod="web-7d4b9c-x2x4z"} 1
+kubelet_summary_container_info{container="postgres",image="postgres:15",namespace="ns",node="node",pod="db-0"} 1
+# HELP kubelet_summary_pod_info Information about the pod, including the workload owning it
+# TYPE kubelet_summary_pod_info gauge
+kubelet_summary_pod_info{namespace="ns",node="node",owner_kind="Deployment",owner_name="web",pod="web-7d4b9c-x2x4z",qos_class="Burstable",uid="uid-1"} 1
+kubelet_summary_pod_info{namespace="ns",node="node",owner_kind="StatefulSet",owner_name="db",pod="db-0",qos_class="Guaranteed",uid="uid-2"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubelet_summary_pod_info", "kubelet_summary_container_info"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodLabelsAnnotation holds comma separated key=value labels that workload
+// owners want attached to their pod's metrics.
+const PodLabelsAnnotation = "kubelet-summary-exporter/labels"
+
+// PodSource provides the pods whose annotation labels are exported.
+type PodSource interface {
+	List() []*v1.Pod
+}
+
+// PodAnnotationLabels exports kubelet_summary_pod_annotation_labels, one
+// info series per annotated pod carrying its annotation labels as label_<key>
+// labels, to be joined onto the pod's series on namespace and pod.
+//
+// The label names differ between pods, so the collector is unchecked: it
+// describes nothing and must be registered next to, not inside, the Scraper.
+type PodAnnotationLabels struct {
+	pods      PodSource
+	dynamic   *DynamicConfig
+	namespace string
+}
+
+// NewPodAnnotationLabels returns a collector for the annotation labels of
+// pods. When dynamic is not nil, only the labels on its allowlist are
+// exported.
+func NewPodAnnotationLabels(pods PodSource, dynamic *DynamicConfig) *PodAnnotationLabels {
+	return &PodAnnotationLabels{pods: pods, dynamic: dynamic, namespace: metricNamespace}
+}
+
+// WithNamespace names the metric with namespace like the Scraper's
+// WithNamespace.
+func (c *PodAnnotationLabels) WithNamespace(namespace string) *PodAnnotationLabels {
+	if namespace != "" {
+		c.namespace = namespace
+	}
+	return c
+}
+
+func (c *PodAnnotationLabels) Describe(chan<- *prometheus.Desc) {}
+
+func (c *PodAnnotationLabels) Collect(ch chan<- prometheus.Metric) {
+	fqName := prometheus.BuildFQName(c.namespace, "pod", "annotation_labels")
+
+	pods := c.pods.List()
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	for _, pod := range pods {
+		extra := parsePodLabels(pod.Annotations[PodLabelsAnnotation])
+		for key := range extra {
+			if !c.dynamic.podLabelAllowed(key) {
+				delete(extra, key)
+			}
+		}
+		if len(extra) == 0 {
+			continue
+		}
+
+		names := []string{"node", "namespace", "pod"}
+		values := []string{pod.Spec.NodeName, pod.Namespace, pod.Name}
+
+		for _, key := range sortedKeys(extra) {
+			names = append(names, key)
+			values = append(values, extra[key])
+		}
+
+		desc := prometheus.NewDesc(fqName, "Labels from the pod's "+PodLabelsAnnotation+" annotation", names, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, values...)
+	}
+}
+
+// parsePodLabels parses "team=payments,tier=critical" into label_ prefixed
+// label names and their values. Malformed entries are skipped, and on
+// duplicates after sanitizing the last one wins.
+func parsePodLabels(annotation string) map[string]string {
+	parsed := map[string]string{}
+
+	for _, entry := range strings.Split(annotation, ",") {
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+
+		parsed["label_"+sanitizeLabelName(key)] = strings.TrimSpace(value)
+	}
+
+	return parsed
+}
+
+// sanitizeLabelName replaces every character that is not valid in a
+// Prometheus label name with an underscore.
+func sanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
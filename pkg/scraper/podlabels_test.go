@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePodLabels(t *testing.T) {
+	got := parsePodLabels(" team=payments, tier=critical,broken,=empty,cost-center=42")
+	want := map[string]string{
+		"label_team":        "payments",
+		"label_tier":        "critical",
+		"label_cost_center": "42",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+type staticPods []*v1.Pod
+
+func (p staticPods) List() []*v1.Pod {
+	return p
+}
+
+func TestPodAnnotationLabels(t *testing.T) {
+	pods := staticPods{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "checkout", Annotations: map[string]string{PodLabelsAnnotation: "team=payments"}},
+			Spec:       v1.PodSpec{NodeName: "node-a"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "cart", Annotations: map[string]string{PodLabelsAnnotation: "tier=critical"}},
+			Spec:       v1.PodSpec{NodeName: "node-a"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "plain"},
+			Spec:       v1.PodSpec{NodeName: "node-a"},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPodAnnotationLabels(pods, nil))
+
+	expected := `
+# HELP kubelet_summary_pod_annotation_labels Labels from the pod's kubelet-summary-exporter/labels annotation
+# TYPE kubelet_summary_pod_annotation_labels gauge
+kubelet_summary_pod_annotation_labels{label_tier="critical",namespace="shop",node="node-a",pod="cart"} 1
+kubelet_summary_pod_annotation_labels{label_team="payments",namespace="shop",node="node-a",pod="checkout"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPodAnnotationLabelsAllowlist(t *testing.T) {
+	pods := staticPods{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "checkout", Annotations: map[string]string{PodLabelsAnnotation: "team=payments,cost-center=42"}},
+			Spec:       v1.PodSpec{NodeName: "node-a"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "cart", Annotations: map[string]string{PodLabelsAnnotation: "tier=critical"}},
+			Spec:       v1.PodSpec{NodeName: "node-a"},
+		},
+	}
+
+	dynamic := NewDynamicConfig()
+	dynamic.Set(PodFilter{}, []string{"team"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPodAnnotationLabels(pods, dynamic))
+
+	expected := `
+# HELP kubelet_summary_pod_annotation_labels Labels from the pod's kubelet-summary-exporter/labels annotation
+# TYPE kubelet_summary_pod_annotation_labels gauge
+kubelet_summary_pod_annotation_labels{label_team="payments",namespace="shop",node="node-a",pod="checkout"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
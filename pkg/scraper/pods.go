@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// WithPodWorkers builds the metrics of up to workers pods at once, which
+// shortens scrapes of nodes running hundreds of pods. The metrics are still
+// emitted in the order of the pods. Below 2, pods are emitted one by one.
+func WithPodWorkers(workers int) Option {
+	return func(s *Scraper) {
+		s.podWorkers = workers
+	}
+}
+
+// podMetrics are the metrics built for a pod, emitted once they are ready.
+type podMetrics struct {
+	metrics       []prometheus.Metric
+	volumesHealth map[volumeKey]bool
+	logsUsage     map[containerKey]cumulativeValue
+	done          chan struct{}
+}
+
+// emitPods emits the metrics of pods, returning the health of their volumes
+// and the log usage of their containers.
+func (s *Scraper) emitPods(ch chan<- prometheus.Metric, nodeName string, pods []*statsapi.PodStats, now time.Time) (map[volumeKey]bool, map[containerKey]cumulativeValue) {
+	volumesHealth := map[volumeKey]bool{}
+	logsUsage := map[containerKey]cumulativeValue{}
+	podSpecs := s.podSpecs()
+
+	if s.podWorkers < 2 || len(pods) < 2 {
+		for _, pod := range pods {
+			s.emitPod(ch, nodeName, pod, podSpecs[types.UID(pod.PodRef.UID)], now, volumesHealth, logsUsage)
+		}
+		return volumesHealth, logsUsage
+	}
+
+	results := make([]*podMetrics, len(pods))
+	for i := range results {
+		results[i] = &podMetrics{
+			volumesHealth: map[volumeKey]bool{},
+			logsUsage:     map[containerKey]cumulativeValue{},
+			done:          make(chan struct{}),
+		}
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.podWorkers && w < len(pods); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				s.buildPod(results[i], nodeName, pods[i], podSpecs[types.UID(pods[i].PodRef.UID)], now)
+			}
+		}()
+	}
+	go func() {
+		for i := range pods {
+			next <- i
+		}
+		close(next)
+	}()
+
+	// Pods are sent in order as they complete, so the channel is written
+	// while later pods are still being built.
+	for _, result := range results {
+		<-result.done
+		for _, m := range result.metrics {
+			ch <- m
+		}
+		for key, abnormal := range result.volumesHealth {
+			volumesHealth[key] = abnormal
+		}
+		for key, usage := range result.logsUsage {
+			logsUsage[key] = usage
+		}
+	}
+	wg.Wait()
+
+	return volumesHealth, logsUsage
+}
+
+// buildPod buffers the metrics of pod in result.
+func (s *Scraper) buildPod(result *podMetrics, nodeName string, pod *statsapi.PodStats, podSpec *v1.Pod, now time.Time) {
+	defer close(result.done)
+
+	podCh := make(chan prometheus.Metric, 64)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for m := range podCh {
+			result.metrics = append(result.metrics, m)
+		}
+	}()
+
+	s.emitPod(podCh, nodeName, pod, podSpec, now, result.volumesHealth, result.logsUsage)
+	close(podCh)
+	<-collected
+}
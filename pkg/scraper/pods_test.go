@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// busySummary returns the synthetic summary with pods copies of its pod,
+// each with containers containers.
+func busySummary(pods, containers int) *statsapi.Summary {
+	summary := syntheticSummary(time.Now())
+	template := summary.Pods[0]
+	summary.Pods = nil
+	for i := 0; i < pods; i++ {
+		pod := template
+		pod.PodRef = statsapi.PodReference{Name: fmt.Sprintf("pod-%d", i), Namespace: fmt.Sprintf("ns-%d", i%10), UID: fmt.Sprintf("uid-%d", i)}
+		pod.Containers = nil
+		for c := 0; c < containers; c++ {
+			container := template.Containers[0]
+			container.Name = fmt.Sprintf("container-%d", c)
+			pod.Containers = append(pod.Containers, container)
+		}
+		summary.Pods = append(summary.Pods, pod)
+	}
+	return summary
+}
+
+// emitted returns the names and labels of the metrics emit sends for
+// summary, in order. Values are left out as the volume health transitions
+// are timed by each scraper.
+func emitted(t testing.TB, s *Scraper, summary *statsapi.Summary) []string {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		s.emit(ch, summary)
+		close(ch)
+	}()
+
+	var metrics []string
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, fmt.Sprint(m.Desc(), out.Label))
+	}
+	return metrics
+}
+
+func TestPodWorkersKeepOrder(t *testing.T) {
+	summary := busySummary(50, 3)
+	expected := emitted(t, NewScraper(logging.Nop(), "", "", time.Second), summary)
+
+	for _, workers := range []int{2, 8, 100} {
+		got := emitted(t, NewScraper(logging.Nop(), "", "", time.Second, WithPodWorkers(workers)), summary)
+		if len(got) != len(expected) {
+			t.Fatalf("%d workers: expected %d metrics, got %d", workers, len(expected), len(got))
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("%d workers: metric %d differs:\nexpected %s\ngot      %s", workers, i, expected[i], got[i])
+			}
+		}
+	}
+}
+
+// BenchmarkEmit emits a node of 300 pods of 4 containers, as scraped by
+// Prometheus, which writes every metric it is sent.
+func BenchmarkEmit(b *testing.B) {
+	summary := busySummary(300, 4)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s := NewScraper(logging.Nop(), "", "", time.Second, WithPodWorkers(workers))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				ch := make(chan prometheus.Metric, 1024)
+				go func() {
+					s.emit(ch, summary)
+					close(ch)
+				}()
+				for m := range ch {
+					var out dto.Metric
+					_ = m.Write(&out)
+				}
+			}
+		})
+	}
+}
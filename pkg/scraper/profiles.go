@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+)
+
+// Profiles, presets of the collector groups to export.
+const (
+	// ProfileEssentials exports node and pod metrics, enough for capacity
+	// planning at a small share of the series.
+	ProfileEssentials = "essentials"
+	// ProfileStandard exports everything but per-container metrics, which
+	// dominate cardinality on busy nodes.
+	ProfileStandard = "standard"
+	// ProfileFull exports every group.
+	ProfileFull = "full"
+)
+
+// Profiles lists every profile, from the smallest to the largest.
+var Profiles = []string{ProfileEssentials, ProfileStandard, ProfileFull}
+
+var profileGroups = map[string][]string{
+	ProfileEssentials: {GroupNode, GroupPods},
+	ProfileStandard:   {GroupNode, GroupSystemContainers, GroupPods, GroupVolumes, GroupNetwork, GroupAccelerators},
+	ProfileFull:       Groups,
+}
+
+// ProfileDisabledGroups returns the collector groups a profile doesn't
+// export, to be passed to WithDisabledGroups.
+func ProfileDisabledGroups(profile string) ([]string, error) {
+	groups, ok := profileGroups[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", profile)
+	}
+
+	exported := map[string]struct{}{}
+	for _, group := range groups {
+		exported[group] = struct{}{}
+	}
+
+	var disabled []string
+	for _, group := range Groups {
+		if _, ok := exported[group]; !ok {
+			disabled = append(disabled, group)
+		}
+	}
+	return disabled, nil
+}
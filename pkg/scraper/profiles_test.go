@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestProfiles(t *testing.T) {
+	if _, err := ProfileDisabledGroups("minimal"); err == nil {
+		t.Error("expected an unknown profile to be rejected")
+	}
+
+	// Each profile exports fewer metrics than the next one.
+	previous := -1
+	for _, profile := range Profiles {
+		disabled, err := ProfileDisabledGroups(profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := NewScraper(logging.Nop(), "", "", time.Second, WithDisabledGroups(disabled))
+		if err := s.SelfCheck(); err != nil {
+			t.Fatalf("%s: %v", profile, err)
+		}
+
+		exported := len(s.Metrics())
+		if exported <= previous {
+			t.Errorf("%s: expected more than %d metrics, got %d", profile, previous, exported)
+		}
+		previous = exported
+	}
+
+	if disabled, _ := ProfileDisabledGroups(ProfileFull); len(disabled) != 0 {
+		t.Errorf("expected the full profile to export every group, got %v disabled", disabled)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// SummaryProvider provides the stats summaries the Scraper exports. Kubelet
+// is requested over HTTP unless WithSummaryProvider sets another provider,
+// e.g. a summaryclient.File.
+type SummaryProvider interface {
+	GetSummary(ctx context.Context) (*statsapi.Summary, error)
+}
+
+// RawSummaryProvider is a SummaryProvider that can also return the summary
+// as JSON, including fields unknown to the stats API, which expressions are
+// evaluated against.
+type RawSummaryProvider interface {
+	SummaryProvider
+	GetRawSummary(ctx context.Context) ([]byte, error)
+}
+
+var (
+	_ RawSummaryProvider = (*summaryclient.Client)(nil)
+	_ RawSummaryProvider = summaryclient.File("")
+	_ RawSummaryProvider = (*summaryclient.Replay)(nil)
+)
+
+// WithSummaryProvider takes summaries from p instead of requesting kubelet.
+// Failures of p are counted like those of kubelet requests.
+func WithSummaryProvider(p SummaryProvider) Option {
+	return func(s *Scraper) {
+		s.summaryProvider = p
+	}
+}
+
+// SummaryFiller completes the summaries of a provider from another source,
+// e.g. the container runtime, before they are exported. It handles its own
+// failures, leaving the summary as it was.
+type SummaryFiller interface {
+	Fill(ctx context.Context, summary *statsapi.Summary)
+}
+
+// WithSummaryFiller completes every summary with f. Expressions are still
+// evaluated against the summary as the provider returned it.
+func WithSummaryFiller(f SummaryFiller) Option {
+	return func(s *Scraper) {
+		s.summaryFiller = f
+	}
+}
+
+// provider returns the provider of the next summary.
+func (s *Scraper) provider() SummaryProvider {
+	if s.summaryProvider != nil {
+		return s.summaryProvider
+	}
+
+	var tokens summaryclient.TokenSource
+	if s.authenticated() {
+		tokens = s.tokens()
+	}
+	client := summaryclient.New(s.statsURL(), tokens, summaryclient.DoerFunc(s.do))
+	client.MaxBytes = s.maxSummaryBytes
+	return client
+}
+
+// RawSummary fetches a summary as JSON, e.g. to record it. Providers that
+// can't return JSON have their summary marshalled.
+func (s *Scraper) RawSummary(ctx context.Context) ([]byte, error) {
+	p := s.provider()
+	if raw, ok := p.(RawSummaryProvider); ok {
+		return raw.GetRawSummary(ctx)
+	}
+
+	summary, err := p.GetSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(summary)
+}
+
+// getSummary returns the next summary, and its JSON when expressions need
+// it. Providers that can't return JSON have their summary marshalled, which
+// loses the fields unknown to the stats API.
+func (s *Scraper) getSummary(ctx context.Context) (*statsapi.Summary, []byte, error) {
+	p := s.provider()
+	if len(s.expressions) == 0 {
+		summary, err := p.GetSummary(ctx)
+		return summary, nil, err
+	}
+
+	if raw, ok := p.(RawSummaryProvider); ok {
+		body, err := raw.GetRawSummary(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary, err := summaryclient.Parse(body)
+		return summary, body, err
+	}
+
+	summary, err := p.GetSummary(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return nil, nil, &summaryclient.Error{Step: summaryclient.StepParse, Err: err}
+	}
+	return summary, body, nil
+}
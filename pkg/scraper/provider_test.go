@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+type summaryFunc func(ctx context.Context) (*statsapi.Summary, error)
+
+func (f summaryFunc) GetSummary(ctx context.Context) (*statsapi.Summary, error) { return f(ctx) }
+
+func TestFileSummaryProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := os.WriteFile(path, []byte(`{"node":{"nodeName":"node-a","runtime":{},"memory":{"workingSetBytes":1024}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithSummaryProvider(summaryclient.File(path)))
+
+	expected := `
+# HELP kubelet_summary_node_memory_working_set_bytes working set bytes in node memory
+# TYPE kubelet_summary_node_memory_working_set_bytes gauge
+kubelet_summary_node_memory_working_set_bytes{node="node-a"} 1024
+`
+	if err := testutil.CollectAndCompare(s, strings.NewReader(expected), "kubelet_summary_node_memory_working_set_bytes"); err != nil {
+		t.Error(err)
+	}
+
+	s = NewScraper(logging.Nop(), "", "", time.Second, WithSummaryProvider(summaryclient.File(filepath.Join(t.TempDir(), "missing.json"))))
+	ch := make(chan prometheus.Metric)
+	go func() {
+		for range ch {
+		}
+	}()
+	err := s.Scrape(ch)
+	close(ch)
+	if err == nil {
+		t.Error("expected a missing file to fail the scrape")
+	}
+}
+
+func TestSummaryProviderExpressions(t *testing.T) {
+	exprs, err := ParseExpressions([]byte(`
+- name: node_memory_working_set
+  each: .node
+  value: .memory.workingSetBytes
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workingSet := uint64(2048)
+	provider := summaryFunc(func(context.Context) (*statsapi.Summary, error) {
+		return &statsapi.Summary{Node: statsapi.NodeStats{
+			NodeName: "node-a",
+			Runtime:  &statsapi.RuntimeStats{},
+			Memory:   &statsapi.MemoryStats{WorkingSetBytes: &workingSet},
+		}}, nil
+	})
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithSummaryProvider(provider), WithExpressions(exprs))
+
+	// Summaries of providers without JSON are marshalled for expressions.
+	expected := `
+# HELP kubelet_summary_node_memory_working_set Value of .memory.workingSetBytes for each .node
+# TYPE kubelet_summary_node_memory_working_set gauge
+kubelet_summary_node_memory_working_set{node="node-a"} 2048
+`
+	if err := testutil.CollectAndCompare(s, strings.NewReader(expected), "kubelet_summary_node_memory_working_set"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRawSummary(t *testing.T) {
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithSummaryProvider(summaryFunc(func(context.Context) (*statsapi.Summary, error) {
+		return &statsapi.Summary{Node: statsapi.NodeStats{NodeName: "node-a"}}, nil
+	})))
+
+	body, err := s.RawSummary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := summaryclient.Parse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Node.NodeName != "node-a" {
+		t.Errorf("expected the provider's summary, got node %q", summary.Node.NodeName)
+	}
+}
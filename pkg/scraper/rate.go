@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+	"time"
+)
+
+type containerKey struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// cumulativeValue is a cumulative value of a container, such as its log
+// usage or CPU usage, as reported in one summary.
+type cumulativeValue struct {
+	value uint64
+	time  time.Time
+}
+
+type rateSample struct {
+	cumulativeValue
+	rate    float64
+	hasRate bool
+}
+
+// rateTracker keeps the previous cumulative value of every container to
+// derive how fast it grows between summaries.
+type rateTracker struct {
+	mu         sync.Mutex
+	containers map[containerKey]rateSample
+}
+
+func newRateTracker() *rateTracker {
+	return &rateTracker{containers: map[containerKey]rateSample{}}
+}
+
+// update records the values observed in one summary and returns the rate
+// per second of every container with a previous sample. A decreasing
+// value, e.g. after log rotation or a container restart, restarts the
+// computation. Containers that are no longer reported are forgotten.
+func (t *rateTracker) update(observed map[containerKey]cumulativeValue) map[containerKey]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rates := map[containerKey]float64{}
+	containers := make(map[containerKey]rateSample, len(observed))
+	for key, value := range observed {
+		sample := rateSample{cumulativeValue: value}
+
+		if prev, ok := t.containers[key]; ok {
+			switch {
+			case !value.time.After(prev.time):
+				// The kubelet served cached stats, keep the previous sample.
+				sample = prev
+			case value.value >= prev.value:
+				sample.rate = float64(value.value-prev.value) / value.time.Sub(prev.time).Seconds()
+				sample.hasRate = true
+			}
+		}
+
+		if sample.hasRate {
+			rates[key] = sample.rate
+		}
+		containers[key] = sample
+	}
+	t.containers = containers
+
+	return rates
+}
+
+// reset forgets every container's previous sample.
+func (t *rateTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.containers = map[containerKey]rateSample{}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateTracker(t *testing.T) {
+	tracker := newRateTracker()
+	key := containerKey{namespace: "default", pod: "web", container: "app"}
+	start := time.Now()
+
+	for _, tc := range []struct {
+		name    string
+		value   uint64
+		after   time.Duration
+		rate    float64
+		hasRate bool
+	}{
+		{name: "first sample", value: 1e9},
+		{name: "growing", value: 6e9, after: 10 * time.Second, rate: 5e8, hasRate: true},
+		{name: "cached stats", value: 6e9, after: 10 * time.Second, rate: 5e8, hasRate: true},
+		{name: "counter reset", value: 1e8, after: 20 * time.Second},
+		{name: "after the reset", value: 1.1e9, after: 30 * time.Second, rate: 1e8, hasRate: true},
+	} {
+		rates := tracker.update(map[containerKey]cumulativeValue{key: {value: tc.value, time: start.Add(tc.after)}})
+		rate, ok := rates[key]
+		if ok != tc.hasRate || rate != tc.rate {
+			t.Errorf("%s: expected rate %v (%v), got %v (%v)", tc.name, tc.rate, tc.hasRate, rate, ok)
+		}
+	}
+
+	tracker.update(map[containerKey]cumulativeValue{})
+	if len(tracker.containers) != 0 {
+		t.Errorf("expected containers no longer reported to be forgotten, got %v", tracker.containers)
+	}
+}
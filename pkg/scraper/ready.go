@@ -0,0 +1,28 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"time"
+)
+
+// CheckKubelet returns nil if a summary was fetched within window, by a
+// scrape or an earlier check, and otherwise fetches one, so readiness
+// follows kubelet's reachability without fetching on every probe.
+func (s *Scraper) CheckKubelet(ctx context.Context, window time.Duration) error {
+	start := time.Now()
+	if start.Sub(s.scrapeStats.lastFetched()) <= window {
+		return nil
+	}
+
+	if _, _, err := s.getSummary(ctx); err != nil {
+		return err
+	}
+	s.scrapeStats.fetchedAt(start)
+	return nil
+}
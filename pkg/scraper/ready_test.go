@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestCheckKubelet(t *testing.T) {
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"node":{"nodeName":"node","runtime":{}}}`))
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second, WithTokenSource(staticToken("token")))
+
+	if err := s.CheckKubelet(context.Background(), time.Minute); err == nil {
+		t.Error("expected an unreachable kubelet to fail the check")
+	}
+
+	healthy.Store(true)
+	if err := s.CheckKubelet(context.Background(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// Within the window the check doesn't fetch again.
+	healthy.Store(false)
+	if err := s.CheckKubelet(context.Background(), time.Minute); err != nil {
+		t.Error(err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const metricNamespace = "kubelet_summary"
+
+// MetricInfo describes a metric family exported by the Scraper.
+type MetricInfo struct {
+	// Name is the fully qualified metric name.
+	Name string
+	// Group is the metric group (the subsystem part of the name), e.g. pod_memory.
+	Group string
+	// Help is the metric's help text.
+	Help string
+	// Labels are the variable labels of the metric, in emission order.
+	Labels []string
+	// Unit is the metric's base unit, e.g. bytes, or empty when it has
+	// none. It is carried in remote write and OpenMetrics metadata.
+	Unit string
+	// Counter is set for cumulative metrics exposed as counters.
+	Counter bool
+	// ConstLabels are added to every series by metric overrides.
+	ConstLabels map[string]string
+}
+
+// baseUnits are the units a metric name may end in, following the
+// Prometheus naming conventions.
+var baseUnits = []string{"bytes", "seconds"}
+
+func unitOf(name string) string {
+	for _, unit := range baseUnits {
+		if strings.HasSuffix(name, "_"+unit) {
+			return unit
+		}
+	}
+	return ""
+}
+
+// descRegistry is the table of every summary descriptor the Scraper can
+// emit, kept alongside the metadata used to build it so that tooling (self
+// checks, dashboards, rules) can be derived from a single source.
+type descRegistry struct {
+	descs []*prometheus.Desc
+	infos []MetricInfo
+}
+
+func (r *descRegistry) add(subsystem, name, help string, labels []string) *prometheus.Desc {
+	fqName := prometheus.BuildFQName(metricNamespace, subsystem, name)
+	desc := prometheus.NewDesc(fqName, help, labels, nil)
+
+	r.descs = append(r.descs, desc)
+	r.infos = append(r.infos, MetricInfo{
+		Name:   fqName,
+		Group:  subsystem,
+		Help:   help,
+		Labels: labels,
+		Unit:   unitOf(fqName),
+	})
+
+	return desc
+}
+
+// addCounter is add for metrics that are counters in the summary already,
+// rather than through WithCounters.
+func (r *descRegistry) addCounter(subsystem, name, help string, labels []string) *prometheus.Desc {
+	desc := r.add(subsystem, name, help, labels)
+	r.infos[len(r.infos)-1].Counter = true
+
+	return desc
+}
+
+// Metrics returns the metric families the Scraper exports, leaving out
+// disabled collector groups.
+func (s *Scraper) Metrics() []MetricInfo {
+	infos := make([]MetricInfo, 0, len(s.descs.infos))
+	for _, info := range s.descs.infos {
+		if s.groupEnabled(info) {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// WithNamespace names the summary metrics with namespace instead of
+// kubelet_summary, e.g. for setups federating several exporters. The
+// exporter's own kubelet_summary_exporter metrics keep their names.
+func WithNamespace(namespace string) Option {
+	return func(s *Scraper) {
+		s.namespace = namespace
+	}
+}
+
+// CheckNamespace fails for namespaces that don't make valid metric names.
+func CheckNamespace(namespace string) error {
+	if !model.IsValidMetricName(model.LabelValue(namespace)) {
+		return fmt.Errorf("invalid metric namespace %q", namespace)
+	}
+	return nil
+}
+
+// renameDescs rebuilds the descriptors in place under the namespace of
+// WithNamespace, before counters and overrides are applied so those see the
+// names exported.
+func (s *Scraper) renameDescs() {
+	if s.namespace == "" || s.namespace == metricNamespace {
+		return
+	}
+
+	for i, info := range s.descs.infos {
+		name := s.namespace + strings.TrimPrefix(info.Name, metricNamespace)
+		*s.descs.descs[i] = *prometheus.NewDesc(name, info.Help, info.Labels, nil)
+		s.descs.infos[i].Name = name
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestMetricUnits(t *testing.T) {
+	units := map[string]string{}
+	for _, info := range NewScraper(logging.Nop(), "", "", time.Second).Metrics() {
+		units[info.Name] = info.Unit
+	}
+
+	for name, want := range map[string]string{
+		"kubelet_summary_pod_memory_working_set_bytes": "bytes",
+		"kubelet_summary_node_cpu_usage_nano_cores":    "",
+		"kubelet_summary_node_fs_inodes_free":          "",
+	} {
+		got, ok := units[name]
+		if !ok {
+			t.Errorf("%s is not registered", name)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: expected unit %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestCounters(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", time.Second, WithCounters())
+	if err := scraper.SelfCheck(); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := map[string]MetricInfo{}
+	for _, info := range scraper.Metrics() {
+		infos[info.Name] = info
+	}
+
+	for name, counter := range map[string]bool{
+		"kubelet_summary_node_cpu_usage_core_nano_seconds_total":   true,
+		"kubelet_summary_pod_interface_rx_bytes_total":             true,
+		"kubelet_summary_container_memory_major_page_faults_total": true,
+		"kubelet_summary_node_cpu_usage_nano_cores":                false,
+	} {
+		info, ok := infos[name]
+		if !ok {
+			t.Errorf("%s is not registered", name)
+			continue
+		}
+		if info.Counter != counter {
+			t.Errorf("%s: expected counter %v", name, counter)
+		}
+	}
+
+	if unit := infos["kubelet_summary_pod_interface_rx_bytes_total"].Unit; unit != "bytes" {
+		t.Errorf("expected counters to keep their unit, got %q", unit)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", time.Second, WithNamespace("k8s_node"), WithCounters(),
+		WithMetricOverrides(map[string]MetricOverride{"k8s_node_node_cpu_usage_core_nano_seconds": {Help: "Overridden"}}))
+	if err := scraper.SelfCheck(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, info := range scraper.Metrics() {
+		if !strings.HasPrefix(info.Name, "k8s_node_") {
+			t.Errorf("%s is not in the namespace", info.Name)
+		}
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	scraper.emit(ch, syntheticSummary(time.Now()))
+	close(ch)
+
+	fqName := regexp.MustCompile(`fqName: "([^"]+)"`)
+	emitted := map[string]bool{}
+	for metric := range ch {
+		emitted[fqName.FindStringSubmatch(metric.Desc().String())[1]] = true
+	}
+	for _, name := range []string{"k8s_node_pod_memory_working_set_bytes", "k8s_node_node_cpu_usage_core_nano_seconds_total"} {
+		if !emitted[name] {
+			t.Errorf("expected %s to be emitted", name)
+		}
+	}
+
+	if err := CheckNamespace("k8s-node"); err == nil {
+		t.Error("expected an invalid namespace to fail")
+	}
+}
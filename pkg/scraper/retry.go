@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// RetryOptions retry summary requests that failed transiently, e.g. on a
+// connection reset, instead of leaving a gap until the next scrape.
+type RetryOptions struct {
+	// MaxAttempts is the number of requests per scrape, including the
+	// first. Below 2 requests aren't retried.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every
+	// following one.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, unless it is 0.
+	MaxBackoff time.Duration
+	// Jitter randomizes every delay by up to this share of it, between 0
+	// and 1, so exporters don't retry a restarting kubelet in lockstep.
+	Jitter float64
+}
+
+// WithRetries retries failed summary requests as long as the scrape's
+// context leaves time for the retry.
+func WithRetries(o RetryOptions) Option {
+	return func(s *Scraper) {
+		s.retry = o
+	}
+}
+
+func newRetries() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kubelet_summary_exporter",
+		Name:      "retries_total",
+		Help:      "Stats summary requests retried, by the stage of the failure retried",
+	}, []string{"stage"})
+}
+
+// retryable tells the failures that may pass on a retry: the request or
+// the read of the response failed, or kubelet or a proxy answered that it
+// is unavailable.
+func retryable(err error) (summaryclient.Step, bool) {
+	var fetchErr *summaryclient.Error
+	if !errors.As(err, &fetchErr) {
+		return "", false
+	}
+
+	switch fetchErr.Step {
+	case summaryclient.StepRequest:
+		return fetchErr.Step, true
+	case summaryclient.StepRead:
+		return fetchErr.Step, !errors.Is(err, summaryclient.ErrTooLarge)
+	case summaryclient.StepStatus:
+		switch fetchErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return fetchErr.Step, true
+		}
+	}
+	return "", false
+}
+
+// backoff returns the delay before retry n, counted from 1.
+func (o RetryOptions) backoff(n int) time.Duration {
+	delay := o.Backoff
+	for i := 1; i < n && (o.MaxBackoff <= 0 || delay < o.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if o.MaxBackoff > 0 && delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+
+	if o.Jitter > 0 {
+		//nolint:gosec // Jitter doesn't need a secure random source.
+		delay += time.Duration(float64(delay) * o.Jitter * (2*rand.Float64() - 1))
+	}
+	return delay
+}
+
+// getSummaryRetrying is getSummary, retried on transient failures while
+// attempts and the deadline of ctx allow it.
+func (s *Scraper) getSummaryRetrying(ctx context.Context) (*statsapi.Summary, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		summary, body, err := s.getSummary(ctx)
+		if err == nil || attempt >= s.retry.MaxAttempts {
+			return summary, body, err
+		}
+
+		stage, ok := retryable(err)
+		if !ok || ctx.Err() != nil {
+			return summary, body, err
+		}
+
+		delay := s.retry.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return summary, body, err
+		}
+
+		s.logger.Debug("retrying stats summary request", "attempt", attempt, "delay", delay, "error", err)
+		s.retries.WithLabelValues(string(stage)).Inc()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return summary, body, err
+		}
+	}
+}
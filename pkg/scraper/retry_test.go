@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		status           int
+		attempts         int
+		expectedRequests int32
+		expectedErr      bool
+	}{
+		{name: "unavailable retried", status: http.StatusServiceUnavailable, attempts: 3, expectedRequests: 3},
+		{name: "attempts exhausted", status: http.StatusServiceUnavailable, attempts: 2, expectedRequests: 2, expectedErr: true},
+		{name: "disabled", status: http.StatusServiceUnavailable, attempts: 1, expectedRequests: 1, expectedErr: true},
+		{name: "forbidden not retried", status: http.StatusForbidden, attempts: 3, expectedRequests: 1, expectedErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int32
+			kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) < 3 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"node":{"nodeName":"node","runtime":{}}}`))
+			}))
+			defer kubelet.Close()
+
+			s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second,
+				WithTokenSource(staticToken("token")),
+				WithRetries(RetryOptions{MaxAttempts: tc.attempts, Backoff: time.Millisecond, Jitter: 0.5}))
+
+			_, _, err := s.getSummaryRetrying(context.Background())
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if got := atomic.LoadInt32(&requests); got != tc.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedRequests, got)
+			}
+			if got := testutil.ToFloat64(s.retries.WithLabelValues("status")); got != float64(tc.expectedRequests-1) {
+				t.Errorf("expected %d retries, got %v", tc.expectedRequests-1, got)
+			}
+		})
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	var requests int32
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second,
+		WithTokenSource(staticToken("token")),
+		WithRetries(RetryOptions{MaxAttempts: 5, Backoff: time.Minute}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, _, err := s.getSummaryRetrying(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected no retry past the deadline, got %d requests", got)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	o := RetryOptions{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for n, expected := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		60: time.Second,
+	} {
+		if got := o.backoff(n); got != expected {
+			t.Errorf("retry %d: expected %v, got %v", n, expected, got)
+		}
+	}
+
+	o.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := o.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("expected 100ms ±50%%, got %v", got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"hash/fnv"
+)
+
+// sampler selects a stable subset of pods in low-value namespaces so that
+// very large batch clusters can trade completeness for cardinality.
+type sampler struct {
+	namespaces map[string]struct{}
+	every      uint32
+}
+
+// WithSampling exports only every Nth pod (chosen by a stable hash of its
+// namespace and name) in the given namespaces. A value of every below 2
+// disables sampling.
+func WithSampling(namespaces []string, every uint32) Option {
+	return func(s *Scraper) {
+		if len(namespaces) == 0 || every < 2 {
+			return
+		}
+
+		smp := &sampler{
+			namespaces: make(map[string]struct{}, len(namespaces)),
+			every:      every,
+		}
+		for _, namespace := range namespaces {
+			smp.namespaces[namespace] = struct{}{}
+		}
+
+		s.sampler = smp
+	}
+}
+
+// sampled reports whether pods in namespace are subject to sampling.
+func (smp *sampler) sampled(namespace string) bool {
+	if smp == nil {
+		return false
+	}
+
+	_, ok := smp.namespaces[namespace]
+	return ok
+}
+
+// keep reports whether the pod belongs to the exported subset. The decision
+// only depends on the pod's identity so a pod is either always or never
+// exported across scrapes.
+func (smp *sampler) keep(namespace, pod string) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	_, _ = h.Write([]byte{'/'})
+	_, _ = h.Write([]byte(pod))
+
+	return h.Sum32()%smp.every == 0
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestSampling(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", 1*time.Microsecond, WithSampling([]string{"batch"}, 4))
+
+	if scraper.sampler.sampled("default") {
+		t.Errorf("namespace default should not be sampled")
+	}
+
+	if !scraper.sampler.sampled("batch") {
+		t.Fatalf("namespace batch should be sampled")
+	}
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		pod := fmt.Sprintf("job-%d", i)
+		keep := scraper.sampler.keep("batch", pod)
+		if keep != scraper.sampler.keep("batch", pod) {
+			t.Fatalf("sampling decision for %s is not stable", pod)
+		}
+		if keep {
+			kept++
+		}
+	}
+
+	if kept < 150 || kept > 350 {
+		t.Errorf("expected roughly a quarter of pods to be kept, got %d of 1000", kept)
+	}
+}
+
+func TestSamplingDisabled(t *testing.T) {
+	for _, every := range []uint32{0, 1} {
+		scraper := NewScraper(logging.Nop(), "", "", 1*time.Microsecond, WithSampling([]string{"batch"}, every))
+		if scraper.sampler.sampled("batch") {
+			t.Errorf("sampling should be disabled for every=%d", every)
+		}
+	}
+}
@@ -7,26 +7,104 @@
 package scraper
 
 import (
+	"context"
 	"crypto/tls"
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"go.uber.org/zap"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/nodeproxy"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
 )
 
 type Scraper struct {
-	tokenPath string
-	timeout   time.Duration
-	targetIP  string
-	errors    *prometheus.Desc
-	errCnt    float64
-	logger    *zap.Logger
+	tokenPath   string
+	tokenSource TokenSource
+	timeout     time.Duration
+	targetIP    string
+	errors      *prometheus.CounterVec
+	authErrs    *prometheus.CounterVec
+
+	expressions     []*Expression
+	hedgeDelay      time.Duration
+	retry           RetryOptions
+	retries         *prometheus.CounterVec
+	faults          *faultTransport
+	tlsConfig       *tls.Config
+	transport       TransportOptions
+	httpClient      *http.Client
+	disabledGroups  map[string]struct{}
+	podFilter       PodFilter
+	interfaceFilter InterfaceFilter
+	podWorkers      int
+	timestamps      bool
+	freshness       *freshnessTracker
+	gaps            *gapDetector
+	dynamic         *DynamicConfig
+	counters        bool
+	counterDescs    map[*prometheus.Desc]*prometheus.Desc
+	disabledDescs   map[*prometheus.Desc]struct{}
+	hedgedRequests  uint64
+	hedged          *prometheus.Desc
+
+	maxSummaryBytes int64
+	metricOverrides map[string]MetricOverride
+	overrideErrs    []error
+	canary          *canary
+	scheme          string
+	port            string
+	nodeProxy       *nodeproxy.Proxy
+	summaryProvider SummaryProvider
+	summaryFiller   SummaryFiller
+
+	memory                   *memoryTracker
+	scrapeAllocatedBytes     *prometheus.Desc
+	scrapeHeapHighWaterBytes *prometheus.Desc
+
+	scrapeDuration              prometheus.Histogram
+	requestPhases               *prometheus.HistogramVec
+	scrapeStats                 *scrapeStats
+	nativeHistogramBucketFactor float64
+
+	logger    logging.Logger
+	sampler   *sampler
+	sysfsPath string
+	descs     *descRegistry
+
+	nodes          NodeSource
+	nodepoolLabels []string
+	pods           PodSource
+	resourceRatios bool
+	infoMetrics    bool
+
+	namespaceAggregates bool
+	userDefinedMetrics  bool
+
+	suppressInMaintenance bool
+	volumeHealth          *volumeHealthTracker
+	logGrowth             *rateTracker
+	cpuRate               *rateTracker
+	history               *usageHistory
+	imageGC               *imageGCConfig
+	statsPath             string
+	statsQuery            url.Values
+	onlyCPUAndMemory      bool
+	namespace             string
+
+	breakersMu           sync.Mutex
+	breakers             map[string]*breaker
+	collectorMaxFailures int
+	collectorCooldown    time.Duration
+	collectorEnabled     *prometheus.Desc
 
 	nodeFsUsedBytes                            *prometheus.Desc
 	nodeFsAvailableBytes                       *prometheus.Desc
@@ -38,6 +116,8 @@ type Scraper struct {
 	nodeRuntimeImageFsInodesFree               *prometheus.Desc
 	nodeRuntimeImageFsInodes                   *prometheus.Desc
 	nodeRuntimeImageFsInodesUsed               *prometheus.Desc
+	nodeRuntimeImageFsGCHeadroomBytes          *prometheus.Desc
+	nodeRuntimeImageFsGCImminent               *prometheus.Desc
 	nodeRuntimeContainerFsUsedBytes            *prometheus.Desc
 	nodeRuntimeContainerFsAvailableBytes       *prometheus.Desc
 	nodeRuntimeContainerFsInodesFree           *prometheus.Desc
@@ -55,10 +135,27 @@ type Scraper struct {
 	nodeSwapUsageBytes                         *prometheus.Desc
 	nodeRLimitMaxPID                           *prometheus.Desc
 	nodeRLimitNumOfRunningProcess              *prometheus.Desc
+	nodePodsProcessCount                       *prometheus.Desc
+	nodeHeadroomCPUNanoCores                   *prometheus.Desc
+	nodeHeadroomMemoryBytes                    *prometheus.Desc
+	nodeMaintenance                            *prometheus.Desc
+	nodeAllocatableCPUNanoCores                *prometheus.Desc
+	nodeAllocatableMemoryBytes                 *prometheus.Desc
+	nodeAllocatablePods                        *prometheus.Desc
+	nodeAllocatableEphemeralStorageBytes       *prometheus.Desc
+	nodeCapacityCPUNanoCores                   *prometheus.Desc
+	nodeCapacityMemoryBytes                    *prometheus.Desc
+	nodeCapacityPods                           *prometheus.Desc
+	nodeCapacityEphemeralStorageBytes          *prometheus.Desc
 	nodeInterfaceRxBytes                       *prometheus.Desc
 	nodeInterfaceRxErrors                      *prometheus.Desc
 	nodeInterfaceTxBytes                       *prometheus.Desc
 	nodeInterfaceTxErrors                      *prometheus.Desc
+	nodeInterfaceSpeedBytes                    *prometheus.Desc
+	nodeAcceleratorMemoryTotal                 *prometheus.Desc
+	nodeAcceleratorMemoryUsed                  *prometheus.Desc
+	nodeAcceleratorDutyCycle                   *prometheus.Desc
+	nodeAcceleratorContainers                  *prometheus.Desc
 	nodeSystemContainerRootFsUsedBytes         *prometheus.Desc
 	nodeSystemContainerRootFsAvailableBytes    *prometheus.Desc
 	nodeSystemContainerRootFsInodesFree        *prometheus.Desc
@@ -108,7 +205,16 @@ type Scraper struct {
 	podVolumeInodes                   *prometheus.Desc
 	podVolumeInodesUsed               *prometheus.Desc
 	podVolumeHealthStatus             *prometheus.Desc
+	podVolumeHealthLastTransition     *prometheus.Desc
 	podProcessCount                   *prometheus.Desc
+	podStartTime                      *prometheus.Desc
+	podContainerCount                 *prometheus.Desc
+	podVolumeCount                    *prometheus.Desc
+	podInterfaceCount                 *prometheus.Desc
+	podInfo                           *prometheus.Desc
+	containerInfo                     *prometheus.Desc
+	containerUserDefined              *prometheus.Desc
+	containerUserDefinedTotal         *prometheus.Desc
 
 	containerRootFsUsedBytes         *prometheus.Desc
 	containerRootFsAvailableBytes    *prometheus.Desc
@@ -120,6 +226,8 @@ type Scraper struct {
 	containerLogsInodesFree          *prometheus.Desc
 	containerLogsInodes              *prometheus.Desc
 	containerLogsInodesUsed          *prometheus.Desc
+	containerLogsGrowthBytes         *prometheus.Desc
+	containerCPUDerivedNanoCores     *prometheus.Desc
 	containerCPUUsageNanoCores       *prometheus.Desc
 	containerCPUUsageCoreNanoSeconds *prometheus.Desc
 	containerMemoryAvailableBytes    *prometheus.Desc
@@ -130,962 +238,1234 @@ type Scraper struct {
 	containerMemoryMajorPageFaults   *prometheus.Desc
 	containerSwapAvailableBytes      *prometheus.Desc
 	containerSwapUsageBytes          *prometheus.Desc
+	containerStartTime               *prometheus.Desc
 	containerAcceleratorMemoryTotal  *prometheus.Desc
 	containerAcceleratorMemoryUsed   *prometheus.Desc
 	containerAcceleratorDutyCycle    *prometheus.Desc
+
+	containerCPUUsageVsRequestRatio         *prometheus.Desc
+	containerCPUUsageVsLimitRatio           *prometheus.Desc
+	containerMemoryWorkingSetVsRequestRatio *prometheus.Desc
+	containerMemoryWorkingSetVsLimitRatio   *prometheus.Desc
+
+	namespaceSampleFactor *prometheus.Desc
+	staleness             *prometheus.Desc
+
+	namespaceCPUUsageNanoCores     *prometheus.Desc
+	namespaceMemoryWorkingSetBytes *prometheus.Desc
 }
 
-func NewScraper(logger *zap.Logger, targetIP string, tokenPath string, timeout time.Duration) *Scraper {
-	return &Scraper{
-		tokenPath: tokenPath,
-		timeout:   timeout,
-		targetIP:  targetIP,
-		logger:    logger.With(zap.String("component", "scraper")),
-		containerRootFsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_fs", "usage_bytes"),
+// Option configures optional Scraper behaviour.
+type Option func(*Scraper)
+
+func NewScraper(logger logging.Logger, targetIP string, tokenPath string, timeout time.Duration, opts ...Option) *Scraper {
+	descs := &descRegistry{}
+	s := &Scraper{
+		tokenPath:    tokenPath,
+		timeout:      timeout,
+		targetIP:     targetIP,
+		logger:       logging.With(logger, "component", "scraper"),
+		descs:        descs,
+		volumeHealth: newVolumeHealthTracker(),
+		logGrowth:    newRateTracker(),
+		cpuRate:      newRateTracker(),
+		statsPath:    defaultStatsPath,
+		scheme:       defaultScheme,
+		port:         defaultPort,
+
+		collectorMaxFailures: defaultCollectorMaxFailures,
+		collectorCooldown:    defaultCollectorCooldown,
+		containerRootFsUsedBytes: descs.add(
+			"container_fs", "usage_bytes",
 			"Disk used in bytes",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerRootFsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_fs", "limit_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerRootFsAvailableBytes: descs.add(
+			"container_fs", "limit_bytes",
 			"Capacity of container disk",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerRootFsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_fs", "inodes_free"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerRootFsInodesFree: descs.add(
+			"container_fs", "inodes_free",
 			"Number of inodes free in container fs",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerRootFsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_fs", "inodes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerRootFsInodes: descs.add(
+			"container_fs", "inodes",
 			"Number of inodes in container fs",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerRootFsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_fs", "inodes_used"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerRootFsInodesUsed: descs.add(
+			"container_fs", "inodes_used",
 			"Number of inodes used in container fs",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerLogsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_logs", "usage_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerLogsUsedBytes: descs.add(
+			"container_logs", "usage_bytes",
 			"Logs space used in bytes",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerLogsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_logs", "limit_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerLogsAvailableBytes: descs.add(
+			"container_logs", "limit_bytes",
 			"Capacity of container log space",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerLogsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_logs", "inodes_free"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerLogsInodesFree: descs.add(
+			"container_logs", "inodes_free",
 			"Number of inodes free in container log space",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerLogsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_logs", "inodes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerLogsInodes: descs.add(
+			"container_logs", "inodes",
 			"Number of inodes in container log space",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerLogsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_logs", "inodes_used"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerLogsInodesUsed: descs.add(
+			"container_logs", "inodes_used",
 			"Number of inodes used in container log space",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerCPUUsageNanoCores: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_cpu", "usage_nano_cores"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerLogsGrowthBytes: descs.add(
+			"container_logs", "growth_bytes_per_second",
+			"Growth rate of container logs in bytes per second",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerCPUUsageNanoCores: descs.add(
+			"container_cpu", "usage_nano_cores",
 			"CPU usage in nanocores",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerCPUUsageCoreNanoSeconds: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_cpu", "usage_core_nano_seconds"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerCPUUsageCoreNanoSeconds: descs.add(
+			"container_cpu", "usage_core_nano_seconds",
 			"CPU nanoseconds used",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerMemoryAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_memory", "available_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerCPUDerivedNanoCores: descs.add(
+			"container_cpu", "derived_usage_nano_cores",
+			"CPU usage in nanocores derived from consecutive usage_core_nano_seconds, for containers whose runtime doesn't report usage_nano_cores",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryAvailableBytes: descs.add(
+			"container_memory", "available_bytes",
 			"available bytes in container memory",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerMemoryUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_memory", "usage_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryUsageBytes: descs.add(
+			"container_memory", "usage_bytes",
 			"Used bytes in container memory",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerMemoryWorkingSetBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_memory", "working_set_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryWorkingSetBytes: descs.add(
+			"container_memory", "working_set_bytes",
 			"working set bytes in container memory",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerMemoryRSSBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_memory", "rss_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryRSSBytes: descs.add(
+			"container_memory", "rss_bytes",
 			"rss bytes in container memory",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerMemoryPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_memory", "page_faults"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryPageFaults: descs.add(
+			"container_memory", "page_faults",
 			"Page faults in container memory",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerMemoryMajorPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_memory", "major_page_faults"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryMajorPageFaults: descs.add(
+			"container_memory", "major_page_faults",
 			"Major page faults in container memory",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerSwapAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_swap", "available_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerSwapAvailableBytes: descs.add(
+			"container_swap", "available_bytes",
 			"Available bytes in container's swap storage",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerSwapUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_swap", "usage_bytes"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerSwapUsageBytes: descs.add(
+			"container_swap", "usage_bytes",
 			"Used bytes in container's swap storage",
-			[]string{"node", "namespace", "pod", "container"},
-			nil),
-		containerAcceleratorMemoryTotal: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_accelerator", "memory_total"),
+			[]string{"node", "namespace", "pod", "container"}),
+		containerStartTime: descs.add(
+			"container", "start_time_seconds",
+			"Unix time the container was last started at",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerCPUUsageVsRequestRatio: descs.add(
+			"container_cpu", "usage_vs_request_ratio",
+			"CPU usage relative to the container's CPU request",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerCPUUsageVsLimitRatio: descs.add(
+			"container_cpu", "usage_vs_limit_ratio",
+			"CPU usage relative to the container's CPU limit",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryWorkingSetVsRequestRatio: descs.add(
+			"container_memory", "working_set_vs_request_ratio",
+			"Memory working set relative to the container's memory request",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerMemoryWorkingSetVsLimitRatio: descs.add(
+			"container_memory", "working_set_vs_limit_ratio",
+			"Memory working set relative to the container's memory limit, the OOM kill threshold",
+			[]string{"node", "namespace", "pod", "container"}),
+		containerAcceleratorMemoryTotal: descs.add(
+			"container_accelerator", "memory_total",
 			"Total memory in container's accelerator",
-			[]string{"node", "namespace", "pod", "container", "id", "model", "make"},
-			nil),
-		containerAcceleratorMemoryUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_accelerator", "memory_used"),
+			[]string{"node", "namespace", "pod", "container", "id", "model", "make"}),
+		containerAcceleratorMemoryUsed: descs.add(
+			"container_accelerator", "memory_used",
 			"Memory used in container's accelerator",
-			[]string{"node", "namespace", "pod", "container", "id", "model", "make"},
-			nil),
-		containerAcceleratorDutyCycle: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "container_accelerator", "duty_cycle"),
+			[]string{"node", "namespace", "pod", "container", "id", "model", "make"}),
+		containerAcceleratorDutyCycle: descs.add(
+			"container_accelerator", "duty_cycle",
 			"Percentage of time over which accelerator was allocated",
-			[]string{"node", "namespace", "pod", "container", "id", "model", "make"},
-			nil),
+			[]string{"node", "namespace", "pod", "container", "id", "model", "make"}),
 
-		podCPUUsageNanoCores: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_cpu", "usage_nano_cores"),
+		podCPUUsageNanoCores: descs.add(
+			"pod_cpu", "usage_nano_cores",
 			"CPU usage in nanocores",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podCPUUsageCoreNanoSeconds: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_cpu", "usage_core_nano_seconds"),
+			[]string{"node", "namespace", "pod"}),
+		podCPUUsageCoreNanoSeconds: descs.add(
+			"pod_cpu", "usage_core_nano_seconds",
 			"CPU nanoseconds used",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podMemoryAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_memory", "available_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podMemoryAvailableBytes: descs.add(
+			"pod_memory", "available_bytes",
 			"available bytes in pod memory",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podMemoryUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_memory", "usage_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podMemoryUsageBytes: descs.add(
+			"pod_memory", "usage_bytes",
 			"Used bytes in pod memory",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podMemoryWorkingSetBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_memory", "working_set_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podMemoryWorkingSetBytes: descs.add(
+			"pod_memory", "working_set_bytes",
 			"working set bytes in pod memory",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podMemoryRSSBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_memory", "rss_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podMemoryRSSBytes: descs.add(
+			"pod_memory", "rss_bytes",
 			"rss bytes in pod memory",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podMemoryPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_memory", "page_faults"),
+			[]string{"node", "namespace", "pod"}),
+		podMemoryPageFaults: descs.add(
+			"pod_memory", "page_faults",
 			"Page faults in pod memory",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podMemoryMajorPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_memory", "major_page_faults"),
+			[]string{"node", "namespace", "pod"}),
+		podMemoryMajorPageFaults: descs.add(
+			"pod_memory", "major_page_faults",
 			"Major page faults in pod memory",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podSwapAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_swap", "available_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podSwapAvailableBytes: descs.add(
+			"pod_swap", "available_bytes",
 			"Available bytes in pod's swap storage",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podSwapUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_swap", "usage_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podSwapUsageBytes: descs.add(
+			"pod_swap", "usage_bytes",
 			"Used bytes in pod's swap storage",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podEphemeralStorageUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_ephemeral_storage", "usage_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podEphemeralStorageUsedBytes: descs.add(
+			"pod_ephemeral_storage", "usage_bytes",
 			"Amount of bytes used in pod's ephemeral storage",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podEphemeralStorageAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_ephemeral_storage", "limit_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podEphemeralStorageAvailableBytes: descs.add(
+			"pod_ephemeral_storage", "limit_bytes",
 			"Capacity of pod's ephemeral storage in bytes",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podEphemeralStorageInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_ephemeral_storage", "inodes_free"),
+			[]string{"node", "namespace", "pod"}),
+		podEphemeralStorageInodesFree: descs.add(
+			"pod_ephemeral_storage", "inodes_free",
 			"Number of inodes free in pod's ephemeral storage",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podEphemeralStorageInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_ephemeral_storage", "inodes"),
+			[]string{"node", "namespace", "pod"}),
+		podEphemeralStorageInodes: descs.add(
+			"pod_ephemeral_storage", "inodes",
 			"Number of inodes in pod's ephemeral storage",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podEphemeralStorageInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_ephemeral_storage", "inodes_used"),
+			[]string{"node", "namespace", "pod"}),
+		podEphemeralStorageInodesUsed: descs.add(
+			"pod_ephemeral_storage", "inodes_used",
 			"Number of inodes used in pod's ephemeral storage",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		podVolumeUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_volume", "usage_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podVolumeUsedBytes: descs.add(
+			"pod_volume", "usage_bytes",
 			"Pod volume used in bytes",
-			[]string{"node", "namespace", "pod", "volume_name"},
-			nil),
-		podVolumeAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_volume", "limit_bytes"),
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podVolumeAvailableBytes: descs.add(
+			"pod_volume", "limit_bytes",
 			"Capacity of pod volume in bytes",
-			[]string{"node", "namespace", "pod", "volume_name"},
-			nil),
-		podVolumeInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_volume", "inodes_free"),
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podVolumeInodesFree: descs.add(
+			"pod_volume", "inodes_free",
 			"Number of inodes free in pod volume",
-			[]string{"node", "namespace", "pod", "volume_name"},
-			nil),
-		podVolumeInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_volume", "inodes"),
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podVolumeInodes: descs.add(
+			"pod_volume", "inodes",
 			"Number of inodes in pod volume",
-			[]string{"node", "namespace", "pod", "volume_name"},
-			nil),
-		podVolumeInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_volume", "inodes_used"),
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podVolumeInodesUsed: descs.add(
+			"pod_volume", "inodes_used",
 			"Number of inodes used in pod volume",
-			[]string{"node", "namespace", "pod", "volume_name"},
-			nil),
-		podVolumeHealthStatus: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_volume", "health_status"),
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podVolumeHealthStatus: descs.add(
+			"pod_volume", "health_status",
 			"Health status of pod volume",
-			[]string{"node", "namespace", "pod", "volume_name"},
-			nil),
-		podInterfaceRxBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_interface", "rx_bytes"),
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podVolumeHealthLastTransition: descs.add(
+			"pod_volume", "health_last_transition_timestamp_seconds",
+			"Time of the last transition of the pod volume between normal and abnormal",
+			[]string{"node", "namespace", "pod", "volume_name", "persistentvolumeclaim"}),
+		podInterfaceRxBytes: descs.add(
+			"pod_interface", "rx_bytes",
 			"Cumulative count of receive bytes",
-			[]string{"node", "namespace", "pod", "name"},
-			nil),
-		podInterfaceRxErrors: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_interface", "rx_errors"),
+			[]string{"node", "namespace", "pod", "name"}),
+		podInterfaceRxErrors: descs.add(
+			"pod_interface", "rx_errors",
 			"Cumulative count of receive errors",
-			[]string{"node", "namespace", "pod", "name"},
-			nil),
-		podInterfaceTxBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_interface", "tx_bytes"),
+			[]string{"node", "namespace", "pod", "name"}),
+		podInterfaceTxBytes: descs.add(
+			"pod_interface", "tx_bytes",
 			"Cumulative count of transmit bytes",
-			[]string{"node", "namespace", "pod", "name"},
-			nil),
-		podInterfaceTxErrors: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod_interface", "tx_errors"),
+			[]string{"node", "namespace", "pod", "name"}),
+		podInterfaceTxErrors: descs.add(
+			"pod_interface", "tx_errors",
 			"Cumulative count of transmit errors",
-			[]string{"node", "namespace", "pod", "name"},
-			nil),
-		podProcessCount: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "pod", "process_count"),
+			[]string{"node", "namespace", "pod", "name"}),
+		podProcessCount: descs.add(
+			"pod", "process_count",
 			"Count of process in pod",
-			[]string{"node", "namespace", "pod"},
-			nil),
-		nodeFsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_fs", "usage_bytes"),
+			[]string{"node", "namespace", "pod"}),
+		podStartTime: descs.add(
+			"pod", "start_time_seconds",
+			"Unix time the pod was started at, as seen by kubelet",
+			[]string{"node", "namespace", "pod"}),
+		podContainerCount: descs.add(
+			"pod", "containers",
+			"Count of containers in pod",
+			[]string{"node", "namespace", "pod"}),
+		podVolumeCount: descs.add(
+			"pod", "volumes",
+			"Count of volumes with stats in pod",
+			[]string{"node", "namespace", "pod"}),
+		podInterfaceCount: descs.add(
+			"pod", "interfaces",
+			"Count of network interfaces in pod",
+			[]string{"node", "namespace", "pod"}),
+		podInfo: descs.add(
+			"pod", "info",
+			"Information about the pod, including the workload owning it",
+			[]string{"node", "namespace", "pod", "uid", "owner_kind", "owner_name", "qos_class"}),
+		containerInfo: descs.add(
+			"container", "info",
+			"Information about a container of the pod's spec",
+			[]string{"node", "namespace", "pod", "container", "image"}),
+		containerUserDefined: descs.add(
+			"container", "user_defined",
+			"User defined gauge or delta metric of the container",
+			[]string{"node", "namespace", "pod", "container", "name", "type", "units", "labels"}),
+		containerUserDefinedTotal: descs.addCounter(
+			"container", "user_defined_total",
+			"User defined cumulative metric of the container",
+			[]string{"node", "namespace", "pod", "container", "name", "type", "units", "labels"}),
+		nodeFsUsedBytes: descs.add(
+			"node_fs", "usage_bytes",
 			"Disk used in bytes",
-			[]string{"node"},
-			nil),
-		nodeFsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_fs", "limit_bytes"),
+			[]string{"node"}),
+		nodeFsAvailableBytes: descs.add(
+			"node_fs", "limit_bytes",
 			"Capacity of container disk",
-			[]string{"node"},
-			nil),
-		nodeFsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_fs", "inodes_free"),
+			[]string{"node"}),
+		nodeFsInodesFree: descs.add(
+			"node_fs", "inodes_free",
 			"Number of inodes free in node fs",
-			[]string{"node"},
-			nil),
-		nodeFsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_fs", "inodes"),
+			[]string{"node"}),
+		nodeFsInodes: descs.add(
+			"node_fs", "inodes",
 			"Number of inodes in node fs",
-			[]string{"node"},
-			nil),
-		nodeFsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_fs", "inodes_used"),
+			[]string{"node"}),
+		nodeFsInodesUsed: descs.add(
+			"node_fs", "inodes_used",
 			"Number of inodes used in node fs",
-			[]string{"node"},
-			nil),
-		nodeRuntimeImageFsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_image_fs", "usage_bytes"),
+			[]string{"node"}),
+		nodeRuntimeImageFsUsedBytes: descs.add(
+			"node_runtime_image_fs", "usage_bytes",
 			"Usage of node runtime image fs in bytes",
-			[]string{"node"},
-			nil),
-		nodeRuntimeImageFsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_image_fs", "limit_bytes"),
+			[]string{"node"}),
+		nodeRuntimeImageFsAvailableBytes: descs.add(
+			"node_runtime_image_fs", "limit_bytes",
 			"Capacity of node runtime image fs in bytes",
-			[]string{"node"},
-			nil),
-		nodeRuntimeImageFsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_image_fs", "inodes_free"),
+			[]string{"node"}),
+		nodeRuntimeImageFsInodesFree: descs.add(
+			"node_runtime_image_fs", "inodes_free",
 			"Inodes free in node's runtime image fs",
-			[]string{"node"},
-			nil),
-		nodeRuntimeImageFsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_image_fs", "inodes"),
+			[]string{"node"}),
+		nodeRuntimeImageFsInodes: descs.add(
+			"node_runtime_image_fs", "inodes",
 			"Inodes in node's runtime image fs",
-			[]string{"node"},
-			nil),
-		nodeRuntimeImageFsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_image_fs", "inodes_used"),
+			[]string{"node"}),
+		nodeRuntimeImageFsInodesUsed: descs.add(
+			"node_runtime_image_fs", "inodes_used",
 			"Inodes used in node's runtime image fs",
-			[]string{"node"},
-			nil),
-		nodeRuntimeContainerFsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_container_fs", "usage_bytes"),
+			[]string{"node"}),
+		nodeRuntimeImageFsGCHeadroomBytes: descs.add(
+			"node_runtime_image_fs", "gc_headroom_bytes",
+			"Bytes that can be written to node's runtime image fs before image garbage collection starts",
+			[]string{"node"}),
+		nodeRuntimeImageFsGCImminent: descs.add(
+			"node_runtime_image_fs", "gc_imminent",
+			"Whether node's runtime image fs usage reached the image garbage collection threshold",
+			[]string{"node"}),
+		nodeRuntimeContainerFsUsedBytes: descs.add(
+			"node_runtime_container_fs", "usage_bytes",
 			"Usage of node runtime container's writeable layer in bytes",
-			[]string{"node"},
-			nil),
-		nodeRuntimeContainerFsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_container_fs", "limit_bytes"),
+			[]string{"node"}),
+		nodeRuntimeContainerFsAvailableBytes: descs.add(
+			"node_runtime_container_fs", "limit_bytes",
 			"Capacity of node runtime container's writeable layer in bytes",
-			[]string{"node"},
-			nil),
-		nodeRuntimeContainerFsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_container_fs", "inodes_free"),
+			[]string{"node"}),
+		nodeRuntimeContainerFsInodesFree: descs.add(
+			"node_runtime_container_fs", "inodes_free",
 			"Count of free Inodes in node runtime container fs",
-			[]string{"node"},
-			nil),
-		nodeRuntimeContainerFsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_container_fs", "inodes"),
+			[]string{"node"}),
+		nodeRuntimeContainerFsInodes: descs.add(
+			"node_runtime_container_fs", "inodes",
 			"Total inodes in node's runtime container fs",
-			[]string{"node"},
-			nil),
-		nodeRuntimeContainerFsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_runtime_container_fs", "inodes_used"),
+			[]string{"node"}),
+		nodeRuntimeContainerFsInodesUsed: descs.add(
+			"node_runtime_container_fs", "inodes_used",
 			"Count of inodes being used",
-			[]string{"node"},
-			nil),
-		nodeCPUUsageNanoCores: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_cpu", "usage_nano_cores"),
+			[]string{"node"}),
+		nodeCPUUsageNanoCores: descs.add(
+			"node_cpu", "usage_nano_cores",
 			"CPU usage in nanocores",
-			[]string{"node"},
-			nil),
-		nodeCPUUsageCoreNanoSeconds: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_cpu", "usage_core_nano_seconds"),
+			[]string{"node"}),
+		nodeCPUUsageCoreNanoSeconds: descs.add(
+			"node_cpu", "usage_core_nano_seconds",
 			"CPU nanoseconds used",
-			[]string{"node"},
-			nil),
-		nodeMemoryAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_memory", "available_bytes"),
+			[]string{"node"}),
+		nodeMemoryAvailableBytes: descs.add(
+			"node_memory", "available_bytes",
 			"available bytes in node memory",
-			[]string{"node"},
-			nil),
-		nodeMemoryUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_memory", "usage_bytes"),
+			[]string{"node"}),
+		nodeMemoryUsageBytes: descs.add(
+			"node_memory", "usage_bytes",
 			"Used bytes in node memory",
-			[]string{"node"},
-			nil),
-		nodeMemoryWorkingSetBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_memory", "working_set_bytes"),
+			[]string{"node"}),
+		nodeMemoryWorkingSetBytes: descs.add(
+			"node_memory", "working_set_bytes",
 			"working set bytes in node memory",
-			[]string{"node"},
-			nil),
-		nodeMemoryRSSBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_memory", "rss_bytes"),
+			[]string{"node"}),
+		nodeMemoryRSSBytes: descs.add(
+			"node_memory", "rss_bytes",
 			"rss bytes in node memory",
-			[]string{"node"},
-			nil),
-		nodeMemoryPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_memory", "page_faults"),
+			[]string{"node"}),
+		nodeMemoryPageFaults: descs.add(
+			"node_memory", "page_faults",
 			"Page faults in node memory",
-			[]string{"node"},
-			nil),
-		nodeMemoryMajorPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_memory", "major_page_faults"),
+			[]string{"node"}),
+		nodeMemoryMajorPageFaults: descs.add(
+			"node_memory", "major_page_faults",
 			"Major page faults in node memory",
-			[]string{"node"},
-			nil),
-		nodeSwapAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_swap", "available_bytes"),
+			[]string{"node"}),
+		nodeSwapAvailableBytes: descs.add(
+			"node_swap", "available_bytes",
 			"Available bytes in node's swap storage",
-			[]string{"node"},
-			nil),
-		nodeSwapUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_swap", "usage_bytes"),
+			[]string{"node"}),
+		nodeSwapUsageBytes: descs.add(
+			"node_swap", "usage_bytes",
 			"Used bytes in node's swap storage",
-			[]string{"node"},
-			nil),
-		nodeRLimitMaxPID: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_rlimit", "max_pid"),
+			[]string{"node"}),
+		nodeRLimitMaxPID: descs.add(
+			"node_rlimit", "max_pid",
 			"Maximum PID",
-			[]string{"node"},
-			nil),
-		nodeRLimitNumOfRunningProcess: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_rlimit", "num_of_running_process"),
+			[]string{"node"}),
+		nodeRLimitNumOfRunningProcess: descs.add(
+			"node_rlimit", "num_of_running_process",
 			"Number of running process in node",
-			[]string{"node"},
-			nil),
-		nodeInterfaceRxBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_interface", "rx_bytes"),
+			[]string{"node"}),
+		nodePodsProcessCount: descs.add(
+			"node", "pods_process_count",
+			"Count of process in all pods on the node",
+			[]string{"node"}),
+		nodeHeadroomCPUNanoCores: descs.add(
+			"node_headroom", "cpu_nano_cores",
+			"Allocatable CPU not used by pods in nanocores",
+			[]string{"node", "nodepool"}),
+		nodeHeadroomMemoryBytes: descs.add(
+			"node_headroom", "memory_bytes",
+			"Allocatable memory not in the working set of pods in bytes",
+			[]string{"node", "nodepool"}),
+		nodeMaintenance: descs.add(
+			"node", "maintenance",
+			"Set to 1 while the node is cordoned or being drained",
+			[]string{"node", "nodepool", "reason"}),
+		nodeAllocatableCPUNanoCores: descs.add(
+			"node_allocatable", "cpu_nano_cores",
+			"Allocatable CPU of the node in nanocores",
+			[]string{"node", "nodepool"}),
+		nodeAllocatableMemoryBytes: descs.add(
+			"node_allocatable", "memory_bytes",
+			"Allocatable memory of the node in bytes",
+			[]string{"node", "nodepool"}),
+		nodeAllocatablePods: descs.add(
+			"node_allocatable", "pods",
+			"Allocatable number of pods of the node",
+			[]string{"node", "nodepool"}),
+		nodeAllocatableEphemeralStorageBytes: descs.add(
+			"node_allocatable", "ephemeral_storage_bytes",
+			"Allocatable ephemeral storage of the node in bytes",
+			[]string{"node", "nodepool"}),
+		nodeCapacityCPUNanoCores: descs.add(
+			"node_capacity", "cpu_nano_cores",
+			"Capacity CPU of the node in nanocores",
+			[]string{"node", "nodepool"}),
+		nodeCapacityMemoryBytes: descs.add(
+			"node_capacity", "memory_bytes",
+			"Capacity memory of the node in bytes",
+			[]string{"node", "nodepool"}),
+		nodeCapacityPods: descs.add(
+			"node_capacity", "pods",
+			"Capacity number of pods of the node",
+			[]string{"node", "nodepool"}),
+		nodeCapacityEphemeralStorageBytes: descs.add(
+			"node_capacity", "ephemeral_storage_bytes",
+			"Capacity ephemeral storage of the node in bytes",
+			[]string{"node", "nodepool"}),
+		nodeInterfaceRxBytes: descs.add(
+			"node_interface", "rx_bytes",
 			"Cumulative count of receive bytes",
-			[]string{"node", "name"},
-			nil),
-		nodeInterfaceRxErrors: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_interface", "rx_errors"),
+			[]string{"node", "name"}),
+		nodeInterfaceRxErrors: descs.add(
+			"node_interface", "rx_errors",
 			"Cumulative count of receive errors",
-			[]string{"node", "name"},
-			nil),
-		nodeInterfaceTxBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_interface", "tx_bytes"),
+			[]string{"node", "name"}),
+		nodeInterfaceTxBytes: descs.add(
+			"node_interface", "tx_bytes",
 			"Cumulative count of transmit bytes",
-			[]string{"node", "name"},
-			nil),
-		nodeInterfaceTxErrors: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_interface", "tx_errors"),
+			[]string{"node", "name"}),
+		nodeInterfaceTxErrors: descs.add(
+			"node_interface", "tx_errors",
 			"Cumulative count of transmit errors",
-			[]string{"node", "name"},
-			nil),
-		nodeSystemContainerRootFsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_fs", "usage_bytes"),
+			[]string{"node", "name"}),
+		nodeInterfaceSpeedBytes: descs.add(
+			"node_interface", "speed_bytes",
+			"Link speed of the interface in bytes per second",
+			[]string{"node", "name"}),
+		nodeSystemContainerRootFsUsedBytes: descs.add(
+			"node_system_container_fs", "usage_bytes",
 			"Disk used in bytes",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerRootFsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_fs", "limit_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerRootFsAvailableBytes: descs.add(
+			"node_system_container_fs", "limit_bytes",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerRootFsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_fs", "inodes_free"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerRootFsInodesFree: descs.add(
+			"node_system_container_fs", "inodes_free",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerRootFsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_fs", "inodes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerRootFsInodes: descs.add(
+			"node_system_container_fs", "inodes",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerRootFsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_fs", "inodes_used"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerRootFsInodesUsed: descs.add(
+			"node_system_container_fs", "inodes_used",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerLogsUsedBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_logs", "usage_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerLogsUsedBytes: descs.add(
+			"node_system_container_logs", "usage_bytes",
 			"Disk used in bytes",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerLogsAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_logs", "limit_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerLogsAvailableBytes: descs.add(
+			"node_system_container_logs", "limit_bytes",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerLogsInodesFree: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_logs", "inodes_free"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerLogsInodesFree: descs.add(
+			"node_system_container_logs", "inodes_free",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerLogsInodes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_logs", "inodes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerLogsInodes: descs.add(
+			"node_system_container_logs", "inodes",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerLogsInodesUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_logs", "inodes_used"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerLogsInodesUsed: descs.add(
+			"node_system_container_logs", "inodes_used",
 			"Capacity of nodeSystemContainer disk",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerCPUUsageNanoCores: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_cpu", "usage_nano_cores"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerCPUUsageNanoCores: descs.add(
+			"node_system_container_cpu", "usage_nano_cores",
 			"CPU usage in nanocores",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerCPUUsageCoreNanoSeconds: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_cpu", "usage_core_nano_seconds"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerCPUUsageCoreNanoSeconds: descs.add(
+			"node_system_container_cpu", "usage_core_nano_seconds",
 			"CPU usage in core nanoseconds",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerMemoryAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_memory", "available_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerMemoryAvailableBytes: descs.add(
+			"node_system_container_memory", "available_bytes",
 			"available bytes in nodeSystemContainer memory",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerMemoryUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_memory", "usage_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerMemoryUsageBytes: descs.add(
+			"node_system_container_memory", "usage_bytes",
 			"Used bytes in nodeSystemContainer memory",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerMemoryWorkingSetBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_memory", "working_set_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerMemoryWorkingSetBytes: descs.add(
+			"node_system_container_memory", "working_set_bytes",
 			"working set bytes in nodeSystemContainer memory",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerMemoryRSSBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_memory", "rss_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerMemoryRSSBytes: descs.add(
+			"node_system_container_memory", "rss_bytes",
 			"rss bytes in nodeSystemContainer memory",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerMemoryPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_memory", "page_faults"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerMemoryPageFaults: descs.add(
+			"node_system_container_memory", "page_faults",
 			"Page faults in nodeSystemContainer memory",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerMemoryMajorPageFaults: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_memory", "major_page_faults"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerMemoryMajorPageFaults: descs.add(
+			"node_system_container_memory", "major_page_faults",
 			"Major page faults in nodeSystemContainer memory",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerSwapAvailableBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_swap", "available_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerSwapAvailableBytes: descs.add(
+			"node_system_container_swap", "available_bytes",
 			"Available bytes in nodeSystemContainer's swap storage",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerSwapUsageBytes: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_swap", "usage_bytes"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerSwapUsageBytes: descs.add(
+			"node_system_container_swap", "usage_bytes",
 			"Used bytes in nodeSystemContainer's swap storage",
-			[]string{"node", "container"},
-			nil),
-		nodeSystemContainerAcceleratorMemoryTotal: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_accelerator", "memory_total"),
+			[]string{"node", "container", "original_name"}),
+		nodeSystemContainerAcceleratorMemoryTotal: descs.add(
+			"node_system_container_accelerator", "memory_total",
 			"Total memory in nodeSystemContainer's accelerator",
-			[]string{"node", "container", "id", "model", "make"},
-			nil),
-		nodeSystemContainerAcceleratorMemoryUsed: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_accelerator", "memory_used"),
+			[]string{"node", "container", "original_name", "id", "model", "make"}),
+		nodeSystemContainerAcceleratorMemoryUsed: descs.add(
+			"node_system_container_accelerator", "memory_used",
 			"Memory used in nodeSystemContainer's accelerator",
-			[]string{"node", "container", "id", "model", "make"},
-			nil),
-		nodeSystemContainerAcceleratorDutyCycle: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary", "node_system_container_accelerator", "duty_cycle"),
+			[]string{"node", "container", "original_name", "id", "model", "make"}),
+		nodeSystemContainerAcceleratorDutyCycle: descs.add(
+			"node_system_container_accelerator", "duty_cycle",
 			"Percentage of time over which accelerator was allocated",
-			[]string{"node", "container", "id", "model", "make"},
-			nil),
-		errors: prometheus.NewDesc(
-			prometheus.BuildFQName("kubelet_summary_exporter", "", "errors"),
-			"Errors scraping kubelet stats summary",
-			[]string{"type"},
+			[]string{"node", "container", "original_name", "id", "model", "make"}),
+		nodeAcceleratorMemoryTotal: descs.add(
+			"node_accelerator", "memory_total",
+			"Total memory of the accelerator",
+			[]string{"node", "id", "model", "make"}),
+		nodeAcceleratorMemoryUsed: descs.add(
+			"node_accelerator", "memory_used",
+			"Memory of the accelerator used by all containers",
+			[]string{"node", "id", "model", "make"}),
+		nodeAcceleratorDutyCycle: descs.add(
+			"node_accelerator", "duty_cycle",
+			"Average percentage of time over which the accelerator was allocated, across the containers using it",
+			[]string{"node", "id", "model", "make"}),
+		nodeAcceleratorContainers: descs.add(
+			"node_accelerator", "containers",
+			"Containers using the accelerator",
+			[]string{"node", "id", "model", "make"}),
+		namespaceSampleFactor: descs.add(
+			"namespace", "sample_factor",
+			"Only one in this many pods of the namespace is exported; multiply sums by it to estimate totals",
+			[]string{"node", "namespace"}),
+		namespaceCPUUsageNanoCores: descs.add(
+			"namespace_cpu", "usage_nano_cores",
+			"CPU usage in nanocores of the namespace's pods on the node",
+			[]string{"node", "namespace"}),
+		namespaceMemoryWorkingSetBytes: descs.add(
+			"namespace_memory", "working_set_bytes",
+			"Working set bytes in memory of the namespace's pods on the node",
+			[]string{"node", "namespace"}),
+		staleness: descs.add(
+			"", "staleness_seconds",
+			"Age of the oldest stats of a summary section when scraped, kubelet caches some for up to 15s",
+			[]string{"node", "section"}),
+		errors:      newErrors(),
+		authErrs:    newAuthErrors(),
+		memory:      &memoryTracker{},
+		scrapeStats: newScrapeStats(),
+		scrapeAllocatedBytes: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "scrape", "allocated_bytes"),
+			"Bytes allocated by the exporter during the last scrape",
+			nil,
+			nil),
+		scrapeHeapHighWaterBytes: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "scrape", "heap_high_water_bytes"),
+			"Highest heap size of the exporter seen at the end of a scrape",
+			nil,
+			nil),
+		hedged: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "hedged_requests"),
+			"Stats summary requests hedged because kubelet was slow or failed",
+			nil,
+			nil),
+		collectorEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "collector", "enabled"),
+			"Whether an optional collector is enabled or disabled after exhausting its error budget",
+			[]string{"collector"},
 			nil),
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.scrapeDuration = newScrapeDuration(s.nativeHistogramBucketFactor)
+	s.requestPhases = newRequestPhases(s.nativeHistogramBucketFactor)
+	s.retries = newRetries()
+	if s.httpClient == nil {
+		s.httpClient = s.newClient()
+	}
+	s.renameDescs()
+	s.useCounters()
+	s.overrideDescs()
+	s.disableGroups()
+	s.dropStorageDescs()
+
+	return s
 }
 
 func (s *Scraper) Describe(ch chan<- *prometheus.Desc) {
-	ch <- s.errors
-
-	ch <- s.nodeFsUsedBytes
-	ch <- s.nodeFsAvailableBytes
-	ch <- s.nodeFsInodesFree
-	ch <- s.nodeFsInodes
-	ch <- s.nodeFsInodesUsed
-	ch <- s.nodeRuntimeImageFsUsedBytes
-	ch <- s.nodeRuntimeImageFsAvailableBytes
-	ch <- s.nodeRuntimeImageFsInodesFree
-	ch <- s.nodeRuntimeImageFsInodes
-	ch <- s.nodeRuntimeImageFsInodesUsed
-	ch <- s.nodeRuntimeContainerFsUsedBytes
-	ch <- s.nodeRuntimeContainerFsAvailableBytes
-	ch <- s.nodeRuntimeContainerFsInodesFree
-	ch <- s.nodeRuntimeContainerFsInodes
-	ch <- s.nodeRuntimeContainerFsInodesUsed
-	ch <- s.nodeCPUUsageNanoCores
-	ch <- s.nodeCPUUsageCoreNanoSeconds
-	ch <- s.nodeMemoryAvailableBytes
-	ch <- s.nodeMemoryUsageBytes
-	ch <- s.nodeMemoryWorkingSetBytes
-	ch <- s.nodeMemoryRSSBytes
-	ch <- s.nodeMemoryPageFaults
-	ch <- s.nodeMemoryMajorPageFaults
-	ch <- s.nodeSwapAvailableBytes
-	ch <- s.nodeSwapUsageBytes
-	ch <- s.nodeRLimitMaxPID
-	ch <- s.nodeRLimitNumOfRunningProcess
-	ch <- s.nodeInterfaceRxBytes
-	ch <- s.nodeInterfaceRxErrors
-	ch <- s.nodeInterfaceTxBytes
-	ch <- s.nodeInterfaceTxErrors
-	ch <- s.nodeSystemContainerRootFsUsedBytes
-	ch <- s.nodeSystemContainerRootFsAvailableBytes
-	ch <- s.nodeSystemContainerRootFsInodesFree
-	ch <- s.nodeSystemContainerRootFsInodes
-	ch <- s.nodeSystemContainerRootFsInodesUsed
-	ch <- s.nodeSystemContainerLogsUsedBytes
-	ch <- s.nodeSystemContainerLogsAvailableBytes
-	ch <- s.nodeSystemContainerLogsInodesFree
-	ch <- s.nodeSystemContainerLogsInodes
-	ch <- s.nodeSystemContainerLogsInodesUsed
-	ch <- s.nodeSystemContainerCPUUsageNanoCores
-	ch <- s.nodeSystemContainerCPUUsageCoreNanoSeconds
-	ch <- s.nodeSystemContainerMemoryAvailableBytes
-	ch <- s.nodeSystemContainerMemoryUsageBytes
-	ch <- s.nodeSystemContainerMemoryWorkingSetBytes
-	ch <- s.nodeSystemContainerMemoryRSSBytes
-	ch <- s.nodeSystemContainerMemoryPageFaults
-	ch <- s.nodeSystemContainerMemoryMajorPageFaults
-	ch <- s.nodeSystemContainerSwapAvailableBytes
-	ch <- s.nodeSystemContainerSwapUsageBytes
-	ch <- s.nodeSystemContainerAcceleratorMemoryTotal
-	ch <- s.nodeSystemContainerAcceleratorMemoryUsed
-	ch <- s.nodeSystemContainerAcceleratorDutyCycle
-
-	ch <- s.podCPUUsageNanoCores
-	ch <- s.podCPUUsageCoreNanoSeconds
-	ch <- s.podMemoryAvailableBytes
-	ch <- s.podMemoryUsageBytes
-	ch <- s.podMemoryWorkingSetBytes
-	ch <- s.podMemoryRSSBytes
-	ch <- s.podMemoryPageFaults
-	ch <- s.podMemoryMajorPageFaults
-	ch <- s.podSwapAvailableBytes
-	ch <- s.podSwapUsageBytes
-	ch <- s.podInterfaceRxBytes
-	ch <- s.podInterfaceRxErrors
-	ch <- s.podInterfaceTxBytes
-	ch <- s.podInterfaceTxErrors
-	ch <- s.podEphemeralStorageUsedBytes
-	ch <- s.podEphemeralStorageAvailableBytes
-	ch <- s.podEphemeralStorageInodesFree
-	ch <- s.podEphemeralStorageInodes
-	ch <- s.podEphemeralStorageInodesUsed
-	ch <- s.podVolumeUsedBytes
-	ch <- s.podVolumeAvailableBytes
-	ch <- s.podVolumeInodesFree
-	ch <- s.podVolumeInodes
-	ch <- s.podVolumeInodesUsed
-	ch <- s.podVolumeHealthStatus
-	ch <- s.podProcessCount
-
-	ch <- s.containerRootFsUsedBytes
-	ch <- s.containerRootFsAvailableBytes
-	ch <- s.containerRootFsInodesFree
-	ch <- s.containerRootFsInodes
-	ch <- s.containerRootFsInodesUsed
-	ch <- s.containerLogsUsedBytes
-	ch <- s.containerLogsAvailableBytes
-	ch <- s.containerLogsInodesFree
-	ch <- s.containerLogsInodes
-	ch <- s.containerLogsInodesUsed
-	ch <- s.containerCPUUsageNanoCores
-	ch <- s.containerCPUUsageCoreNanoSeconds
-	ch <- s.containerMemoryAvailableBytes
-	ch <- s.containerMemoryUsageBytes
-	ch <- s.containerMemoryWorkingSetBytes
-	ch <- s.containerMemoryRSSBytes
-	ch <- s.containerMemoryPageFaults
-	ch <- s.containerMemoryMajorPageFaults
-	ch <- s.containerSwapAvailableBytes
-	ch <- s.containerSwapUsageBytes
-	ch <- s.containerAcceleratorMemoryTotal
-	ch <- s.containerAcceleratorMemoryUsed
-	ch <- s.containerAcceleratorDutyCycle
+	s.errors.Describe(ch)
+	s.authErrs.Describe(ch)
+	ch <- s.hedged
+	ch <- s.collectorEnabled
+	ch <- s.scrapeDuration.Desc()
+	s.requestPhases.Describe(ch)
+	s.retries.Describe(ch)
+	s.scrapeStats.describe(ch)
+	ch <- s.scrapeAllocatedBytes
+	ch <- s.scrapeHeapHighWaterBytes
+	if s.freshness != nil {
+		s.freshness.describe(ch)
+	}
+	if s.gaps != nil {
+		ch <- s.gaps.desc
+	}
+	if s.canary != nil {
+		ch <- s.canary.desc
+	}
+
+	for _, desc := range s.descs.descs {
+		ch <- desc
+	}
 }
 
 func (s *Scraper) Collect(ch chan<- prometheus.Metric) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s:10250/stats/summary", s.targetIP), nil)
-	if err != nil {
-		s.logger.Error("failed to create request", zap.Error(err))
-		return
+	_ = s.Scrape(ch)
+}
+
+// Scrape collects the metrics of one scrape of kubelet and returns why
+// kubelet's stats couldn't be scraped, if they couldn't. The error is also
+// counted and logged as on Collect.
+func (s *Scraper) Scrape(ch chan<- prometheus.Metric) error {
+	return s.ScrapeContext(context.Background(), ch)
+}
+
+// ScrapeContext is Scrape with the kubelet request bounded by ctx, e.g. by
+// the scrape timeout of the Prometheus scraping the exporter.
+func (s *Scraper) ScrapeContext(ctx context.Context, ch chan<- prometheus.Metric) (err error) {
+	ch, flush := s.filterDisabled(ch)
+	defer flush()
+
+	start, allocated := time.Now(), s.memory.begin()
+	if s.freshness != nil {
+		s.freshness.advance(start)
+	}
+	if s.gaps != nil {
+		if reason := s.gaps.observe(start.Round(0), start.Sub(processStart)); reason != "" {
+			s.logger.Warn("gap since the last scrape, restarting derived rates", "reason", reason)
+		}
 	}
+	defer func() {
+		s.scrapeDuration.Observe(time.Since(start).Seconds())
+		ch <- s.scrapeDuration
+		s.requestPhases.Collect(ch)
+		s.retries.Collect(ch)
+		s.errors.Collect(ch)
+		s.authErrs.Collect(ch)
+		if !abandoned(ctx, err) {
+			s.scrapeStats.record(start, err)
+		}
+		s.scrapeStats.collect(ch)
+		s.emitMemory(ch, allocated)
+		if s.freshness != nil {
+			s.freshness.collect(ch, time.Now())
+		}
+		if s.gaps != nil {
+			s.gaps.collect(ch)
+		}
+	}()
 
-	token, err := os.ReadFile(s.tokenPath)
+	summary, body, err := s.getSummaryRetrying(ctx)
+	if s.hedgeDelay > 0 {
+		ch <- prometheus.MustNewConstMetric(s.hedged, prometheus.CounterValue, float64(atomic.LoadUint64(&s.hedgedRequests)))
+	}
+	if abandoned(ctx, err) {
+		s.logger.Debug("scrape canceled before kubelet answered", "error", err)
+		return err
+	}
 	if err != nil {
-		s.logger.Fatal("unable to load specified token", zap.String("file", s.tokenPath), zap.Error(err))
+		s.fetchError(err)
+		return err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-	client := &http.Client{
-		Timeout: s.timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, //nolint:gosec //See https://nvbugspro.nvidia.com/bug/4474467
-			},
-		},
+	if s.freshness != nil {
+		s.freshness.succeeded(time.Now())
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		s.errCnt++
-		ch <- prometheus.MustNewConstMetric(
-			s.errors,
-			prometheus.CounterValue,
-			s.errCnt,
-			"request error",
-		)
-		s.logger.Warn("failed to make request to stats/summary", zap.Error(err))
-		return
+	if s.gaps != nil && s.gaps.takeReset() {
+		s.logGrowth.reset()
+		s.cpuRate.reset()
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		s.errCnt++
-		ch <- prometheus.MustNewConstMetric(
-			s.errors,
-			prometheus.CounterValue,
-			s.errCnt,
-			"status error",
-		)
-		s.logger.Warn("got unexpected status for stats/summary", zap.String("status", resp.Status))
-		return
+	if s.canary != nil {
+		s.checkCanary(ch, summary)
 	}
 
-	defer resp.Body.Close()
+	if s.summaryFiller != nil {
+		s.summaryFiller.Fill(ctx, summary)
+	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		s.errCnt++
-		ch <- prometheus.MustNewConstMetric(
-			s.errors,
-			prometheus.CounterValue,
-			s.errCnt,
-			"read body error",
-		)
-		s.logger.Error("failed to read body", zap.Error(err))
-		return
+	s.refreshImageGC()
+	s.emit(ch, summary)
+	s.emitExpressions(ch, summary.Node.NodeName, body)
+	s.emitCollectorStates(ch)
+	return nil
+}
+
+// abandoned reports whether a scrape failed because its context was
+// canceled, e.g. as the exporter shuts down or the client went away, which
+// is no fault of kubelet and isn't counted as an error.
+func abandoned(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.Canceled)
+}
+
+// authError counts and logs a failure to authenticate to kubelet. These are
+// kept apart from kubelet errors because they are fixed through RBAC and token
+// mounts rather than on the node, and the hint says where to look.
+func (s *Scraper) authError(errType, msg, hint string, keysAndValues ...any) {
+	s.authErrs.WithLabelValues(errType).Inc()
+	s.logger.Error(msg, append(keysAndValues, "hint", hint)...)
+}
+
+// newAuthErrors returns the counter of failures to authenticate to kubelet
+// by their type. Every type starts at 0 so it can be alerted on before
+// failing once.
+func newAuthErrors() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kubelet_summary_exporter",
+		Name:      "auth_errors",
+		Help:      "Errors authenticating to kubelet, separate from errors of kubelet itself",
+	}, []string{"type"})
+	for _, errType := range []string{"token empty", "token read error", "unauthorized", "forbidden"} {
+		counter.WithLabelValues(errType)
 	}
 
-	summary, err := s.parse(body)
-	if err != nil {
-		ch <- prometheus.MustNewConstMetric(
-			s.errors,
-			prometheus.CounterValue,
-			s.errCnt,
-			"parse body error",
-		)
-		s.logger.Error("failed to parse body", zap.Error(err))
+	return counter
+}
+
+// newErrors returns the counter of failures to fetch the summary by the
+// stage that failed and, for the status stage, kubelet's status code. Stages
+// without a code start at 0 so they can be alerted on before failing once.
+func newErrors() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kubelet_summary_exporter",
+		Name:      "errors",
+		Help:      "Errors scraping kubelet stats summary by stage and status code",
+	}, []string{"stage", "code"})
+	for _, stage := range []summaryclient.Step{summaryclient.StepRequest, summaryclient.StepRead, summaryclient.StepParse} {
+		counter.WithLabelValues(string(stage), "")
+	}
+
+	return counter
+}
+
+// fetchError records a failure to fetch the summary under the stage that
+// failed.
+func (s *Scraper) fetchError(err error) {
+	var fetchErr *summaryclient.Error
+	if !errors.As(err, &fetchErr) {
+		fetchErr = &summaryclient.Error{Step: summaryclient.StepRequest, Err: err}
+	}
+
+	stage, code := fetchErr.Step, ""
+	switch fetchErr.Step {
+	case summaryclient.StepToken:
+		hint, source := s.tokenHint()
+		if errors.Is(err, summaryclient.ErrEmptyToken) {
+			s.authError("token empty", "specified token is empty", hint, source...)
+		} else {
+			s.authError("token read error", "unable to load specified token", hint, append(source, "error", fetchErr.Err)...)
+		}
 		return
+	case summaryclient.StepStatus:
+		switch fetchErr.StatusCode {
+		case http.StatusUnauthorized:
+			s.authError("unauthorized", "kubelet rejected the token",
+				"check that the token is valid and not expired, and that kubelet has webhook authentication enabled", "status", fetchErr.StatusCode)
+			return
+		case http.StatusForbidden:
+			s.authError("forbidden", "kubelet denied access to stats/summary",
+				"grant the service account get on nodes/stats", "status", fetchErr.StatusCode)
+			return
+		}
+
+		code = strconv.Itoa(fetchErr.StatusCode)
+		s.logger.Warn("got unexpected status for stats/summary", "status", fetchErr.StatusCode,
+			"hint", "check kubelet's logs on the node")
+	case summaryclient.StepRead:
+		if errors.Is(fetchErr.Err, summaryclient.ErrTooLarge) {
+			s.logger.Error("failed to read body", "error", fetchErr.Err, "max_bytes", s.maxSummaryBytes,
+				"hint", "raise --max-summary-bytes if the node runs that many pods")
+		} else {
+			s.logger.Error("failed to read body", "error", fetchErr.Err)
+		}
+	case summaryclient.StepParse:
+		s.logger.Error("failed to parse body", "error", fetchErr.Err)
+	default:
+		stage = summaryclient.StepRequest
+		s.logger.Warn("failed to make request to stats/summary", "error", fetchErr.Err,
+			"hint", "check that kubelet is running and reachable from the exporter at "+s.kubeletHost())
 	}
+
+	s.errors.WithLabelValues(string(stage), code).Inc()
+}
+
+// emit converts a parsed summary into metrics.
+func (s *Scraper) emit(ch chan<- prometheus.Metric, summary *statsapi.Summary) {
+	sortSummary(summary)
+
 	node := summary.Node
 	nodeName := node.NodeName
 	for _, nodeSystemContainer := range node.SystemContainers {
+		name := canonicalSystemContainer(nodeSystemContainer.Name)
 		if nodeSystemContainer.Rootfs != nil {
-			s.pushMetrics(ch, s.nodeSystemContainerRootFsUsedBytes, nodeSystemContainer.Rootfs.UsedBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerRootFsAvailableBytes, nodeSystemContainer.Rootfs.CapacityBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerRootFsInodes, nodeSystemContainer.Rootfs.Inodes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerRootFsInodesFree, nodeSystemContainer.Rootfs.InodesFree, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerRootFsInodesUsed, nodeSystemContainer.Rootfs.InodesUsed, nodeName, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Rootfs.Time, s.nodeSystemContainerRootFsUsedBytes, nodeSystemContainer.Rootfs.UsedBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Rootfs.Time, s.nodeSystemContainerRootFsAvailableBytes, nodeSystemContainer.Rootfs.CapacityBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Rootfs.Time, s.nodeSystemContainerRootFsInodes, nodeSystemContainer.Rootfs.Inodes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Rootfs.Time, s.nodeSystemContainerRootFsInodesFree, nodeSystemContainer.Rootfs.InodesFree, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Rootfs.Time, s.nodeSystemContainerRootFsInodesUsed, nodeSystemContainer.Rootfs.InodesUsed, nodeName, name, nodeSystemContainer.Name)
 		}
 
 		if nodeSystemContainer.Logs != nil {
-			s.pushMetrics(ch, s.nodeSystemContainerLogsUsedBytes, nodeSystemContainer.Logs.UsedBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerLogsAvailableBytes, nodeSystemContainer.Logs.CapacityBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerLogsInodes, nodeSystemContainer.Logs.Inodes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerLogsInodesFree, nodeSystemContainer.Logs.InodesFree, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerLogsInodesUsed, nodeSystemContainer.Logs.InodesUsed, nodeName, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Logs.Time, s.nodeSystemContainerLogsUsedBytes, nodeSystemContainer.Logs.UsedBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Logs.Time, s.nodeSystemContainerLogsAvailableBytes, nodeSystemContainer.Logs.CapacityBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Logs.Time, s.nodeSystemContainerLogsInodes, nodeSystemContainer.Logs.Inodes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Logs.Time, s.nodeSystemContainerLogsInodesFree, nodeSystemContainer.Logs.InodesFree, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Logs.Time, s.nodeSystemContainerLogsInodesUsed, nodeSystemContainer.Logs.InodesUsed, nodeName, name, nodeSystemContainer.Name)
 		}
 
 		if nodeSystemContainer.CPU != nil {
-			s.pushMetrics(ch, s.nodeSystemContainerCPUUsageNanoCores, nodeSystemContainer.CPU.UsageNanoCores, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerCPUUsageCoreNanoSeconds, nodeSystemContainer.CPU.UsageCoreNanoSeconds, nodeName, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.CPU.Time, s.nodeSystemContainerCPUUsageNanoCores, nodeSystemContainer.CPU.UsageNanoCores, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.CPU.Time, s.nodeSystemContainerCPUUsageCoreNanoSeconds, nodeSystemContainer.CPU.UsageCoreNanoSeconds, nodeName, name, nodeSystemContainer.Name)
 		}
 
 		if nodeSystemContainer.Memory != nil {
-			fmt.Println(nodeSystemContainer.Memory, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerMemoryAvailableBytes, nodeSystemContainer.Memory.AvailableBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerMemoryUsageBytes, nodeSystemContainer.Memory.UsageBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerMemoryWorkingSetBytes, nodeSystemContainer.Memory.WorkingSetBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerMemoryRSSBytes, nodeSystemContainer.Memory.RSSBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerMemoryPageFaults, nodeSystemContainer.Memory.PageFaults, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerMemoryMajorPageFaults, nodeSystemContainer.Memory.MajorPageFaults, nodeName, nodeSystemContainer.Name)
+			s.logger.Debug("system container memory", "container", nodeSystemContainer.Name, "memory", nodeSystemContainer.Memory)
+			s.pushMetricsAt(ch, nodeSystemContainer.Memory.Time, s.nodeSystemContainerMemoryAvailableBytes, nodeSystemContainer.Memory.AvailableBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Memory.Time, s.nodeSystemContainerMemoryUsageBytes, nodeSystemContainer.Memory.UsageBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Memory.Time, s.nodeSystemContainerMemoryWorkingSetBytes, nodeSystemContainer.Memory.WorkingSetBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Memory.Time, s.nodeSystemContainerMemoryRSSBytes, nodeSystemContainer.Memory.RSSBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Memory.Time, s.nodeSystemContainerMemoryPageFaults, nodeSystemContainer.Memory.PageFaults, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Memory.Time, s.nodeSystemContainerMemoryMajorPageFaults, nodeSystemContainer.Memory.MajorPageFaults, nodeName, name, nodeSystemContainer.Name)
 		}
 
 		if nodeSystemContainer.Swap != nil {
-			s.pushMetrics(ch, s.nodeSystemContainerSwapAvailableBytes, nodeSystemContainer.Swap.SwapAvailableBytes, nodeName, nodeSystemContainer.Name)
-			s.pushMetrics(ch, s.nodeSystemContainerSwapUsageBytes, nodeSystemContainer.Swap.SwapUsageBytes, nodeName, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Swap.Time, s.nodeSystemContainerSwapAvailableBytes, nodeSystemContainer.Swap.SwapAvailableBytes, nodeName, name, nodeSystemContainer.Name)
+			s.pushMetricsAt(ch, nodeSystemContainer.Swap.Time, s.nodeSystemContainerSwapUsageBytes, nodeSystemContainer.Swap.SwapUsageBytes, nodeName, name, nodeSystemContainer.Name)
 		}
 
 		for _, accelerator := range nodeSystemContainer.Accelerators {
-			s.pushMetrics(ch, s.nodeSystemContainerAcceleratorMemoryUsed, &accelerator.MemoryUsed, nodeName, nodeSystemContainer.Name, accelerator.ID, accelerator.Model, accelerator.Make)
-			s.pushMetrics(ch, s.nodeSystemContainerAcceleratorMemoryTotal, &accelerator.MemoryTotal, nodeName, nodeSystemContainer.Name, accelerator.ID, accelerator.Model, accelerator.Make)
-			s.pushMetrics(ch, s.nodeSystemContainerAcceleratorDutyCycle, &accelerator.DutyCycle, nodeName, nodeSystemContainer.Name, accelerator.ID, accelerator.Model, accelerator.Make)
+			s.pushMetrics(ch, s.nodeSystemContainerAcceleratorMemoryUsed, &accelerator.MemoryUsed, nodeName, name, nodeSystemContainer.Name, accelerator.ID, accelerator.Model, accelerator.Make)
+			s.pushMetrics(ch, s.nodeSystemContainerAcceleratorMemoryTotal, &accelerator.MemoryTotal, nodeName, name, nodeSystemContainer.Name, accelerator.ID, accelerator.Model, accelerator.Make)
+			s.pushMetrics(ch, s.nodeSystemContainerAcceleratorDutyCycle, &accelerator.DutyCycle, nodeName, name, nodeSystemContainer.Name, accelerator.ID, accelerator.Model, accelerator.Make)
 		}
 	}
 
 	nodeFs := node.Fs
 	if nodeFs != nil {
-		s.pushMetrics(ch, s.nodeFsUsedBytes, nodeFs.UsedBytes, nodeName)
-		s.pushMetrics(ch, s.nodeFsAvailableBytes, nodeFs.CapacityBytes, nodeName)
-		s.pushMetrics(ch, s.nodeFsInodes, nodeFs.Inodes, nodeName)
-		s.pushMetrics(ch, s.nodeFsInodesFree, nodeFs.InodesFree, nodeName)
-		s.pushMetrics(ch, s.nodeFsInodesUsed, nodeFs.InodesUsed, nodeName)
+		s.pushMetricsAt(ch, nodeFs.Time, s.nodeFsUsedBytes, nodeFs.UsedBytes, nodeName)
+		s.pushMetricsAt(ch, nodeFs.Time, s.nodeFsAvailableBytes, nodeFs.CapacityBytes, nodeName)
+		s.pushMetricsAt(ch, nodeFs.Time, s.nodeFsInodes, nodeFs.Inodes, nodeName)
+		s.pushMetricsAt(ch, nodeFs.Time, s.nodeFsInodesFree, nodeFs.InodesFree, nodeName)
+		s.pushMetricsAt(ch, nodeFs.Time, s.nodeFsInodesUsed, nodeFs.InodesUsed, nodeName)
 	}
 
 	nodeRuntimeImageFs := node.Runtime.ImageFs
 	if nodeRuntimeImageFs != nil {
-		s.pushMetrics(ch, s.nodeRuntimeImageFsUsedBytes, nodeRuntimeImageFs.UsedBytes, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeImageFsAvailableBytes, nodeRuntimeImageFs.CapacityBytes, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeImageFsInodes, nodeRuntimeImageFs.Inodes, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeImageFsInodesFree, nodeRuntimeImageFs.InodesFree, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeImageFsInodesUsed, nodeRuntimeImageFs.InodesUsed, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeImageFs.Time, s.nodeRuntimeImageFsUsedBytes, nodeRuntimeImageFs.UsedBytes, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeImageFs.Time, s.nodeRuntimeImageFsAvailableBytes, nodeRuntimeImageFs.CapacityBytes, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeImageFs.Time, s.nodeRuntimeImageFsInodes, nodeRuntimeImageFs.Inodes, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeImageFs.Time, s.nodeRuntimeImageFsInodesFree, nodeRuntimeImageFs.InodesFree, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeImageFs.Time, s.nodeRuntimeImageFsInodesUsed, nodeRuntimeImageFs.InodesUsed, nodeName)
+		s.emitImageGC(ch, nodeName, nodeRuntimeImageFs)
 	}
 
 	nodeRuntimeContainerFs := node.Runtime.ContainerFs
 	if nodeRuntimeContainerFs != nil {
-		s.pushMetrics(ch, s.nodeRuntimeContainerFsUsedBytes, nodeRuntimeContainerFs.UsedBytes, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeContainerFsAvailableBytes, nodeRuntimeContainerFs.CapacityBytes, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeContainerFsInodes, nodeRuntimeContainerFs.Inodes, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeContainerFsInodesFree, nodeRuntimeContainerFs.InodesFree, nodeName)
-		s.pushMetrics(ch, s.nodeRuntimeContainerFsInodesUsed, nodeRuntimeContainerFs.InodesUsed, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeContainerFs.Time, s.nodeRuntimeContainerFsUsedBytes, nodeRuntimeContainerFs.UsedBytes, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeContainerFs.Time, s.nodeRuntimeContainerFsAvailableBytes, nodeRuntimeContainerFs.CapacityBytes, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeContainerFs.Time, s.nodeRuntimeContainerFsInodes, nodeRuntimeContainerFs.Inodes, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeContainerFs.Time, s.nodeRuntimeContainerFsInodesFree, nodeRuntimeContainerFs.InodesFree, nodeName)
+		s.pushMetricsAt(ch, nodeRuntimeContainerFs.Time, s.nodeRuntimeContainerFsInodesUsed, nodeRuntimeContainerFs.InodesUsed, nodeName)
 	}
 
 	if node.CPU != nil {
-		s.pushMetrics(ch, s.nodeCPUUsageNanoCores, node.CPU.UsageNanoCores, nodeName)
-		s.pushMetrics(ch, s.nodeCPUUsageCoreNanoSeconds, node.CPU.UsageCoreNanoSeconds, nodeName)
+		s.pushMetricsAt(ch, node.CPU.Time, s.nodeCPUUsageNanoCores, node.CPU.UsageNanoCores, nodeName)
+		s.pushMetricsAt(ch, node.CPU.Time, s.nodeCPUUsageCoreNanoSeconds, node.CPU.UsageCoreNanoSeconds, nodeName)
 	}
 
 	if node.Memory != nil {
-		s.pushMetrics(ch, s.nodeMemoryAvailableBytes, node.Memory.AvailableBytes, nodeName)
-		s.pushMetrics(ch, s.nodeMemoryUsageBytes, node.Memory.UsageBytes, nodeName)
-		s.pushMetrics(ch, s.nodeMemoryWorkingSetBytes, node.Memory.WorkingSetBytes, nodeName)
-		s.pushMetrics(ch, s.nodeMemoryRSSBytes, node.Memory.RSSBytes, nodeName)
-		s.pushMetrics(ch, s.nodeMemoryPageFaults, node.Memory.PageFaults, nodeName)
+		s.pushMetricsAt(ch, node.Memory.Time, s.nodeMemoryAvailableBytes, node.Memory.AvailableBytes, nodeName)
+		s.pushMetricsAt(ch, node.Memory.Time, s.nodeMemoryUsageBytes, node.Memory.UsageBytes, nodeName)
+		s.pushMetricsAt(ch, node.Memory.Time, s.nodeMemoryWorkingSetBytes, node.Memory.WorkingSetBytes, nodeName)
+		s.pushMetricsAt(ch, node.Memory.Time, s.nodeMemoryRSSBytes, node.Memory.RSSBytes, nodeName)
+		s.pushMetricsAt(ch, node.Memory.Time, s.nodeMemoryPageFaults, node.Memory.PageFaults, nodeName)
 	}
 
 	if node.Swap != nil {
-		s.pushMetrics(ch, s.nodeSwapAvailableBytes, node.Swap.SwapAvailableBytes, nodeName)
-		s.pushMetrics(ch, s.nodeSwapUsageBytes, node.Swap.SwapUsageBytes, nodeName)
+		s.pushMetricsAt(ch, node.Swap.Time, s.nodeSwapAvailableBytes, node.Swap.SwapAvailableBytes, nodeName)
+		s.pushMetricsAt(ch, node.Swap.Time, s.nodeSwapUsageBytes, node.Swap.SwapUsageBytes, nodeName)
 	}
 
 	if node.Rlimit != nil {
 		if node.Rlimit.MaxPID != nil {
-			ch <- prometheus.MustNewConstMetric(
+			ch <- s.timestamped(node.Rlimit.Time, prometheus.MustNewConstMetric(
 				s.nodeRLimitMaxPID,
 				prometheus.GaugeValue,
 				float64(*node.Rlimit.MaxPID),
 				nodeName,
-			)
+			))
 		}
 		if node.Rlimit.NumOfRunningProcesses != nil {
-			ch <- prometheus.MustNewConstMetric(
+			ch <- s.timestamped(node.Rlimit.Time, prometheus.MustNewConstMetric(
 				s.nodeRLimitNumOfRunningProcess,
 				prometheus.GaugeValue,
 				float64(*node.Rlimit.NumOfRunningProcesses),
 				nodeName,
-			)
+			))
 		}
 	}
 
 	if node.Network != nil {
 		for _, interfaceStats := range node.Network.Interfaces {
 			interfaceName := interfaceStats.Name
-			s.pushMetrics(ch, s.nodeInterfaceRxBytes, interfaceStats.RxBytes, nodeName, interfaceName)
-			s.pushMetrics(ch, s.nodeInterfaceRxErrors, interfaceStats.RxErrors, nodeName, interfaceName)
-			s.pushMetrics(ch, s.nodeInterfaceTxBytes, interfaceStats.TxBytes, nodeName, interfaceName)
-			s.pushMetrics(ch, s.nodeInterfaceTxErrors, interfaceStats.TxErrors, nodeName, interfaceName)
+			if !s.interfaceFilter.keep(interfaceName) {
+				continue
+			}
+			s.pushMetricsAt(ch, node.Network.Time, s.nodeInterfaceRxBytes, interfaceStats.RxBytes, nodeName, interfaceName)
+			s.pushMetricsAt(ch, node.Network.Time, s.nodeInterfaceRxErrors, interfaceStats.RxErrors, nodeName, interfaceName)
+			s.pushMetricsAt(ch, node.Network.Time, s.nodeInterfaceTxBytes, interfaceStats.TxBytes, nodeName, interfaceName)
+			s.pushMetricsAt(ch, node.Network.Time, s.nodeInterfaceTxErrors, interfaceStats.TxErrors, nodeName, interfaceName)
+			s.pushMetrics(ch, s.nodeInterfaceSpeedBytes, s.interfaceSpeedBytes(interfaceName), nodeName, interfaceName)
 		}
 	}
 
-	for _, pod := range summary.Pods {
+	now := time.Now()
+	suppressPods := s.emitMaintenance(ch, nodeName)
+
+	// Processes are summed over all pods, so together with the node's running
+	// processes the remainder held by kubelet, runtime and other system daemons
+	// can be derived.
+	var podsProcessCount *uint64
+	sampledNamespaces := map[string]struct{}{}
+	var pods, aggregated []*statsapi.PodStats
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
 		podName := pod.PodRef.Name
 		namespace := pod.PodRef.Namespace
-		if pod.CPU != nil {
-			s.pushMetrics(ch, s.podCPUUsageNanoCores, pod.CPU.UsageNanoCores, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podCPUUsageCoreNanoSeconds, pod.CPU.UsageCoreNanoSeconds, nodeName, namespace, podName)
+		if pod.ProcessStats != nil && pod.ProcessStats.ProcessCount != nil {
+			if podsProcessCount == nil {
+				podsProcessCount = new(uint64)
+			}
+			*podsProcessCount += *pod.ProcessStats.ProcessCount
 		}
 
-		if pod.Memory != nil {
-			s.pushMetrics(ch, s.podMemoryAvailableBytes, pod.Memory.AvailableBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podMemoryUsageBytes, pod.Memory.UsageBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podMemoryWorkingSetBytes, pod.Memory.WorkingSetBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podMemoryRSSBytes, pod.Memory.RSSBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podMemoryPageFaults, pod.Memory.PageFaults, nodeName, namespace, podName)
+		if suppressPods || !s.podFilter.keep(namespace, podName) || !s.dynamic.keep(namespace, podName) {
+			continue
 		}
+		aggregated = append(aggregated, pod)
 
-		if pod.Swap != nil {
-			s.pushMetrics(ch, s.podSwapAvailableBytes, pod.Swap.SwapAvailableBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podSwapUsageBytes, pod.Swap.SwapUsageBytes, nodeName, namespace, podName)
+		if s.sampler.sampled(namespace) {
+			sampledNamespaces[namespace] = struct{}{}
+			if !s.sampler.keep(namespace, podName) {
+				continue
+			}
 		}
 
-		if pod.EphemeralStorage != nil {
-			s.pushMetrics(ch, s.podEphemeralStorageUsedBytes, pod.EphemeralStorage.UsedBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podEphemeralStorageAvailableBytes, pod.EphemeralStorage.CapacityBytes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podEphemeralStorageInodes, pod.EphemeralStorage.Inodes, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podEphemeralStorageInodesFree, pod.EphemeralStorage.InodesFree, nodeName, namespace, podName)
-			s.pushMetrics(ch, s.podEphemeralStorageInodesUsed, pod.EphemeralStorage.InodesUsed, nodeName, namespace, podName)
-		}
+		pods = append(pods, pod)
+	}
+	volumesHealth, logsUsage := s.emitPods(ch, nodeName, pods, now)
 
-		if pod.ProcessStats != nil {
-			s.pushMetrics(ch, s.podProcessCount, pod.ProcessStats.ProcessCount, nodeName, namespace, podName)
+	s.history.prune(now)
+	s.pushMetrics(ch, s.nodePodsProcessCount, podsProcessCount, nodeName)
+
+	rates := s.logGrowth.update(logsUsage)
+	for _, key := range sortedContainerKeys(rates) {
+		ch <- prometheus.MustNewConstMetric(
+			s.containerLogsGrowthBytes,
+			prometheus.GaugeValue,
+			rates[key],
+			nodeName, key.namespace, key.pod, key.container,
+		)
+	}
+
+	cpuRates := s.cpuRate.update(missingNanoCores(pods))
+	for _, key := range sortedContainerKeys(cpuRates) {
+		ch <- prometheus.MustNewConstMetric(
+			s.containerCPUDerivedNanoCores,
+			prometheus.GaugeValue,
+			cpuRates[key],
+			nodeName, key.namespace, key.pod, key.container,
+		)
+	}
+
+	transitions := s.volumeHealth.update(volumesHealth, now)
+	for _, key := range sortedVolumeKeys(transitions) {
+		ch <- prometheus.MustNewConstMetric(
+			s.podVolumeHealthLastTransition,
+			prometheus.GaugeValue,
+			float64(transitions[key].UnixNano())/1e9,
+			nodeName, key.namespace, key.pod, key.volume, key.claim,
+		)
+	}
+
+	s.emitNamespaceAggregates(ch, nodeName, aggregated)
+	s.emitHeadroom(ch, summary)
+	s.emitNodeResources(ch, nodeName)
+	s.emitNodeAccelerators(ch, summary)
+	s.emitStaleness(ch, summary, now)
+
+	for _, namespace := range sortedKeys(sampledNamespaces) {
+		every := uint64(s.sampler.every)
+		s.pushMetrics(ch, s.namespaceSampleFactor, &every, nodeName, namespace)
+	}
+}
+
+// emitPod emits the metrics of pod, recording the health of its volumes and
+// the log usage of its containers in volumesHealth and logsUsage.
+func (s *Scraper) emitPod(ch chan<- prometheus.Metric, nodeName string, pod *statsapi.PodStats, podSpec *v1.Pod, now time.Time, volumesHealth map[volumeKey]bool, logsUsage map[containerKey]cumulativeValue) {
+	podName := pod.PodRef.Name
+	namespace := pod.PodRef.Namespace
+
+	if pod.CPU != nil {
+		s.pushMetricsAt(ch, pod.CPU.Time, s.podCPUUsageNanoCores, pod.CPU.UsageNanoCores, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.CPU.Time, s.podCPUUsageCoreNanoSeconds, pod.CPU.UsageCoreNanoSeconds, nodeName, namespace, podName)
+	}
+
+	if pod.Memory != nil {
+		s.pushMetricsAt(ch, pod.Memory.Time, s.podMemoryAvailableBytes, pod.Memory.AvailableBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.Memory.Time, s.podMemoryUsageBytes, pod.Memory.UsageBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.Memory.Time, s.podMemoryWorkingSetBytes, pod.Memory.WorkingSetBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.Memory.Time, s.podMemoryRSSBytes, pod.Memory.RSSBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.Memory.Time, s.podMemoryPageFaults, pod.Memory.PageFaults, nodeName, namespace, podName)
+	}
+
+	if pod.Swap != nil {
+		s.pushMetricsAt(ch, pod.Swap.Time, s.podSwapAvailableBytes, pod.Swap.SwapAvailableBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.Swap.Time, s.podSwapUsageBytes, pod.Swap.SwapUsageBytes, nodeName, namespace, podName)
+	}
+
+	if pod.EphemeralStorage != nil {
+		s.pushMetricsAt(ch, pod.EphemeralStorage.Time, s.podEphemeralStorageUsedBytes, pod.EphemeralStorage.UsedBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.EphemeralStorage.Time, s.podEphemeralStorageAvailableBytes, pod.EphemeralStorage.CapacityBytes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.EphemeralStorage.Time, s.podEphemeralStorageInodes, pod.EphemeralStorage.Inodes, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.EphemeralStorage.Time, s.podEphemeralStorageInodesFree, pod.EphemeralStorage.InodesFree, nodeName, namespace, podName)
+		s.pushMetricsAt(ch, pod.EphemeralStorage.Time, s.podEphemeralStorageInodesUsed, pod.EphemeralStorage.InodesUsed, nodeName, namespace, podName)
+	}
+
+	if pod.ProcessStats != nil {
+		s.pushMetrics(ch, s.podProcessCount, pod.ProcessStats.ProcessCount, nodeName, namespace, podName)
+	}
+
+	s.pushTime(ch, s.podStartTime, pod.StartTime, nodeName, namespace, podName)
+	s.emitPodCounts(ch, nodeName, *pod)
+	s.emitPodInfo(ch, nodeName, podSpec)
+
+	for _, podVolume := range pod.VolumeStats {
+		// PVCs are always in their pod's namespace, so only their name is
+		// exported, matching kube-state-metrics' persistentvolumeclaim label.
+		claim := ""
+		if podVolume.PVCRef != nil {
+			claim = podVolume.PVCRef.Name
 		}
 
-		for _, podVolume := range pod.VolumeStats {
-			s.pushMetrics(ch, s.podVolumeUsedBytes, podVolume.FsStats.UsedBytes, nodeName, namespace, podName, podVolume.Name)
-			s.pushMetrics(ch, s.podVolumeAvailableBytes, podVolume.FsStats.CapacityBytes, nodeName, namespace, podName, podVolume.Name)
-			s.pushMetrics(ch, s.podVolumeInodes, podVolume.FsStats.Inodes, nodeName, namespace, podName, podVolume.Name)
-			s.pushMetrics(ch, s.podVolumeInodesFree, podVolume.FsStats.InodesFree, nodeName, namespace, podName, podVolume.Name)
-			s.pushMetrics(ch, s.podVolumeInodesUsed, podVolume.FsStats.InodesUsed, nodeName, namespace, podName, podVolume.Name)
-
-			if podVolume.VolumeHealthStats != nil {
-				var podVolumeHealthStatus uint64 = 0
-				if podVolume.VolumeHealthStats.Abnormal {
-					podVolumeHealthStatus = 1
-				}
-				s.pushMetrics(ch, s.podVolumeHealthStatus, &podVolumeHealthStatus, nodeName, namespace, podName, podVolume.Name)
+		s.pushMetricsAt(ch, podVolume.FsStats.Time, s.podVolumeUsedBytes, podVolume.FsStats.UsedBytes, nodeName, namespace, podName, podVolume.Name, claim)
+		s.pushMetricsAt(ch, podVolume.FsStats.Time, s.podVolumeAvailableBytes, podVolume.FsStats.CapacityBytes, nodeName, namespace, podName, podVolume.Name, claim)
+		s.pushMetricsAt(ch, podVolume.FsStats.Time, s.podVolumeInodes, podVolume.FsStats.Inodes, nodeName, namespace, podName, podVolume.Name, claim)
+		s.pushMetricsAt(ch, podVolume.FsStats.Time, s.podVolumeInodesFree, podVolume.FsStats.InodesFree, nodeName, namespace, podName, podVolume.Name, claim)
+		s.pushMetricsAt(ch, podVolume.FsStats.Time, s.podVolumeInodesUsed, podVolume.FsStats.InodesUsed, nodeName, namespace, podName, podVolume.Name, claim)
+
+		if podVolume.VolumeHealthStats != nil {
+			var podVolumeHealthStatus uint64 = 0
+			if podVolume.VolumeHealthStats.Abnormal {
+				podVolumeHealthStatus = 1
 			}
+			s.pushMetrics(ch, s.podVolumeHealthStatus, &podVolumeHealthStatus, nodeName, namespace, podName, podVolume.Name, claim)
+			volumesHealth[volumeKey{namespace: namespace, pod: podName, volume: podVolume.Name, claim: claim}] = podVolume.VolumeHealthStats.Abnormal
 		}
+	}
 
-		if pod.Network != nil {
-			for _, interfaceStats := range pod.Network.Interfaces {
-				interfaceName := interfaceStats.Name
-				s.pushMetrics(ch, s.podInterfaceRxBytes, interfaceStats.RxBytes, nodeName, namespace, podName, interfaceName)
-				s.pushMetrics(ch, s.podInterfaceRxErrors, interfaceStats.RxErrors, nodeName, namespace, podName, interfaceName)
-				s.pushMetrics(ch, s.podInterfaceTxBytes, interfaceStats.TxBytes, nodeName, namespace, podName, interfaceName)
-				s.pushMetrics(ch, s.podInterfaceTxErrors, interfaceStats.TxErrors, nodeName, namespace, podName, interfaceName)
+	if pod.Network != nil {
+		for _, interfaceStats := range pod.Network.Interfaces {
+			interfaceName := interfaceStats.Name
+			if !s.interfaceFilter.keep(interfaceName) {
+				continue
 			}
+			s.pushMetricsAt(ch, pod.Network.Time, s.podInterfaceRxBytes, interfaceStats.RxBytes, nodeName, namespace, podName, interfaceName)
+			s.pushMetricsAt(ch, pod.Network.Time, s.podInterfaceRxErrors, interfaceStats.RxErrors, nodeName, namespace, podName, interfaceName)
+			s.pushMetricsAt(ch, pod.Network.Time, s.podInterfaceTxBytes, interfaceStats.TxBytes, nodeName, namespace, podName, interfaceName)
+			s.pushMetricsAt(ch, pod.Network.Time, s.podInterfaceTxErrors, interfaceStats.TxErrors, nodeName, namespace, podName, interfaceName)
 		}
-		for _, container := range pod.Containers {
-			if container.Rootfs != nil {
-				s.pushMetrics(ch, s.containerRootFsUsedBytes, container.Rootfs.UsedBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerRootFsAvailableBytes, container.Rootfs.CapacityBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerRootFsInodes, container.Rootfs.Inodes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerRootFsInodesFree, container.Rootfs.InodesFree, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerRootFsInodesUsed, container.Rootfs.InodesUsed, nodeName, namespace, podName, container.Name)
-			}
+	}
+	for _, container := range pod.Containers {
+		s.pushTime(ch, s.containerStartTime, container.StartTime, nodeName, namespace, podName, container.Name)
 
-			if container.Logs != nil {
-				s.pushMetrics(ch, s.containerLogsUsedBytes, container.Logs.UsedBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerLogsAvailableBytes, container.Logs.CapacityBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerLogsInodes, container.Logs.Inodes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerLogsInodesFree, container.Logs.InodesFree, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerLogsInodesUsed, container.Logs.InodesUsed, nodeName, namespace, podName, container.Name)
-			}
+		if container.Rootfs != nil {
+			s.pushMetricsAt(ch, container.Rootfs.Time, s.containerRootFsUsedBytes, container.Rootfs.UsedBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Rootfs.Time, s.containerRootFsAvailableBytes, container.Rootfs.CapacityBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Rootfs.Time, s.containerRootFsInodes, container.Rootfs.Inodes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Rootfs.Time, s.containerRootFsInodesFree, container.Rootfs.InodesFree, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Rootfs.Time, s.containerRootFsInodesUsed, container.Rootfs.InodesUsed, nodeName, namespace, podName, container.Name)
+		}
 
-			if container.CPU != nil {
-				s.pushMetrics(ch, s.containerCPUUsageNanoCores, container.CPU.UsageNanoCores, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerCPUUsageCoreNanoSeconds, container.CPU.UsageCoreNanoSeconds, nodeName, namespace, podName, container.Name)
-			}
+		if container.Logs != nil {
+			s.pushMetricsAt(ch, container.Logs.Time, s.containerLogsUsedBytes, container.Logs.UsedBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Logs.Time, s.containerLogsAvailableBytes, container.Logs.CapacityBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Logs.Time, s.containerLogsInodes, container.Logs.Inodes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Logs.Time, s.containerLogsInodesFree, container.Logs.InodesFree, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Logs.Time, s.containerLogsInodesUsed, container.Logs.InodesUsed, nodeName, namespace, podName, container.Name)
 
-			if container.Memory != nil {
-				s.pushMetrics(ch, s.containerMemoryAvailableBytes, container.Memory.AvailableBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerMemoryUsageBytes, container.Memory.UsageBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerMemoryWorkingSetBytes, container.Memory.WorkingSetBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerMemoryRSSBytes, container.Memory.RSSBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerMemoryPageFaults, container.Memory.PageFaults, nodeName, namespace, podName, container.Name)
+			if container.Logs.UsedBytes != nil {
+				logsUsage[containerKey{namespace: namespace, pod: podName, container: container.Name}] = cumulativeValue{
+					value: *container.Logs.UsedBytes,
+					time:  container.Logs.Time.Time,
+				}
 			}
+		}
 
-			if container.Swap != nil {
-				s.pushMetrics(ch, s.containerSwapAvailableBytes, container.Swap.SwapAvailableBytes, nodeName, namespace, podName, container.Name)
-				s.pushMetrics(ch, s.containerSwapUsageBytes, container.Swap.SwapUsageBytes, nodeName, namespace, podName, container.Name)
-			}
+		if container.CPU != nil {
+			s.pushMetricsAt(ch, container.CPU.Time, s.containerCPUUsageNanoCores, container.CPU.UsageNanoCores, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.CPU.Time, s.containerCPUUsageCoreNanoSeconds, container.CPU.UsageCoreNanoSeconds, nodeName, namespace, podName, container.Name)
+		}
 
-			for _, accelerator := range container.Accelerators {
-				s.pushMetrics(ch, s.containerAcceleratorMemoryUsed, &accelerator.MemoryUsed, nodeName, podName, namespace, container.Name, accelerator.ID, accelerator.Model, accelerator.Make)
-				s.pushMetrics(ch, s.containerAcceleratorMemoryTotal, &accelerator.MemoryTotal, nodeName, podName, namespace, container.Name, accelerator.ID, accelerator.Model, accelerator.Make)
-				s.pushMetrics(ch, s.containerAcceleratorDutyCycle, &accelerator.DutyCycle, nodeName, podName, namespace, container.Name, accelerator.ID, accelerator.Model, accelerator.Make)
-			}
+		if container.Memory != nil {
+			s.pushMetricsAt(ch, container.Memory.Time, s.containerMemoryAvailableBytes, container.Memory.AvailableBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Memory.Time, s.containerMemoryUsageBytes, container.Memory.UsageBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Memory.Time, s.containerMemoryWorkingSetBytes, container.Memory.WorkingSetBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Memory.Time, s.containerMemoryRSSBytes, container.Memory.RSSBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Memory.Time, s.containerMemoryPageFaults, container.Memory.PageFaults, nodeName, namespace, podName, container.Name)
+		}
+
+		s.emitResourceRatios(ch, nodeName, podSpec, container)
+		s.emitUserDefinedMetrics(ch, nodeName, namespace, podName, container)
+
+		s.history.record(containerKey{namespace: namespace, pod: podName, container: container.Name}, container.CPU, container.Memory, now)
+
+		if container.Swap != nil {
+			s.pushMetricsAt(ch, container.Swap.Time, s.containerSwapAvailableBytes, container.Swap.SwapAvailableBytes, nodeName, namespace, podName, container.Name)
+			s.pushMetricsAt(ch, container.Swap.Time, s.containerSwapUsageBytes, container.Swap.SwapUsageBytes, nodeName, namespace, podName, container.Name)
+		}
+
+		for _, accelerator := range container.Accelerators {
+			s.pushMetrics(ch, s.containerAcceleratorMemoryUsed, &accelerator.MemoryUsed, nodeName, namespace, podName, container.Name, accelerator.ID, accelerator.Model, accelerator.Make)
+			s.pushMetrics(ch, s.containerAcceleratorMemoryTotal, &accelerator.MemoryTotal, nodeName, namespace, podName, container.Name, accelerator.ID, accelerator.Model, accelerator.Make)
+			s.pushMetrics(ch, s.containerAcceleratorDutyCycle, &accelerator.DutyCycle, nodeName, namespace, podName, container.Name, accelerator.ID, accelerator.Model, accelerator.Make)
 		}
 	}
 }
 
-func (s *Scraper) parse(body []byte) (*statsapi.Summary, error) {
-	var summary statsapi.Summary
-	err := json.Unmarshal(body, &summary)
-	if err != nil {
-		return nil, err
+// pushTime pushes t as seconds since the epoch, unless it is unset.
+func (s *Scraper) pushTime(ch chan<- prometheus.Metric, metric *prometheus.Desc, t metav1.Time, labelValues ...string) {
+	if t.IsZero() {
+		return
 	}
-	return &summary, nil
+	ch <- prometheus.MustNewConstMetric(metric, prometheus.GaugeValue, float64(t.UnixNano())/1e9, labelValues...)
 }
 
 func (s *Scraper) pushMetrics(ch chan<- prometheus.Metric, metric *prometheus.Desc, value *uint64, labelValues ...string) {
+	s.pushMetricsAt(ch, metav1.Time{}, metric, value, labelValues...)
+}
+
+// pushMetricsAt is pushMetrics for stats taken at t, which timestamps the
+// metric when enabled with WithTimestamps.
+func (s *Scraper) pushMetricsAt(ch chan<- prometheus.Metric, t metav1.Time, metric *prometheus.Desc, value *uint64, labelValues ...string) {
+	valueType := prometheus.GaugeValue
+	if counter, ok := s.counterDescs[metric]; ok {
+		metric, valueType = counter, prometheus.CounterValue
+	}
+
 	if value != nil {
-		ch <- prometheus.MustNewConstMetric(
+		ch <- s.timestamped(t, prometheus.MustNewConstMetric(
 			metric,
-			prometheus.GaugeValue,
+			valueType,
 			float64(*value),
 			labelValues...,
-		)
+		))
 	}
 }
@@ -7,86 +7,95 @@
 package scraper
 
 import (
+	"bytes"
+	"flag"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
-	"github.com/google/go-cmp/cmp"
-	"go.uber.org/zap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
 )
 
-var capacityBytes uint64 = 107361579008
-var usedBytes uint64 = 0
+var update = flag.Bool("update", false, "rewrite the golden files of TestGolden")
 
-func TestDecodingJson(t *testing.T) {
+// TestGolden feeds recorded summaries through Collect and compares the
+// summary metrics with the exposition in testdata/golden, which is the
+// contract of the exported names and labels. Run with -update to accept
+// changes to it.
+func TestGolden(t *testing.T) {
 	for _, tc := range []struct {
-		Name        string
-		InputFile   string
-		WantSummary *Summary
+		name    string
+		summary string
+		opts    []Option
 	}{
-		{
-			Name:      "validate example one test data",
-			InputFile: "testdata/example.yaml",
-			WantSummary: &Summary{Node: Node{NodeName: "ip-172-20-125-125.ec2.internal"},
-				Pods: []*Pod{
-					{
-						PodRef: struct {
-							Name      string "json:\"name\""
-							Namespace string "json:\"namespace\""
-						}{Name: "aws-xray-daemon-bpmqx", Namespace: "kube-system"},
-						Containers: []ContainerStats{
-							{
-								Name: "aws-xray-daemon",
-								Rootfs: FsStats{
-									CapacityBytes: &capacityBytes,
-									UsedBytes:     &usedBytes,
-								},
-							},
-						},
-					},
-				},
-			},
-		},
-		{
-			Name:      "validate example one test data",
-			InputFile: "testdata/example2.yaml",
-			WantSummary: &Summary{Node: Node{NodeName: "ip-172-20-96-152.ec2.internal"},
-				Pods: []*Pod{
-					{
-						PodRef: struct {
-							Name      string "json:\"name\""
-							Namespace string "json:\"namespace\""
-						}{Name: "appcache-us-east-1f-6599bdfbcd-lf9j6", Namespace: "rmux"},
-						Containers: []ContainerStats{
-							{
-								Name: "rmux",
-								Rootfs: FsStats{
-									CapacityBytes: &capacityBytes,
-									UsedBytes:     &usedBytes,
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+		{name: "example", summary: "testdata/example.yaml"},
+		{name: "example2", summary: "testdata/example2.yaml"},
+		{name: "example-counters", summary: "testdata/example.yaml", opts: []Option{WithCounters()}},
 	} {
-		t.Run(tc.Name, func(t *testing.T) {
-			ex, err := os.ReadFile(tc.InputFile)
-			if err != nil {
-				t.Fatalf("failed to read test data %+v", err)
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithSummaryProvider(summaryclient.File(tc.summary))}, tc.opts...)
+			s := NewScraper(logging.Nop(), "", "", time.Second, opts...)
+
+			// The exporter's own metrics and the age of the stats vary
+			// between runs and are left out.
+			var names []string
+			for _, info := range s.Metrics() {
+				if info.Name != "kubelet_summary_staleness_seconds" {
+					names = append(names, info.Name)
+				}
 			}
 
-			scraper := NewScraper(zap.L(), "", "", 1*time.Microsecond)
+			golden := filepath.Join("testdata", "golden", tc.name+".prom")
+			if *update {
+				writeGolden(t, s, golden, names)
+				return
+			}
 
-			summary, err := scraper.parse(ex)
+			expected, err := os.Open(golden)
 			if err != nil {
-				t.Fatalf("failed to parse test data %+v", err)
+				t.Fatal(err)
 			}
+			defer expected.Close()
 
-			if diff := cmp.Diff(tc.WantSummary, summary); diff != "" {
-				t.Errorf("summary mismatch (-want +got):\n%s", diff)
+			if err := testutil.CollectAndCompare(s, expected, names...); err != nil {
+				t.Error(err)
 			}
 		})
 	}
 }
+
+func writeGolden(t *testing.T, c prometheus.Collector, path string, names []string) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := map[string]struct{}{}
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+
+	var out bytes.Buffer
+	for _, family := range families {
+		if _, ok := wanted[family.GetName()]; !ok {
+			continue
+		}
+		if _, err := expfmt.MetricFamilyToText(&out, family); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
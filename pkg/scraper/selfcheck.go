@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// SelfCheck runs every emission path against a synthetic summary with all
+// optional sections populated and verifies that each emitted metric uses a
+// described descriptor with a matching number of label values. A mismatch is
+// a programming error that would otherwise only surface as a panic (or a
+// silently dropped series) once a kubelet happens to report that section.
+// The synthetic pod's namespace and name differ, so label values passed in
+// another order than the descriptor's labels are caught too. Metric
+// overrides that don't apply are reported as well. It must not run
+// concurrently with Collect.
+func (s *Scraper) SelfCheck() (err error) {
+	if err := s.overrideError(); err != nil {
+		return err
+	}
+
+	// Swap out state carried across scrapes so the synthetic summaries
+	// neither see nor leave behind real observations.
+	nodes, volumeHealth, logGrowth, cpuRate, imageGC := s.nodes, s.volumeHealth, s.logGrowth, s.cpuRate, s.imageGC
+	s.nodes, s.volumeHealth, s.logGrowth, s.cpuRate = syntheticNodes{}, newVolumeHealthTracker(), newRateTracker(), newRateTracker()
+	s.imageGC = &imageGCConfig{highThresholdPercent: defaultImageGCHighThresholdPercent, known: true}
+	suppressInMaintenance, history := s.suppressInMaintenance, s.history
+	s.suppressInMaintenance, s.history = false, nil
+	defer func() {
+		s.nodes, s.volumeHealth, s.logGrowth, s.cpuRate, s.imageGC = nodes, volumeHealth, logGrowth, cpuRate, imageGC
+		s.suppressInMaintenance, s.history = suppressInMaintenance, history
+	}()
+
+	described := map[*prometheus.Desc]struct{}{}
+	descCh := make(chan *prometheus.Desc)
+	go func() {
+		s.Describe(descCh)
+		close(descCh)
+	}()
+	for desc := range descCh {
+		described[desc] = struct{}{}
+	}
+
+	metricCh := make(chan prometheus.Metric)
+	undescribed := make(chan string, 1)
+	swapped := make(chan string, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range metricCh {
+			if _, ok := described[metric.Desc()]; !ok {
+				select {
+				case undescribed <- metric.Desc().String():
+				default:
+				}
+			}
+			if swappedPodLabels(metric) {
+				select {
+				case swapped <- metric.Desc().String():
+				default:
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		close(metricCh)
+		<-done
+
+		if r := recover(); r != nil {
+			err = fmt.Errorf("emitting synthetic summary: %v", r)
+			return
+		}
+
+		select {
+		case desc := <-undescribed:
+			err = fmt.Errorf("emitted metric with undescribed descriptor %s", desc)
+		case desc := <-swapped:
+			err = fmt.Errorf("emitted metric with swapped namespace and pod label values %s", desc)
+		default:
+		}
+	}()
+
+	// Two summaries are needed for metrics derived from consecutive samples.
+	now := time.Now()
+	s.emit(metricCh, syntheticSummary(now.Add(-time.Minute)))
+	s.emit(metricCh, syntheticSummary(now))
+
+	return nil
+}
+
+// The namespace and name of the synthetic pod.
+const (
+	syntheticNamespace = "self-check-namespace"
+	syntheticPod       = "self-check-pod"
+)
+
+// swappedPodLabels tells whether a metric has the synthetic pod's name as
+// its namespace or its namespace as its pod.
+func swappedPodLabels(metric prometheus.Metric) bool {
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		return false
+	}
+	for _, pair := range m.GetLabel() {
+		switch {
+		case pair.GetName() == "namespace" && pair.GetValue() == syntheticPod,
+			pair.GetName() == "pod" && pair.GetValue() == syntheticNamespace:
+			return true
+		}
+	}
+	return false
+}
+
+// syntheticSummary returns a summary taken at the given time in which every
+// optional section the exporter knows about is present.
+func syntheticSummary(at time.Time) *statsapi.Summary {
+	value := func() *uint64 {
+		v := uint64(1)
+		return &v
+	}
+	count := func() *int64 {
+		v := int64(1)
+		return &v
+	}
+	fs := func() *statsapi.FsStats {
+		return &statsapi.FsStats{
+			Time:           metav1.NewTime(at),
+			AvailableBytes: value(),
+			CapacityBytes:  value(),
+			UsedBytes:      value(),
+			InodesFree:     value(),
+			Inodes:         value(),
+			InodesUsed:     value(),
+		}
+	}
+	cpu := func() *statsapi.CPUStats {
+		return &statsapi.CPUStats{UsageNanoCores: value(), UsageCoreNanoSeconds: value()}
+	}
+	memory := func() *statsapi.MemoryStats {
+		return &statsapi.MemoryStats{
+			AvailableBytes:  value(),
+			UsageBytes:      value(),
+			WorkingSetBytes: value(),
+			RSSBytes:        value(),
+			PageFaults:      value(),
+			MajorPageFaults: value(),
+		}
+	}
+	swap := func() *statsapi.SwapStats {
+		return &statsapi.SwapStats{SwapAvailableBytes: value(), SwapUsageBytes: value()}
+	}
+	network := func() *statsapi.NetworkStats {
+		return &statsapi.NetworkStats{
+			Interfaces: []statsapi.InterfaceStats{
+				{Name: "eth0", RxBytes: value(), RxErrors: value(), TxBytes: value(), TxErrors: value()},
+			},
+		}
+	}
+	container := func(name string) statsapi.ContainerStats {
+		return statsapi.ContainerStats{
+			Name:      name,
+			StartTime: metav1.Now(),
+			CPU:       cpu(),
+			Memory:    memory(),
+			Accelerators: []statsapi.AcceleratorStats{
+				{Make: "nvidia", Model: "t4", ID: "gpu-0", MemoryTotal: 1, MemoryUsed: 1, DutyCycle: 1},
+			},
+			Rootfs: fs(),
+			Logs:   fs(),
+			Swap:   swap(),
+			UserDefinedMetrics: []statsapi.UserDefinedMetric{
+				{UserDefinedMetricDescriptor: statsapi.UserDefinedMetricDescriptor{Name: "self-check", Type: statsapi.MetricGauge}, Value: 1},
+				{UserDefinedMetricDescriptor: statsapi.UserDefinedMetricDescriptor{Name: "self-check", Type: statsapi.MetricCumulative}, Value: 1},
+			},
+		}
+	}
+
+	return &statsapi.Summary{
+		Node: statsapi.NodeStats{
+			NodeName:         "self-check",
+			SystemContainers: []statsapi.ContainerStats{container(statsapi.SystemContainerKubelet)},
+			StartTime:        metav1.Now(),
+			CPU:              cpu(),
+			Memory:           memory(),
+			Network:          network(),
+			Fs:               fs(),
+			Runtime:          &statsapi.RuntimeStats{ImageFs: fs(), ContainerFs: fs()},
+			Rlimit:           &statsapi.RlimitStats{MaxPID: count(), NumOfRunningProcesses: count()},
+			Swap:             swap(),
+		},
+		Pods: []statsapi.PodStats{
+			{
+				PodRef:     statsapi.PodReference{Name: syntheticPod, Namespace: syntheticNamespace, UID: "self-check"},
+				StartTime:  metav1.Now(),
+				Containers: []statsapi.ContainerStats{container("self-check")},
+				CPU:        cpu(),
+				Memory:     memory(),
+				Network:    network(),
+				VolumeStats: []statsapi.VolumeStats{
+					{
+						Name:              "self-check",
+						FsStats:           *fs(),
+						PVCRef:            &statsapi.PVCReference{Name: "self-check", Namespace: syntheticNamespace},
+						VolumeHealthStats: &statsapi.VolumeHealthStats{Abnormal: true},
+					},
+				},
+				EphemeralStorage: fs(),
+				ProcessStats:     &statsapi.ProcessStats{ProcessCount: value()},
+				Swap:             swap(),
+			},
+		},
+	}
+}
+
+// syntheticNodes returns a cordoned node with every resource the exporter
+// reads.
+type syntheticNodes struct{}
+
+func (syntheticNodes) Get(name string) (*v1.Node, bool) {
+	resources := v1.ResourceList{
+		v1.ResourceCPU:              resource.MustParse("1"),
+		v1.ResourceMemory:           resource.MustParse("1Gi"),
+		v1.ResourcePods:             resource.MustParse("110"),
+		v1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+	}
+
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.NodeSpec{Unschedulable: true},
+		Status: v1.NodeStatus{
+			Capacity:    resources,
+			Allocatable: resources,
+		},
+	}, true
+}
@@ -0,0 +1,32 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestSelfCheck(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", 1*time.Microsecond)
+
+	if err := scraper.SelfCheck(); err != nil {
+		t.Fatalf("self-check failed: %+v", err)
+	}
+}
+
+func TestSelfCheckDetectsLabelMismatch(t *testing.T) {
+	scraper := NewScraper(logging.Nop(), "", "", 1*time.Microsecond)
+	scraper.nodeFsUsedBytes = prometheus.NewDesc("kubelet_summary_node_fs_usage_bytes", "Disk used in bytes", []string{"node", "extra"}, nil)
+
+	if err := scraper.SelfCheck(); err == nil {
+		t.Fatalf("expected self-check to fail on label count mismatch")
+	}
+}
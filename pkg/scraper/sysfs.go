@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WithSysfsPath enables reading link speeds of node interfaces from the
+// given sysfs mount (usually the host's /sys mounted into the pod).
+func WithSysfsPath(path string) Option {
+	return func(s *Scraper) {
+		s.sysfsPath = path
+	}
+}
+
+// interfaceSpeedBytes returns the link speed of a node interface in bytes
+// per second, or nil when sysfs is not configured or the speed is unknown
+// (virtual interfaces report -1 or fail the read).
+func (s *Scraper) interfaceSpeedBytes(name string) *uint64 {
+	if s.sysfsPath == "" || name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.sysfsPath, "class", "net", name, "speed"))
+	if err != nil {
+		return nil
+	}
+
+	mbits, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || mbits <= 0 {
+		return nil
+	}
+
+	speed := uint64(mbits) * 1000 * 1000 / 8
+	return &speed
+}
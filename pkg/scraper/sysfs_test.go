@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestInterfaceSpeedBytes(t *testing.T) {
+	sysfs := t.TempDir()
+	for path, content := range map[string]string{
+		"class/net/eth0/speed":     "10000\n",
+		"class/net/veth1234/speed": "-1\n",
+		"class/net/bond0/speed":    "unknown\n",
+		// Outside class/net, only reachable by a name with a separator.
+		"class/eth1/speed": "1000\n",
+	} {
+		path = filepath.Join(sysfs, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A speed that can't be read, even by root.
+	if err := os.MkdirAll(filepath.Join(sysfs, "class", "net", "lo", "speed"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]uint64{
+		"eth0":     1250000000,
+		"veth1234": 0,
+		"bond0":    0,
+		"lo":       0,
+		"missing":  0,
+		"":         0,
+		"../eth1":  0,
+	} {
+		s := NewScraper(logging.Nop(), "127.0.0.1", "", time.Second, WithSysfsPath(sysfs))
+		got := s.interfaceSpeedBytes(name)
+		switch {
+		case want == 0 && got != nil:
+			t.Errorf("expected no speed for %q, got %d", name, *got)
+		case want != 0 && got == nil:
+			t.Errorf("expected speed %d for %q, got none", want, name)
+		case want != 0 && *got != want:
+			t.Errorf("expected speed %d for %q, got %d", want, name, *got)
+		}
+	}
+
+	s := NewScraper(logging.Nop(), "127.0.0.1", "", time.Second)
+	if got := s.interfaceSpeedBytes("eth0"); got != nil {
+		t.Errorf("expected no speed without sysfs, got %d", *got)
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// legacySystemContainers maps system container names reported by older
+// kubelets, dockershim and runtime specific cgroup layouts to the names
+// current kubelets use.
+var legacySystemContainers = map[string]string{
+	"docker-daemon": statsapi.SystemContainerRuntime,
+	"docker":        statsapi.SystemContainerRuntime,
+	"dockerd":       statsapi.SystemContainerRuntime,
+	"containerd":    statsapi.SystemContainerRuntime,
+	"crio":          statsapi.SystemContainerRuntime,
+	"cri-o":         statsapi.SystemContainerRuntime,
+	"kubelet":       statsapi.SystemContainerKubelet,
+	"kubepods":      statsapi.SystemContainerPods,
+	"system":        statsapi.SystemContainerMisc,
+}
+
+// canonicalSystemContainer returns the current name of a system container,
+// so dashboards keep working across runtime migrations. The reported name
+// is kept in the original_name label. Unknown names are returned as is.
+func canonicalSystemContainer(name string) string {
+	trimmed := strings.TrimPrefix(name, "/")
+	trimmed = strings.TrimPrefix(trimmed, "system.slice/")
+	trimmed = strings.TrimSuffix(trimmed, ".service")
+	trimmed = strings.TrimSuffix(trimmed, ".slice")
+
+	if canonical, ok := legacySystemContainers[trimmed]; ok {
+		return canonical
+	}
+	return name
+}
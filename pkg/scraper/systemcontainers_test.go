@@ -0,0 +1,32 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+)
+
+func TestCanonicalSystemContainer(t *testing.T) {
+	for name, want := range map[string]string{
+		"runtime":                          "runtime",
+		"kubelet":                          "kubelet",
+		"pods":                             "pods",
+		"misc":                             "misc",
+		"/docker-daemon":                   "runtime",
+		"/system.slice/containerd.service": "runtime",
+		"crio.service":                     "runtime",
+		"/kubelet":                         "kubelet",
+		"/system.slice/kubelet.service":    "kubelet",
+		"/kubepods.slice":                  "pods",
+		"/system":                          "misc",
+		"/custom-agent":                    "/custom-agent",
+	} {
+		if got := canonicalSystemContainer(name); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeStats records the outcome of scrapes, so slow or failing kubelets
+// can be alerted on directly rather than inferred from the error counters.
+type scrapeStats struct {
+	mu          sync.Mutex
+	total       map[string]float64
+	lastSuccess bool
+	lastTime    time.Time
+	// fetched is when a summary was last fetched, by a scrape or a check.
+	fetched time.Time
+
+	scrapes       *prometheus.Desc
+	lastScrape    *prometheus.Desc
+	lastTimestamp *prometheus.Desc
+}
+
+func newScrapeStats() *scrapeStats {
+	return &scrapeStats{
+		total: map[string]float64{"success": 0, "failure": 0},
+		scrapes: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "scrapes_total"),
+			"Scrapes of kubelet stats summary by result",
+			[]string{"result"},
+			nil),
+		lastScrape: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "last_scrape_success"),
+			"Whether the last scrape of kubelet stats summary succeeded",
+			nil,
+			nil),
+		lastTimestamp: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "last_scrape_timestamp_seconds"),
+			"Time the last scrape of kubelet stats summary started",
+			nil,
+			nil),
+	}
+}
+
+// record counts a scrape started at start.
+func (st *scrapeStats) record(start time.Time, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.lastSuccess = err == nil
+	st.lastTime = start
+	if st.lastSuccess {
+		st.total["success"]++
+		st.fetched = start
+	} else {
+		st.total["failure"]++
+	}
+}
+
+// fetchedAt records a summary fetched outside a scrape at t.
+func (st *scrapeStats) fetchedAt(t time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if t.After(st.fetched) {
+		st.fetched = t
+	}
+}
+
+// lastFetched returns when a summary was last fetched.
+func (st *scrapeStats) lastFetched() time.Time {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return st.fetched
+}
+
+func (st *scrapeStats) describe(ch chan<- *prometheus.Desc) {
+	ch <- st.scrapes
+	ch <- st.lastScrape
+	ch <- st.lastTimestamp
+}
+
+func (st *scrapeStats) collect(ch chan<- prometheus.Metric) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, result := range []string{"success", "failure"} {
+		ch <- prometheus.MustNewConstMetric(st.scrapes, prometheus.CounterValue, st.total[result], result)
+	}
+
+	var success float64
+	if st.lastSuccess {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(st.lastScrape, prometheus.GaugeValue, success)
+	ch <- prometheus.MustNewConstMetric(st.lastTimestamp, prometheus.GaugeValue, float64(st.lastTime.UnixNano())/1e9)
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+type staticToken string
+
+func (t staticToken) Token() ([]byte, error) {
+	return []byte(t), nil
+}
+
+func TestScrapeTelemetry(t *testing.T) {
+	var healthy atomic.Bool
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"node":{"nodeName":"node","runtime":{}}}`))
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second, WithTokenSource(staticToken("token")))
+
+	start := time.Now()
+	testutil.CollectAndCount(s)
+	healthy.Store(true)
+	testutil.CollectAndCount(s)
+	healthy.Store(false)
+	testutil.CollectAndCount(s)
+
+	expected := `
+# HELP kubelet_summary_exporter_last_scrape_success Whether the last scrape of kubelet stats summary succeeded
+# TYPE kubelet_summary_exporter_last_scrape_success gauge
+kubelet_summary_exporter_last_scrape_success 0
+# HELP kubelet_summary_exporter_scrapes_total Scrapes of kubelet stats summary by result
+# TYPE kubelet_summary_exporter_scrapes_total counter
+kubelet_summary_exporter_scrapes_total{result="failure"} 3
+kubelet_summary_exporter_scrapes_total{result="success"} 1
+`
+	// Collecting again to compare is one more failed scrape.
+	if err := testutil.CollectAndCompare(s, strings.NewReader(expected),
+		"kubelet_summary_exporter_scrapes_total", "kubelet_summary_exporter_last_scrape_success"); err != nil {
+		t.Error(err)
+	}
+
+	ch := make(chan prometheus.Metric, 10)
+	s.scrapeStats.collect(ch)
+	close(ch)
+	var timestamp float64
+	for metric := range ch {
+		if metric.Desc() == s.scrapeStats.lastTimestamp {
+			m := &dto.Metric{}
+			if err := metric.Write(m); err != nil {
+				t.Fatal(err)
+			}
+			timestamp = m.GetGauge().GetValue()
+		}
+	}
+	if timestamp < float64(start.Unix()) {
+		t.Errorf("expected the last scrape's time, got %v", timestamp)
+	}
+}
+
+func TestAbandonedScrapesAreNotErrors(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", 10*time.Second, WithTokenSource(staticToken("token")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := s.GatherContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP kubelet_summary_exporter_errors Errors scraping kubelet stats summary by stage and status code
+# TYPE kubelet_summary_exporter_errors counter
+kubelet_summary_exporter_errors{code="",stage="parse"} 0
+kubelet_summary_exporter_errors{code="",stage="read"} 0
+kubelet_summary_exporter_errors{code="",stage="request"} 0
+# HELP kubelet_summary_exporter_scrapes_total Scrapes of kubelet stats summary by result
+# TYPE kubelet_summary_exporter_scrapes_total counter
+kubelet_summary_exporter_scrapes_total{result="failure"} 0
+kubelet_summary_exporter_scrapes_total{result="success"} 0
+`
+	if err := testutil.CollectAndCompare(collectorFunc(func(ch chan<- prometheus.Metric) {
+		s.errors.Collect(ch)
+		s.scrapeStats.collect(ch)
+	}), strings.NewReader(expected), "kubelet_summary_exporter_errors", "kubelet_summary_exporter_scrapes_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// WithTimestamps exports the summary's stats with the time kubelet took
+// them rather than the scrape time, so stats kubelet served from its cache
+// aren't attributed to the wrong time.
+func WithTimestamps() Option {
+	return func(s *Scraper) {
+		s.timestamps = true
+	}
+}
+
+// timestamped sets the timestamp of m to t when timestamps are enabled and
+// t is known.
+func (s *Scraper) timestamped(t metav1.Time, m prometheus.Metric) prometheus.Metric {
+	if !s.timestamps || t.IsZero() {
+		return m
+	}
+	return prometheus.NewMetricWithTimestamp(t.Time, m)
+}
+
+// sectionTimes tracks the oldest stats of each summary section, named after
+// the subsystem of the section's metrics.
+type sectionTimes map[string]time.Time
+
+func (o sectionTimes) observe(section string, t metav1.Time) {
+	if t.IsZero() {
+		return
+	}
+	if oldest, ok := o[section]; !ok || t.Time.Before(oldest) {
+		o[section] = t.Time
+	}
+}
+
+func (o sectionTimes) fs(section string, stats *statsapi.FsStats) {
+	if stats != nil {
+		o.observe(section, stats.Time)
+	}
+}
+
+func (o sectionTimes) cpu(section string, stats *statsapi.CPUStats) {
+	if stats != nil {
+		o.observe(section, stats.Time)
+	}
+}
+
+func (o sectionTimes) memory(section string, stats *statsapi.MemoryStats) {
+	if stats != nil {
+		o.observe(section, stats.Time)
+	}
+}
+
+func (o sectionTimes) swap(section string, stats *statsapi.SwapStats) {
+	if stats != nil {
+		o.observe(section, stats.Time)
+	}
+}
+
+func (o sectionTimes) network(section string, stats *statsapi.NetworkStats) {
+	if stats != nil {
+		o.observe(section, stats.Time)
+	}
+}
+
+// emitStaleness exports how old the oldest stats of each section of the
+// summary were at now.
+func (s *Scraper) emitStaleness(ch chan<- prometheus.Metric, summary *statsapi.Summary, now time.Time) {
+	times := sectionTimes{}
+
+	node := summary.Node
+	times.cpu("node_cpu", node.CPU)
+	times.memory("node_memory", node.Memory)
+	times.swap("node_swap", node.Swap)
+	times.network("node_interface", node.Network)
+	times.fs("node_fs", node.Fs)
+	if node.Runtime != nil {
+		times.fs("node_runtime_image_fs", node.Runtime.ImageFs)
+		times.fs("node_runtime_container_fs", node.Runtime.ContainerFs)
+	}
+	if node.Rlimit != nil {
+		times.observe("node_rlimit", node.Rlimit.Time)
+	}
+	for _, container := range node.SystemContainers {
+		times.cpu("node_system_container_cpu", container.CPU)
+		times.memory("node_system_container_memory", container.Memory)
+	}
+
+	for _, pod := range summary.Pods {
+		times.cpu("pod_cpu", pod.CPU)
+		times.memory("pod_memory", pod.Memory)
+		times.swap("pod_swap", pod.Swap)
+		times.network("pod_interface", pod.Network)
+		times.fs("pod_ephemeral_storage", pod.EphemeralStorage)
+		for _, volume := range pod.VolumeStats {
+			times.observe("pod_volume", volume.Time)
+		}
+
+		for _, container := range pod.Containers {
+			times.cpu("container_cpu", container.CPU)
+			times.memory("container_memory", container.Memory)
+			times.swap("container_swap", container.Swap)
+			times.fs("container_fs", container.Rootfs)
+			times.fs("container_logs", container.Logs)
+		}
+	}
+
+	sections := make([]string, 0, len(times))
+	for section := range times {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		ch <- prometheus.MustNewConstMetric(
+			s.staleness,
+			prometheus.GaugeValue,
+			now.Sub(times[section]).Seconds(),
+			node.NodeName, section,
+		)
+	}
+}
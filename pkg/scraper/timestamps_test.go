@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestTimestamps(t *testing.T) {
+	taken := time.Now().Add(-10 * time.Second).Truncate(time.Millisecond)
+	cores := uint64(1000)
+
+	summary := func() *statsapi.Summary {
+		return &statsapi.Summary{
+			Node: statsapi.NodeStats{
+				NodeName: "node",
+				CPU:      &statsapi.CPUStats{Time: metav1.NewTime(taken), UsageNanoCores: &cores},
+				Runtime:  &statsapi.RuntimeStats{},
+			},
+			Pods: []statsapi.PodStats{{
+				PodRef: statsapi.PodReference{Namespace: "default", Name: "web"},
+				CPU:    &statsapi.CPUStats{Time: metav1.NewTime(taken.Add(5 * time.Second)), UsageNanoCores: &cores},
+			}},
+		}
+	}
+
+	collect := func(s *Scraper) map[string]*dto.Metric {
+		ch := make(chan prometheus.Metric, 100)
+		s.emit(ch, summary())
+		close(ch)
+
+		metrics := map[string]*dto.Metric{}
+		for metric := range ch {
+			m := &dto.Metric{}
+			if err := metric.Write(m); err != nil {
+				t.Fatal(err)
+			}
+
+			key := metric.Desc().String()
+			for _, label := range m.Label {
+				if label.GetName() == "section" {
+					key = label.GetValue()
+				}
+			}
+			metrics[key] = m
+		}
+		return metrics
+	}
+
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithTimestamps())
+	metrics := collect(s)
+
+	node := metrics[s.nodeCPUUsageNanoCores.String()]
+	if node == nil || node.GetTimestampMs() != taken.UnixMilli() {
+		t.Errorf("expected the node cpu metric to carry the summary's timestamp, got %v", node)
+	}
+	if pod := metrics[s.podCPUUsageNanoCores.String()]; pod == nil || pod.GetTimestampMs() != taken.Add(5*time.Second).UnixMilli() {
+		t.Errorf("expected the pod cpu metric to carry its own timestamp, got %v", pod)
+	}
+
+	for section, minimum := range map[string]float64{"node_cpu": 10, "pod_cpu": 5} {
+		staleness := metrics[section]
+		if staleness == nil {
+			t.Errorf("expected staleness of section %s", section)
+			continue
+		}
+		if got := staleness.GetGauge().GetValue(); got < minimum || got > minimum+5 {
+			t.Errorf("expected %s to be about %vs stale, got %v", section, minimum, got)
+		}
+		if staleness.TimestampMs != nil {
+			t.Errorf("expected staleness to be exported at scrape time")
+		}
+	}
+
+	s = NewScraper(logging.Nop(), "", "", time.Second)
+	if node := collect(s)[s.nodeCPUUsageNanoCores.String()]; node == nil || node.TimestampMs != nil {
+		t.Errorf("expected no timestamp without WithTimestamps, got %v", node)
+	}
+}
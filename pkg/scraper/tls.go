@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSOptions configure verification of the kubelet serving certificate.
+type TLSOptions struct {
+	// CAFile is the bundle the serving certificate must be signed by,
+	// usually the cluster CA.
+	CAFile string
+	// CertFile and KeyFile are an optional client certificate presented to
+	// kubelet.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name verified against the serving
+	// certificate, since kubelet is requested by IP.
+	ServerName string
+}
+
+// Config loads the files the options refer to.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cadata, err := os.ReadFile(o.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(cadata) {
+		return nil, fmt.Errorf("%s: no certificates found", o.CAFile)
+	}
+
+	cfg := &tls.Config{
+		RootCAs:    roots,
+		ServerName: o.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// WithTLSConfig verifies kubelet with the given configuration instead of
+// skipping certificate verification.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *Scraper) {
+		s.tlsConfig = cfg
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func writeCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSVerification(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer kubelet.Close()
+	ca := writeCA(t, kubelet)
+
+	for _, tc := range []struct {
+		serverName string
+		ok         bool
+	}{
+		{serverName: "example.com", ok: true},
+		{serverName: "kubelet.invalid", ok: false},
+	} {
+		cfg, err := TLSOptions{CAFile: ca, ServerName: tc.serverName}.Config()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := NewScraper(logging.Nop(), "", "", time.Second, WithTLSConfig(cfg))
+		resp, err := s.httpClient.Get(kubelet.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: expected success %v, got error %v", tc.serverName, tc.ok, err)
+		}
+	}
+
+	if _, err := (TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing")}).Config(); err == nil {
+		t.Errorf("expected a missing CA bundle to fail")
+	}
+}
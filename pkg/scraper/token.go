@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/salesforce/kubelet-summary-exporter/pkg/auth"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/summaryclient"
+)
+
+// TokenSource provides the bearer token sent to kubelet.
+type TokenSource = summaryclient.TokenSource
+
+// WithTokenSource takes kubelet bearer tokens from ts instead of the token
+// file, e.g. to run without any file access.
+func WithTokenSource(ts TokenSource) Option {
+	return func(s *Scraper) {
+		s.tokenSource = ts
+	}
+}
+
+// tokens returns the source of bearer tokens for kubelet requests.
+func (s *Scraper) tokens() TokenSource {
+	if s.tokenSource != nil {
+		return s.tokenSource
+	}
+	return summaryclient.TokenFile(s.tokenPath)
+}
+
+// tokenHint returns the hint and log fields describing where tokens come
+// from, for token errors.
+func (s *Scraper) tokenHint() (hint string, source []any) {
+	path := s.tokenPath
+	switch ts := s.tokenSource.(type) {
+	case nil:
+	case *auth.TokenFile:
+		path = ts.Path()
+	default:
+		return "check that the service account may create tokens for itself (serviceaccounts/token)", []any{"source", "token request"}
+	}
+	return "check that the service account token is mounted at the token path", []any{"file", path}
+}
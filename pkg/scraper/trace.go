@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Phases of kubelet requests, which tell a slow network from a slow kubelet.
+const (
+	phaseDNS       = "dns"
+	phaseConnect   = "connect"
+	phaseTLS       = "tls"
+	phaseFirstByte = "first_byte"
+)
+
+// newRequestPhases returns the histogram of the durations of the phases of
+// kubelet requests. Requests on a kept-alive connection only have a
+// first_byte phase.
+func newRequestPhases(nativeBucketFactor float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:                   "kubelet_summary_exporter",
+		Subsystem:                   "kubelet_request",
+		Name:                        "phase_duration_seconds",
+		Help:                        "Duration of the DNS lookup, TCP connect and TLS handshake of kubelet requests, and of the wait from the request written to the first response byte",
+		Buckets:                     []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		NativeHistogramBucketFactor: nativeBucketFactor,
+	}, []string{"phase"})
+}
+
+// tracingTransport observes the phases of the requests sent through next.
+type tracingTransport struct {
+	next   http.RoundTripper
+	phases *prometheus.HistogramVec
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &requestTrace{phases: t.phases}
+	return t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace())))
+}
+
+// requestTrace times the phases of one request. Dials to several addresses
+// of a host may run concurrently, so its fields are guarded.
+type requestTrace struct {
+	phases *prometheus.HistogramVec
+
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart map[string]time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+}
+
+func (r *requestTrace) observe(phase string, start time.Time) {
+	if !start.IsZero() {
+		r.phases.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (r *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.observe(phaseDNS, r.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.connectStart == nil {
+				r.connectStart = map[string]time.Time{}
+			}
+			r.connectStart[network+addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if err == nil {
+				r.observe(phaseConnect, r.connectStart[network+addr])
+			}
+		},
+		TLSHandshakeStart: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if err == nil {
+				r.observe(phaseTLS, r.tlsStart)
+			}
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if info.Err == nil {
+				r.wroteRequest = time.Now()
+			}
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.observe(phaseFirstByte, r.wroteRequest)
+		},
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestRequestPhases(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"node":{"nodeName":"node","runtime":{}}}`))
+	}))
+	defer kubelet.Close()
+
+	s := NewScraper(logging.Nop(), strings.TrimPrefix(kubelet.URL, "https://"), "", time.Second, WithTokenSource(staticToken("token")))
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.getSummary(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second request reuses the connection, and the server is dialed
+	// by IP without a DNS lookup.
+	for phase, expected := range map[string]uint64{
+		phaseDNS:       0,
+		phaseConnect:   1,
+		phaseTLS:       1,
+		phaseFirstByte: 2,
+	} {
+		var m dto.Metric
+		if err := s.requestPhases.WithLabelValues(phase).(prometheus.Metric).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetHistogram().GetSampleCount(); got != expected {
+			t.Errorf("%s: expected %d observations, got %d", phase, expected, got)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions tune the connections to kubelet, which are kept alive
+// between scrapes. Zero values use the defaults.
+type TransportOptions struct {
+	// MaxIdleConns is the number of idle connections kept, 2 by default so
+	// a hedged request can reuse one too.
+	MaxIdleConns int
+	// IdleConnTimeout closes idle connections after this long, 90s by
+	// default.
+	IdleConnTimeout time.Duration
+	// TLSHandshakeTimeout limits TLS handshakes, 10s by default.
+	TLSHandshakeTimeout time.Duration
+	// DisableCompression stops asking kubelet for gzip-compressed responses,
+	// which are otherwise decompressed transparently.
+	DisableCompression bool
+}
+
+// WithTransportOptions tunes the connections to kubelet.
+func WithTransportOptions(o TransportOptions) Option {
+	return func(s *Scraper) {
+		s.transport = o
+	}
+}
+
+// WithHTTPClient sends kubelet requests with client, e.g. an embedder's
+// instrumented client, instead of one built from the TLS and transport
+// options. Fault injection, request phase timing and the node proxy's
+// transport don't apply to it.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Scraper) {
+		s.httpClient = client
+	}
+}
+
+// newClient builds the client used for every kubelet request, so
+// connections are reused across scrapes instead of handshaking each time.
+func (s *Scraper) newClient() *http.Client {
+	tlsConfig := s.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: true, //nolint:gosec //See https://nvbugspro.nvidia.com/bug/4474467
+		}
+	}
+
+	o := s.transport
+	if o.MaxIdleConns <= 0 {
+		o.MaxIdleConns = 2
+	}
+	if o.IdleConnTimeout <= 0 {
+		o.IdleConnTimeout = 90 * time.Second
+	}
+	if o.TLSHandshakeTimeout <= 0 {
+		o.TLSHandshakeTimeout = 10 * time.Second
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   s.timeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        o.MaxIdleConns,
+		MaxIdleConnsPerHost: o.MaxIdleConns,
+		IdleConnTimeout:     o.IdleConnTimeout,
+		TLSHandshakeTimeout: o.TLSHandshakeTimeout,
+		DisableCompression:  o.DisableCompression,
+	}
+	if s.nodeProxy != nil {
+		transport = s.nodeProxy.Transport()
+	}
+	transport = &tracingTransport{next: transport, phases: s.requestPhases}
+	if s.faults != nil {
+		transport = s.faults.wrap(transport)
+	}
+
+	return &http.Client{
+		Timeout:   s.timeout,
+		Transport: transport,
+	}
+}
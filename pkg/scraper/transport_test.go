@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestCompression(t *testing.T) {
+	var encodings []string
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Accept-Encoding"))
+		body := `{"node":{"nodeName":"node","runtime":{}}}`
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = w.Write([]byte(body))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(body))
+		_ = gz.Close()
+	}))
+	defer kubelet.Close()
+
+	host := strings.TrimPrefix(kubelet.URL, "https://")
+	for _, disabled := range []bool{false, true} {
+		s := NewScraper(logging.Nop(), host, "", time.Second, WithTokenSource(staticToken("token")),
+			WithTransportOptions(TransportOptions{DisableCompression: disabled}))
+
+		summary, _, err := s.getSummary(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Node.NodeName != "node" {
+			t.Errorf("compression disabled %v: expected the summary to be decoded, got %+v", disabled, summary.Node)
+		}
+	}
+
+	if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "" {
+		t.Errorf("expected gzip to be accepted unless disabled, got %q", encodings)
+	}
+}
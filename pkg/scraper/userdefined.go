@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// WithUserDefinedMetrics exports the user defined metrics of containers.
+// Their names and labels are chosen by the workloads, so the series they
+// add aren't bounded by the exporter.
+func WithUserDefinedMetrics() Option {
+	return func(s *Scraper) {
+		s.userDefinedMetrics = true
+	}
+}
+
+// emitUserDefinedMetrics exports the user defined metrics of a container.
+// Cumulative metrics are counters in a family of their own, since a family
+// has a single type; gauges and deltas are gauges.
+func (s *Scraper) emitUserDefinedMetrics(ch chan<- prometheus.Metric, nodeName, namespace, podName string, container statsapi.ContainerStats) {
+	if !s.userDefinedMetrics {
+		return
+	}
+
+	for _, metric := range container.UserDefinedMetrics {
+		desc, valueType := s.containerUserDefined, prometheus.GaugeValue
+		if metric.Type == statsapi.MetricCumulative {
+			desc, valueType = s.containerUserDefinedTotal, prometheus.CounterValue
+		}
+
+		ch <- s.timestamped(metric.Time, prometheus.MustNewConstMetric(desc, valueType, metric.Value,
+			nodeName, namespace, podName, container.Name, metric.Name, string(metric.Type), metric.Units, joinLabels(metric.Labels)))
+	}
+}
+
+// joinLabels flattens the labels of a user defined metric into one label
+// value, e.g. a=1,b=2, since every metric has its own label names.
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
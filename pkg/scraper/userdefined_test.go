@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestUserDefinedMetrics(t *testing.T) {
+	metric := func(name string, typ statsapi.UserDefinedMetricType, labels map[string]string, value float64) statsapi.UserDefinedMetric {
+		return statsapi.UserDefinedMetric{
+			UserDefinedMetricDescriptor: statsapi.UserDefinedMetricDescriptor{Name: name, Type: typ, Units: "count", Labels: labels},
+			Value:                       value,
+		}
+	}
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{{
+			PodRef: statsapi.PodReference{Namespace: "default", Name: "web"},
+			Containers: []statsapi.ContainerStats{{
+				Name: "app",
+				UserDefinedMetrics: []statsapi.UserDefinedMetric{
+					metric("queue_depth", statsapi.MetricGauge, map[string]string{"queue": "jobs", "priority": "high"}, 12),
+					metric("requests", statsapi.MetricCumulative, nil, 340),
+					metric("errors_per_minute", statsapi.MetricDelta, nil, 2),
+				},
+			}},
+		}},
+	}
+
+	for _, enabled := range []bool{false, true} {
+		var opts []Option
+		expected := ""
+		if enabled {
+			opts = append(opts, WithUserDefinedMetrics())
+			expected = `
+# HELP kubelet_summary_container_user_defined User defined gauge or delta metric of the container
+# TYPE kubelet_summary_container_user_defined gauge
+kubelet_summary_container_user_defined{container="app",labels="",name="errors_per_minute",namespace="default",node="node",pod="web",type="delta",units="count"} 2
+kubelet_summary_container_user_defined{container="app",labels="priority=high,queue=jobs",name="queue_depth",namespace="default",node="node",pod="web",type="gauge",units="count"} 12
+# HELP kubelet_summary_container_user_defined_total User defined cumulative metric of the container
+# TYPE kubelet_summary_container_user_defined_total counter
+kubelet_summary_container_user_defined_total{container="app",labels="",name="requests",namespace="default",node="node",pod="web",type="cumulative",units="count"} 340
+`
+		}
+		s := NewScraper(logging.Nop(), "", "", time.Second, opts...)
+		collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.emit(ch, summary) })
+
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"kubelet_summary_container_user_defined", "kubelet_summary_container_user_defined_total"); err != nil {
+			t.Errorf("enabled %v: %v", enabled, err)
+		}
+	}
+}
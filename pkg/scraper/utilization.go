@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// WithPodResources enables the ratios of container usage to the requests
+// and limits in the pod specs of pods, saving joins with kube-state-metrics.
+func WithPodResources(pods PodSource) Option {
+	return func(s *Scraper) {
+		s.pods = pods
+		s.resourceRatios = true
+	}
+}
+
+// podSpecs indexes the pods of the pod source by UID, so a pod recreated
+// under the same name isn't matched with its predecessor's spec.
+func (s *Scraper) podSpecs() map[types.UID]*v1.Pod {
+	if s.pods == nil {
+		return nil
+	}
+
+	specs := map[types.UID]*v1.Pod{}
+	for _, pod := range s.pods.List() {
+		specs[pod.UID] = pod
+	}
+	return specs
+}
+
+// emitResourceRatios exports the container's CPU usage and memory working
+// set relative to the requests and limits of its spec in pod, when set.
+// Sidecars, restartable init containers, are matched like containers.
+func (s *Scraper) emitResourceRatios(ch chan<- prometheus.Metric, nodeName string, pod *v1.Pod, container statsapi.ContainerStats) {
+	if pod == nil || !s.resourceRatios {
+		return
+	}
+
+	var resources *v1.ResourceRequirements
+	for _, containers := range [][]v1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range containers {
+			if containers[i].Name == container.Name {
+				resources = &containers[i].Resources
+				break
+			}
+		}
+		if resources != nil {
+			break
+		}
+	}
+	if resources == nil {
+		return
+	}
+
+	labels := []string{nodeName, pod.Namespace, pod.Name, container.Name}
+	ratio := func(desc *prometheus.Desc, used *uint64, resources v1.ResourceList, name v1.ResourceName) {
+		quantity, ok := resources[name]
+		if !ok || used == nil || quantity.IsZero() {
+			return
+		}
+
+		total := float64(quantity.Value())
+		if name == v1.ResourceCPU {
+			total = float64(quantity.MilliValue()) * 1e6
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(*used)/total, labels...)
+	}
+
+	if container.CPU != nil {
+		ratio(s.containerCPUUsageVsRequestRatio, container.CPU.UsageNanoCores, resources.Requests, v1.ResourceCPU)
+		ratio(s.containerCPUUsageVsLimitRatio, container.CPU.UsageNanoCores, resources.Limits, v1.ResourceCPU)
+	}
+	if container.Memory != nil {
+		ratio(s.containerMemoryWorkingSetVsRequestRatio, container.Memory.WorkingSetBytes, resources.Requests, v1.ResourceMemory)
+		ratio(s.containerMemoryWorkingSetVsLimitRatio, container.Memory.WorkingSetBytes, resources.Limits, v1.ResourceMemory)
+	}
+}
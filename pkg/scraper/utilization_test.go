@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestResourceRatios(t *testing.T) {
+	pods := staticPods{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web", UID: "uid-1"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name: "app",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("100Mi")},
+					Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("200Mi")},
+				},
+			}},
+			InitContainers: []v1.Container{{
+				Name:      "proxy",
+				Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+			}},
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "db", UID: "uid-2"},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name:      "app",
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+		}}},
+	}}
+
+	s := NewScraper(logging.Nop(), "", "", time.Second, WithPodResources(pods))
+
+	cores, proxyCores, workingSet := uint64(250_000_000), uint64(100_000_000), uint64(50*1024*1024)
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{
+			{
+				PodRef: statsapi.PodReference{Namespace: "ns", Name: "web", UID: "uid-1"},
+				Containers: []statsapi.ContainerStats{
+					{Name: "app", CPU: &statsapi.CPUStats{UsageNanoCores: &cores}, Memory: &statsapi.MemoryStats{WorkingSetBytes: &workingSet}},
+					{Name: "proxy", CPU: &statsapi.CPUStats{UsageNanoCores: &proxyCores}},
+				},
+			},
+			// A pod recreated under the same name doesn't get the old spec.
+			{
+				PodRef:     statsapi.PodReference{Namespace: "ns", Name: "db", UID: "uid-3"},
+				Containers: []statsapi.ContainerStats{{Name: "app", CPU: &statsapi.CPUStats{UsageNanoCores: &cores}}},
+			},
+		},
+	}
+	collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.emit(ch, summary) })
+
+	expected := `
+# HELP kubelet_summary_container_cpu_usage_vs_limit_ratio CPU usage relative to the container's CPU limit
+# TYPE kubelet_summary_container_cpu_usage_vs_limit_ratio gauge
+kubelet_summary_container_cpu_usage_vs_limit_ratio{container="proxy",namespace="ns",node="node",pod="web"} 0.1
+# HELP kubelet_summary_container_cpu_usage_vs_request_ratio CPU usage relative to the container's CPU request
+# TYPE kubelet_summary_container_cpu_usage_vs_request_ratio gauge
+kubelet_summary_container_cpu_usage_vs_request_ratio{container="app",namespace="ns",node="node",pod="web"} 0.5
+# HELP kubelet_summary_container_memory_working_set_vs_limit_ratio Memory working set relative to the container's memory limit, the OOM kill threshold
+# TYPE kubelet_summary_container_memory_working_set_vs_limit_ratio gauge
+kubelet_summary_container_memory_working_set_vs_limit_ratio{container="app",namespace="ns",node="node",pod="web"} 0.25
+# HELP kubelet_summary_container_memory_working_set_vs_request_ratio Memory working set relative to the container's memory request
+# TYPE kubelet_summary_container_memory_working_set_vs_request_ratio gauge
+kubelet_summary_container_memory_working_set_vs_request_ratio{container="app",namespace="ns",node="node",pod="web"} 0.5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubelet_summary_container_cpu_usage_vs_request_ratio", "kubelet_summary_container_cpu_usage_vs_limit_ratio",
+		"kubelet_summary_container_memory_working_set_vs_request_ratio", "kubelet_summary_container_memory_working_set_vs_limit_ratio"); err != nil {
+		t.Error(err)
+	}
+}
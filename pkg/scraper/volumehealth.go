@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"sync"
+	"time"
+)
+
+type volumeKey struct {
+	namespace string
+	pod       string
+	volume    string
+	// claim is the volume's persistent volume claim, if it has one.
+	claim string
+}
+
+type volumeHealth struct {
+	abnormal       bool
+	lastTransition time.Time
+}
+
+// volumeHealthTracker remembers the health of every volume across scrapes
+// so that the time of the last normal/abnormal transition can be exported.
+// A volume seen for the first time is considered to have transitioned when
+// it was first observed.
+type volumeHealthTracker struct {
+	mu      sync.Mutex
+	volumes map[volumeKey]volumeHealth
+}
+
+func newVolumeHealthTracker() *volumeHealthTracker {
+	return &volumeHealthTracker{volumes: map[volumeKey]volumeHealth{}}
+}
+
+// update records the health observed in one summary and returns the last
+// transition time of each observed volume. Volumes that are no longer
+// reported are forgotten.
+func (t *volumeHealthTracker) update(observed map[volumeKey]bool, now time.Time) map[volumeKey]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transitions := make(map[volumeKey]time.Time, len(observed))
+	volumes := make(map[volumeKey]volumeHealth, len(observed))
+	for key, abnormal := range observed {
+		health, ok := t.volumes[key]
+		if !ok || health.abnormal != abnormal {
+			health = volumeHealth{abnormal: abnormal, lastTransition: now}
+		}
+
+		volumes[key] = health
+		transitions[key] = health.lastTransition
+	}
+	t.volumes = volumes
+
+	return transitions
+}
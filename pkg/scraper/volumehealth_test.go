@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestVolumeHealthTransitions(t *testing.T) {
+	tracker := newVolumeHealthTracker()
+	key := volumeKey{namespace: "default", pod: "db-0", volume: "data"}
+	start := time.Unix(1000, 0)
+
+	if got := tracker.update(map[volumeKey]bool{key: false}, start)[key]; !got.Equal(start) {
+		t.Errorf("first observation should be the transition time, got %v", got)
+	}
+
+	if got := tracker.update(map[volumeKey]bool{key: false}, start.Add(time.Minute))[key]; !got.Equal(start) {
+		t.Errorf("unchanged health should keep the transition time, got %v", got)
+	}
+
+	abnormal := start.Add(2 * time.Minute)
+	if got := tracker.update(map[volumeKey]bool{key: true}, abnormal)[key]; !got.Equal(abnormal) {
+		t.Errorf("health change should update the transition time, got %v", got)
+	}
+
+	tracker.update(map[volumeKey]bool{}, abnormal.Add(time.Minute))
+	readded := abnormal.Add(2 * time.Minute)
+	if got := tracker.update(map[volumeKey]bool{key: true}, readded)[key]; !got.Equal(readded) {
+		t.Errorf("forgotten volume should start over, got %v", got)
+	}
+}
+
+func TestVolumeClaimLabel(t *testing.T) {
+	s := NewScraper(logging.Nop(), "", "", time.Second)
+	used := uint64(1024)
+	summary := &statsapi.Summary{
+		Node: statsapi.NodeStats{NodeName: "node", Runtime: &statsapi.RuntimeStats{}},
+		Pods: []statsapi.PodStats{{
+			PodRef: statsapi.PodReference{Namespace: "default", Name: "db-0"},
+			VolumeStats: []statsapi.VolumeStats{
+				{
+					Name:              "data",
+					FsStats:           statsapi.FsStats{UsedBytes: &used},
+					PVCRef:            &statsapi.PVCReference{Name: "data-db-0", Namespace: "default"},
+					VolumeHealthStats: &statsapi.VolumeHealthStats{},
+				},
+				{Name: "config", FsStats: statsapi.FsStats{UsedBytes: &used}},
+			},
+		}},
+	}
+	collector := collectorFunc(func(ch chan<- prometheus.Metric) { s.emit(ch, summary) })
+
+	expected := `
+# HELP kubelet_summary_pod_volume_health_status Health status of pod volume
+# TYPE kubelet_summary_pod_volume_health_status gauge
+kubelet_summary_pod_volume_health_status{namespace="default",node="node",persistentvolumeclaim="data-db-0",pod="db-0",volume_name="data"} 0
+# HELP kubelet_summary_pod_volume_usage_bytes Pod volume used in bytes
+# TYPE kubelet_summary_pod_volume_usage_bytes gauge
+kubelet_summary_pod_volume_usage_bytes{namespace="default",node="node",persistentvolumeclaim="",pod="db-0",volume_name="config"} 1024
+kubelet_summary_pod_volume_usage_bytes{namespace="default",node="node",persistentvolumeclaim="data-db-0",pod="db-0",volume_name="data"} 1024
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kubelet_summary_pod_volume_usage_bytes", "kubelet_summary_pod_volume_health_status"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// TrustedProxies are the networks of reverse proxies (node-local proxies,
+// service mesh sidecars) whose X-Forwarded-For headers are believed.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDRs or single addresses.
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	trusted := make(TrustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return trusted, nil
+}
+
+func (t TrustedProxies) trusts(ip net.IP) bool {
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client behind any trusted proxies:
+// the peer address unless it's a trusted proxy, otherwise the right-most
+// X-Forwarded-For entry that isn't one. Entries left of the first untrusted
+// hop can be forged by the client and are ignored.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	client := host
+	if ip := net.ParseIP(host); ip == nil || !t.trusts(ip) {
+		return client
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			break
+		}
+
+		client = hop
+		if !t.trusts(ip) {
+			break
+		}
+	}
+
+	return client
+}
+
+type clientIPKey struct{}
+
+// ClientIPFromContext returns the client address stored by Handler.
+func ClientIPFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientIPKey{}).(string)
+	return client
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Handler resolves the real client of every request through the trusted
+// proxies, makes it available through ClientIPFromContext and logs the
+// request with it.
+func Handler(logger logging.Logger, trusted TrustedProxies, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		client := trusted.ClientIP(r)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, client)))
+
+		logger.Debug("served request",
+			"client", client,
+			"peer", r.RemoteAddr,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start).String(),
+		)
+	})
+}
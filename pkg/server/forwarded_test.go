@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"127.0.0.1", "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{remote: "192.168.1.5:1234", want: "192.168.1.5"},
+		{remote: "192.168.1.5:1234", forwarded: []string{"1.2.3.4"}, want: "192.168.1.5"},
+		{remote: "127.0.0.1:1234", forwarded: []string{"192.168.1.5"}, want: "192.168.1.5"},
+		{remote: "127.0.0.1:1234", forwarded: []string{"6.6.6.6, 192.168.1.5", "10.1.2.3"}, want: "192.168.1.5"},
+		{remote: "127.0.0.1:1234", forwarded: []string{"10.1.2.3"}, want: "10.1.2.3"},
+		{remote: "127.0.0.1:1234", forwarded: []string{"garbage"}, want: "127.0.0.1"},
+	} {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		r.RemoteAddr = tc.remote
+		for _, header := range tc.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+
+		if got := trusted.ClientIP(r); got != tc.want {
+			t.Errorf("%s via %v: expected %s, got %s", tc.remote, tc.forwarded, tc.want, got)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Errorf("expected an invalid proxy to be rejected")
+	}
+}
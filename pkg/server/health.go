@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+const (
+	// HealthzPath answers while the exporter serves at all.
+	HealthzPath = "/healthz"
+	// ReadyzPath answers when the exporter can scrape, for readiness probes.
+	ReadyzPath = "/readyz"
+)
+
+// Healthz serves HealthzPath. It doesn't depend on kubelet, so liveness
+// probes don't restart an exporter whose kubelet is down.
+func Healthz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
+
+// ReadinessCheck is a named check of ReadyzPath.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Readyz serves ReadyzPath, failing with 503 and the failed checks when any
+// check fails within timeout.
+func Readyz(logger logging.Logger, timeout time.Duration, checks ...ReadinessCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		var failed []string
+		for _, check := range checks {
+			if err := check.Check(ctx); err != nil {
+				logger.Warn("readiness check failed", "check", check.Name, "error", err)
+				failed = append(failed, fmt.Sprintf("%s: %v", check.Name, err))
+			}
+		}
+
+		if len(failed) > 0 {
+			http.Error(w, strings.Join(failed, "\n"), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
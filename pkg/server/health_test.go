@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestReadyz(t *testing.T) {
+	var kubeletErr error
+	h := Readyz(logging.Nop(), time.Second,
+		ReadinessCheck{Name: "token", Check: func(context.Context) error { return nil }},
+		ReadinessCheck{Name: "kubelet", Check: func(context.Context) error { return kubeletErr }},
+	)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", ReadyzPath, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	kubeletErr = errors.New("connection refused")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", ReadyzPath, nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+	if body := w.Body.String(); strings.TrimSpace(body) != "kubelet: connection refused" {
+		t.Errorf("expected the failed check, got %q", body)
+	}
+}
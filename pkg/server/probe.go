@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// ProbePath scrapes the kubelet named by the target parameter, like the
+// blackbox exporter's /probe, so one exporter can serve every node through
+// Prometheus relabeling.
+const ProbePath = "/probe"
+
+// probeIdle is how long the scraper of a target no longer probed is kept.
+const probeIdle = 10 * time.Minute
+
+// ProbeTarget scrapes a kubelet.
+type ProbeTarget interface {
+	Scrape(ch chan<- prometheus.Metric) error
+}
+
+// ProbeResolver returns the address of the kubelet of target, a node name
+// or address, or false when target may not be probed.
+type ProbeResolver func(target string) (address string, ok bool)
+
+// Probe serves ProbePath. Scrapers are kept per address between probes, so
+// their counters keep counting.
+type Probe struct {
+	logger    logging.Logger
+	resolve   ProbeResolver
+	newTarget func(address string) ProbeTarget
+
+	success  *prometheus.Desc
+	duration *prometheus.Desc
+
+	mu      sync.Mutex
+	targets map[string]*probeTarget
+}
+
+type probeTarget struct {
+	ProbeTarget
+	lastUsed time.Time
+}
+
+// NewProbe returns the handler of ProbePath.
+func NewProbe(logger logging.Logger, resolve ProbeResolver, newTarget func(address string) ProbeTarget) *Probe {
+	return &Probe{
+		logger:    logger,
+		resolve:   resolve,
+		newTarget: newTarget,
+		success: prometheus.NewDesc(
+			"probe_success",
+			"Whether the kubelet's stats could be scraped",
+			nil,
+			nil),
+		duration: prometheus.NewDesc(
+			"probe_duration_seconds",
+			"How long the probe took",
+			nil,
+			nil),
+		targets: map[string]*probeTarget{},
+	}
+}
+
+// target returns the scraper of address, forgetting the ones idle for
+// longer than probeIdle.
+func (p *Probe) target(address string, now time.Time) ProbeTarget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for a, t := range p.targets {
+		if now.Sub(t.lastUsed) > probeIdle {
+			delete(p.targets, a)
+		}
+	}
+
+	t, ok := p.targets[address]
+	if !ok {
+		t = &probeTarget{ProbeTarget: p.newTarget(address)}
+		p.targets[address] = t
+	}
+	t.lastUsed = now
+
+	return t.ProbeTarget
+}
+
+func (p *Probe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	address, ok := p.resolve(target)
+	if !ok {
+		p.logger.Warn("rejected probe", "client", ClientIPFromContext(r.Context()), "target", target)
+		http.Error(w, "target may not be probed", http.StatusForbidden)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(probeCollector{probe: p, target: p.target(address, time.Now())})
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// probeCollector scrapes a target once and adds the probe's outcome. It is
+// unchecked, the scraped metrics depend on the kubelet.
+type probeCollector struct {
+	probe  *Probe
+	target ProbeTarget
+}
+
+func (c probeCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c probeCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	err := c.target.Scrape(ch)
+
+	var success float64
+	if err == nil {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.probe.success, prometheus.GaugeValue, success)
+	ch <- prometheus.MustNewConstMetric(c.probe.duration, prometheus.GaugeValue, time.Since(start).Seconds())
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+type fakeProbeTarget struct {
+	address string
+	err     error
+}
+
+func (f fakeProbeTarget) Scrape(ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("kubelet_summary_node_cpu_usage_nano_cores", "CPU usage in nanocores", []string{"node"}, nil)
+	if f.err == nil {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1000, f.address)
+	}
+	return f.err
+}
+
+func TestProbe(t *testing.T) {
+	created := 0
+	handler := NewProbe(logging.Nop(), func(target string) (string, bool) {
+		switch target {
+		case "node-1":
+			return "10.0.0.1", true
+		case "node-2":
+			return "10.0.0.2", true
+		}
+		return "", false
+	}, func(address string) ProbeTarget {
+		created++
+		if address == "10.0.0.2" {
+			return fakeProbeTarget{address: address, err: errors.New("connection refused")}
+		}
+		return fakeProbeTarget{address: address}
+	})
+
+	for _, tc := range []struct {
+		target   string
+		status   int
+		contains []string
+	}{
+		{target: "", status: http.StatusBadRequest},
+		{target: "evil.example.com", status: http.StatusForbidden},
+		{target: "node-1", status: http.StatusOK, contains: []string{`kubelet_summary_node_cpu_usage_nano_cores{node="10.0.0.1"} 1000`, "probe_success 1", "probe_duration_seconds"}},
+		{target: "node-1", status: http.StatusOK, contains: []string{"probe_success 1"}},
+		{target: "node-2", status: http.StatusOK, contains: []string{"probe_success 0"}},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", ProbePath+"?target="+tc.target, nil))
+
+		if w.Code != tc.status {
+			t.Errorf("%q: expected %d, got %d", tc.target, tc.status, w.Code)
+		}
+		for _, s := range tc.contains {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("%q: expected %q in %q", tc.target, s, w.Body.String())
+			}
+		}
+	}
+
+	if created != 2 {
+		t.Errorf("expected one scraper per target, created %d", created)
+	}
+
+	handler.target("10.0.0.3", time.Now().Add(2*probeIdle))
+	if len(handler.targets) != 1 {
+		t.Errorf("expected idle scrapers to be dropped, have %d", len(handler.targets))
+	}
+}
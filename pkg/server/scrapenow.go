@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// ScrapeNowPath serves a target's metrics on demand.
+const ScrapeNowPath = "/-/scrape-now"
+
+// TargetLookup returns the gatherer of a target.
+type TargetLookup func(target string) (prometheus.Gatherer, bool)
+
+// ScrapeNow scrapes a target immediately, outside Prometheus' schedule, for
+// incident response. Requests must carry the bearer token read from a file,
+// and each target is scraped at most once per interval.
+type ScrapeNow struct {
+	logger    logging.Logger
+	tokenFile string
+	interval  time.Duration
+	lookup    TargetLookup
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewScrapeNow returns the handler of ScrapeNowPath. The token file is read
+// on every request so it can be rotated.
+func NewScrapeNow(logger logging.Logger, tokenFile string, interval time.Duration, lookup TargetLookup) *ScrapeNow {
+	return &ScrapeNow{
+		logger:    logger,
+		tokenFile: tokenFile,
+		interval:  interval,
+		lookup:    lookup,
+		last:      map[string]time.Time{},
+	}
+}
+
+func (h *ScrapeNow) authorized(r *http.Request) bool {
+	want, err := os.ReadFile(h.tokenFile)
+	if err != nil {
+		h.logger.Error("failed to read scrape-now token", "file", h.tokenFile, "error", err)
+		return false
+	}
+	want = bytes.TrimSpace(want)
+
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && len(want) > 0 && subtle.ConstantTimeCompare([]byte(got), want) == 1
+}
+
+// wait returns how long target must not be scraped yet, or records the
+// scrape and returns 0.
+func (h *ScrapeNow) wait(target string, now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if wait := h.interval - now.Sub(h.last[target]); wait > 0 {
+		return wait
+	}
+	h.last[target] = now
+	return 0
+}
+
+func (h *ScrapeNow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	client := ClientIPFromContext(r.Context())
+
+	if !h.authorized(r) {
+		h.logger.Warn("rejected scrape-now request", "client", client, "target", target)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	gatherer, ok := h.lookup(target)
+	if !ok {
+		http.Error(w, "unknown target", http.StatusNotFound)
+		return
+	}
+
+	if wait := h.wait(target, time.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "target was scraped too recently", http.StatusTooManyRequests)
+		return
+	}
+
+	h.logger.Info("scraping on demand", "client", client, "target", target)
+	promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestScrapeNow(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "up"}))
+	handler := NewScrapeNow(logging.Nop(), tokenFile, time.Minute, func(target string) (prometheus.Gatherer, bool) {
+		return registry, target == "node-1"
+	})
+
+	for _, tc := range []struct {
+		token  string
+		target string
+		status int
+	}{
+		{token: "wrong", target: "node-1", status: http.StatusUnauthorized},
+		{token: "secret", target: "node-2", status: http.StatusNotFound},
+		{token: "secret", target: "node-1", status: http.StatusOK},
+		{token: "secret", target: "node-1", status: http.StatusTooManyRequests},
+	} {
+		r := httptest.NewRequest("GET", ScrapeNowPath+"?target="+tc.target, nil)
+		r.Header.Set("Authorization", "Bearer "+tc.token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tc.status {
+			t.Errorf("%s for %s: expected %d, got %d", tc.token, tc.target, tc.status, w.Code)
+		}
+		if w.Code == http.StatusOK && !strings.Contains(w.Body.String(), "up 0") {
+			t.Errorf("expected the target's metrics, got %q", w.Body.String())
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("expected to retry after 60s, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+}
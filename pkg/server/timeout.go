@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ScrapeTimeoutHeader carries the scrape timeout of the Prometheus sending a
+// request, in seconds.
+const ScrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// ContextGatherer is a gatherer whose scrapes can be bounded by a context.
+type ContextGatherer interface {
+	prometheus.Gatherer
+	GatherContext(ctx context.Context) ([]*dto.MetricFamily, error)
+}
+
+// Gatherers is prometheus.Gatherers passing the context of GatherContext to
+// the gatherers supporting it.
+type Gatherers []prometheus.Gatherer
+
+func (gs Gatherers) Gather() ([]*dto.MetricFamily, error) {
+	return prometheus.Gatherers(gs).Gather()
+}
+
+// GatherContext is Gather with the scrapes of ContextGatherers bounded by
+// ctx.
+func (gs Gatherers) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	bound := make(prometheus.Gatherers, len(gs))
+	for i, g := range gs {
+		bound[i] = withContext(ctx, g)
+	}
+	return bound.Gather()
+}
+
+// GatherContext gathers g with its scrapes bounded by ctx if it is a
+// ContextGatherer, for gatherers wrapping another one.
+func GatherContext(ctx context.Context, g prometheus.Gatherer) ([]*dto.MetricFamily, error) {
+	return withContext(ctx, g).Gather()
+}
+
+// withContext returns g with its scrapes bounded by ctx, if it supports it.
+func withContext(ctx context.Context, g prometheus.Gatherer) prometheus.Gatherer {
+	cg, ok := g.(ContextGatherer)
+	if !ok {
+		return g
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return cg.GatherContext(ctx)
+	})
+}
+
+// MetricsHandler serves the metrics of g like promhttp.HandlerFor with opts.
+// The scrapes of a ContextGatherer are bounded by the request's scrape
+// timeout less offset, so partial metrics are served before Prometheus gives
+// up rather than none at all.
+func MetricsHandler(g prometheus.Gatherer, offset time.Duration, opts promhttp.HandlerOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout, ok := ScrapeTimeout(r, offset); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		promhttp.HandlerFor(withContext(ctx, g), opts).ServeHTTP(w, r)
+	})
+}
+
+// ScrapeTimeout returns the scrape timeout of r less offset, or false if r
+// doesn't carry one. An offset larger than the timeout itself is ignored.
+func ScrapeTimeout(r *http.Request, offset time.Duration) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(r.Header.Get(ScrapeTimeoutHeader), 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if offset < timeout {
+		timeout -= offset
+	}
+	return timeout, true
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestScrapeTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: ""},
+		{header: "garbage"},
+		{header: "0"},
+		{header: "10", want: 9500 * time.Millisecond, ok: true},
+		{header: "0.25", want: 250 * time.Millisecond, ok: true},
+	} {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		r.Header.Set(ScrapeTimeoutHeader, tc.header)
+
+		got, ok := ScrapeTimeout(r, 500*time.Millisecond)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%q: expected %v %v, got %v %v", tc.header, tc.want, tc.ok, got, ok)
+		}
+	}
+}
+
+type deadlineGatherer struct {
+	deadline time.Time
+	bounded  bool
+}
+
+func (g *deadlineGatherer) Gather() ([]*dto.MetricFamily, error) {
+	return nil, nil
+}
+
+func (g *deadlineGatherer) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	g.deadline, g.bounded = ctx.Deadline()
+	return nil, nil
+}
+
+func TestMetricsHandler(t *testing.T) {
+	g := &deadlineGatherer{}
+	h := MetricsHandler(Gatherers{g}, time.Second, promhttp.HandlerOpts{})
+
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if g.bounded {
+		t.Error("expected no deadline without a scrape timeout")
+	}
+
+	r = httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set(ScrapeTimeoutHeader, "10")
+	start := time.Now()
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if !g.bounded {
+		t.Fatal("expected a deadline")
+	}
+	if d := g.deadline.Sub(start); d > 10*time.Second || d < 8*time.Second {
+		t.Errorf("expected a deadline 9s away, got %v", d)
+	}
+}
+
+func TestMetricsHandlerCompression(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", Help: "Up"}))
+
+	for disabled, want := range map[bool]string{false: "gzip", true: ""} {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		MetricsHandler(registry, 0, promhttp.HandlerOpts{DisableCompression: disabled}).ServeHTTP(w, r)
+
+		if got := w.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("compression disabled %v: expected encoding %q, got %q", disabled, want, got)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package summaryclient fetches and parses kubelet stats summaries. It has
+// no Prometheus dependency so other tools can use it on its own.
+package summaryclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// TokenSource provides the bearer token sent to kubelet.
+type TokenSource interface {
+	Token() ([]byte, error)
+}
+
+// TokenFile reads the token from a file, e.g. a mounted service account
+// token, on every request so rotated tokens are picked up.
+type TokenFile string
+
+// Token reads the file.
+func (f TokenFile) Token() ([]byte, error) {
+	return os.ReadFile(string(f))
+}
+
+// Doer sends HTTP requests. *http.Client is one.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f.
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Step is a step of fetching a summary.
+type Step string
+
+const (
+	StepToken   Step = "token"
+	StepRequest Step = "request"
+	StepStatus  Step = "status"
+	StepRead    Step = "read"
+	StepParse   Step = "parse"
+)
+
+// ErrEmptyToken is the cause of an Error when the token source returned an
+// empty token.
+var ErrEmptyToken = errors.New("token is empty")
+
+// ErrTooLarge is the cause of an Error when the summary is larger than the
+// client's MaxBytes.
+var ErrTooLarge = errors.New("summary exceeds the size limit")
+
+// Error is returned when fetching a summary fails, along with the step that
+// failed.
+type Error struct {
+	Step Step
+	// StatusCode is kubelet's response status when Step is StepStatus.
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Client fetches the summary of one kubelet.
+type Client struct {
+	url    string
+	tokens TokenSource
+	doer   Doer
+
+	// MaxBytes limits the size of summaries, which is unlimited when 0.
+	MaxBytes int64
+}
+
+// New returns a client requesting url with tokens from tokens through doer.
+// Without tokens no Authorization header is sent, e.g. for kubelet's
+// read-only port.
+func New(url string, tokens TokenSource, doer Doer) *Client {
+	return &Client{url: url, tokens: tokens, doer: doer}
+}
+
+// Fetch returns the raw summary.
+func (c *Client) Fetch(ctx context.Context) ([]byte, error) {
+	var body []byte
+	err := c.do(ctx, func(r io.Reader) error {
+		var err error
+		body, err = io.ReadAll(r)
+		if err != nil {
+			return &Error{Step: StepRead, Err: err}
+		}
+		return nil
+	})
+
+	return body, err
+}
+
+// Decode fetches the summary and decodes it while it is read, without
+// holding the raw summary in memory.
+func (c *Client) Decode(ctx context.Context) (*statsapi.Summary, error) {
+	var summary statsapi.Summary
+	err := c.do(ctx, func(r io.Reader) error {
+		body := &errReader{r: r}
+		if err := json.NewDecoder(body).Decode(&summary); err != nil {
+			if body.err != nil {
+				return &Error{Step: StepRead, Err: body.err}
+			}
+			return &Error{Step: StepParse, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// do requests the summary and hands its body to read.
+func (c *Client) do(ctx context.Context, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url, nil)
+	if err != nil {
+		return &Error{Step: StepRequest, Err: err}
+	}
+
+	if c.tokens != nil {
+		token, err := c.tokens.Token()
+		if err != nil {
+			return &Error{Step: StepToken, Err: err}
+		}
+		if len(bytes.TrimSpace(token)) == 0 {
+			return &Error{Step: StepToken, Err: ErrEmptyToken}
+		}
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return &Error{Step: StepRequest, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &Error{Step: StepStatus, StatusCode: resp.StatusCode, Err: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+
+	var body io.Reader = resp.Body
+	if c.MaxBytes > 0 {
+		body = &limitReader{r: resp.Body, n: c.MaxBytes}
+	}
+
+	return read(body)
+}
+
+// limitReader fails with ErrTooLarge once there are more than n bytes to
+// read.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	if int64(n) > l.n {
+		// Hold back the excess, so a reader can't finish with a truncated
+		// summary.
+		n, l.n = int(l.n), 0
+		return n, ErrTooLarge
+	}
+	l.n -= int64(n)
+	return n, err
+}
+
+// errReader remembers the error reading r, to tell it apart from a decoding
+// error.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		e.err = err
+	}
+	return n, err
+}
+
+// Get fetches and parses the summary.
+func (c *Client) Get(ctx context.Context) (*statsapi.Summary, error) {
+	return c.Decode(ctx)
+}
+
+// GetSummary requests and decodes the summary, like Decode.
+func (c *Client) GetSummary(ctx context.Context) (*statsapi.Summary, error) {
+	return c.Decode(ctx)
+}
+
+// GetRawSummary requests the summary without decoding it, like Fetch.
+func (c *Client) GetRawSummary(ctx context.Context) ([]byte, error) {
+	return c.Fetch(ctx)
+}
+
+// Parse parses a raw summary.
+func Parse(body []byte) (*statsapi.Summary, error) {
+	var summary statsapi.Summary
+	if err := json.Unmarshal(body, &summary); err != nil {
+		return nil, &Error{Step: StepParse, Err: err}
+	}
+	return &summary, nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package summaryclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+type staticToken string
+
+func (t staticToken) Token() ([]byte, error) {
+	return []byte(t), nil
+}
+
+func TestClient(t *testing.T) {
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			_, _ = w.Write([]byte(`{"node": {"nodeName": "node-1"}}`))
+		case "Bearer garbage":
+			_, _ = w.Write([]byte(`{"node": `))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer kubelet.Close()
+
+	summary, err := New(kubelet.URL, staticToken("good"), http.DefaultClient).Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Node.NodeName != "node-1" {
+		t.Errorf("expected node-1, got %q", summary.Node.NodeName)
+	}
+
+	for _, tc := range []struct {
+		token      TokenSource
+		step       Step
+		statusCode int
+	}{
+		{token: staticToken(" \n"), step: StepToken},
+		{token: TokenFile(filepath.Join(t.TempDir(), "missing")), step: StepToken},
+		{token: staticToken("bad"), step: StepStatus, statusCode: http.StatusForbidden},
+		{token: staticToken("garbage"), step: StepParse},
+	} {
+		_, err := New(kubelet.URL, tc.token, http.DefaultClient).Get(context.Background())
+
+		var fetchErr *Error
+		if !errors.As(err, &fetchErr) {
+			t.Errorf("%v: expected an *Error, got %v", tc.token, err)
+			continue
+		}
+		if fetchErr.Step != tc.step || fetchErr.StatusCode != tc.statusCode {
+			t.Errorf("%v: expected step %s with status %d, got %s with %d", tc.token, tc.step, tc.statusCode, fetchErr.Step, fetchErr.StatusCode)
+		}
+	}
+
+	if _, err := New(kubelet.URL, staticToken(""), http.DefaultClient).Fetch(context.Background()); !errors.Is(err, ErrEmptyToken) {
+		t.Errorf("expected ErrEmptyToken, got %v", err)
+	}
+
+	// Without a token source no Authorization header is sent.
+	var anonymous *Error
+	if _, err := New(kubelet.URL, nil, http.DefaultClient).Get(context.Background()); !errors.As(err, &anonymous) || anonymous.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the anonymous request to be forbidden, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(kubelet.URL, staticToken("good"), http.DefaultClient).Fetch(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled context to abort the request, got %v", err)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	body := `{"node": {"nodeName": "node-1"}}`
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer kubelet.Close()
+
+	client := New(kubelet.URL, staticToken("good"), http.DefaultClient)
+	client.MaxBytes = int64(len(body))
+	if _, err := client.Decode(context.Background()); err != nil {
+		t.Errorf("expected a summary at the limit to decode, got %v", err)
+	}
+	if raw, err := client.Fetch(context.Background()); err != nil || string(raw) != body {
+		t.Errorf("expected the raw summary at the limit, got %q, %v", raw, err)
+	}
+
+	client.MaxBytes = int64(len(body)) - 1
+	for name, get := range map[string]func() error{
+		"decode": func() error { _, err := client.Decode(context.Background()); return err },
+		"fetch":  func() error { _, err := client.Fetch(context.Background()); return err },
+	} {
+		err := get()
+		var fetchErr *Error
+		if !errors.As(err, &fetchErr) || fetchErr.Step != StepRead || !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: expected a read error caused by ErrTooLarge, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package summaryclient
+
+import (
+	"context"
+	"os"
+
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// File provides the summary saved in a JSON file, e.g. recorded from a
+// node, instead of requesting kubelet. It is re-read on every request.
+type File string
+
+// GetRawSummary returns the file's content.
+func (f File) GetRawSummary(context.Context) ([]byte, error) {
+	body, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, &Error{Step: StepRead, Err: err}
+	}
+	return body, nil
+}
+
+// GetSummary returns the summary parsed from the file.
+func (f File) GetSummary(ctx context.Context) (*statsapi.Summary, error) {
+	body, err := f.GetRawSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(body)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package summaryclient
+
+import (
+	"context"
+	"sync"
+
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// Replay provides recorded summaries in turn, one per request, e.g. those
+// saved by the record command. After the last one it starts over if it
+// loops and keeps providing the last one otherwise.
+type Replay struct {
+	files []File
+	loop  bool
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewReplay replays the summaries saved in files, in order.
+func NewReplay(files []string, loop bool) *Replay {
+	r := &Replay{loop: loop}
+	for _, file := range files {
+		r.files = append(r.files, File(file))
+	}
+	return r
+}
+
+// GetRawSummary returns the content of the next file.
+func (r *Replay) GetRawSummary(ctx context.Context) ([]byte, error) {
+	r.mu.Lock()
+	file := r.files[r.next]
+	switch {
+	case r.next < len(r.files)-1:
+		r.next++
+	case r.loop:
+		r.next = 0
+	}
+	r.mu.Unlock()
+
+	return file.GetRawSummary(ctx)
+}
+
+// GetSummary returns the summary parsed from the next file.
+func (r *Replay) GetSummary(ctx context.Context) (*statsapi.Summary, error) {
+	body, err := r.GetRawSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(body)
+}
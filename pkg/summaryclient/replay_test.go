@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package summaryclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, node := range []string{"node-a", "node-b"} {
+		file := filepath.Join(dir, node+".json")
+		if err := os.WriteFile(file, []byte(`{"node": {"nodeName": "`+node+`"}}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	for loop, want := range map[bool][]string{
+		false: {"node-a", "node-b", "node-b"},
+		true:  {"node-a", "node-b", "node-a"},
+	} {
+		replay := NewReplay(files, loop)
+		for i, node := range want {
+			summary, err := replay.GetSummary(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.Node.NodeName != node {
+				t.Errorf("loop %v, request %d: expected %s, got %s", loop, i, node, summary.Node.NodeName)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"net"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// Sources of targets, from the highest precedence to the lowest.
+const (
+	SourceFile  = "file"
+	SourceNodes = "nodes"
+)
+
+var sourcePrecedence = []string{SourceFile, SourceNodes}
+
+// Dedupe combines the targets of several sources, so a node listed in the
+// targets file and discovered through the api-server is scraped once.
+// Targets are the same node when their hosts match, or when one's host or
+// node label matches the other's node label. The target of the source with
+// the highest precedence keeps its address, and its labels win over those of
+// the duplicates; differing values are counted as conflicts.
+type Dedupe struct {
+	logger logging.Logger
+	sync   func([]Target)
+
+	mu           sync.Mutex
+	sources      map[string][]Target
+	duplicates   int
+	conflicts    map[string]struct{}
+	conflictsCnt float64
+
+	duplicatesDesc *prometheus.Desc
+	conflictsDesc  *prometheus.Desc
+}
+
+// NewDedupe passes the combined targets of every source to sync.
+func NewDedupe(logger logging.Logger, sync func([]Target)) *Dedupe {
+	return &Dedupe{
+		logger:    logger,
+		sync:      sync,
+		sources:   map[string][]Target{},
+		conflicts: map[string]struct{}{},
+		duplicatesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "target_duplicates"),
+			"Targets dropped as duplicates of a target of another source or the same one",
+			nil,
+			nil),
+		conflictsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("kubelet_summary_exporter", "", "target_label_conflicts_total"),
+			"Labels of duplicate targets that differed from those of the target kept",
+			nil,
+			nil),
+	}
+}
+
+// Sync returns the function syncing the targets of source.
+func (d *Dedupe) Sync(source string) func([]Target) {
+	return func(targets []Target) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.sources[source] = targets
+		d.sync(d.merge())
+	}
+}
+
+// merge combines the targets of every source in order of precedence.
+func (d *Dedupe) merge() []Target {
+	var merged []Target
+	index := map[string]int{}
+	conflicts := map[string]struct{}{}
+	duplicates := 0
+
+	for _, source := range sourcePrecedence {
+		for _, target := range d.sources[source] {
+			keys := target.keys()
+
+			i, ok := -1, false
+			for _, key := range keys {
+				if i, ok = index[key]; ok {
+					break
+				}
+			}
+
+			if !ok {
+				merged = append(merged, Target{Address: target.Address, Labels: copyLabels(target.Labels)})
+				for _, key := range keys {
+					index[key] = len(merged) - 1
+				}
+				continue
+			}
+
+			duplicates++
+			kept := &merged[i]
+			for name, value := range target.Labels {
+				current, ok := kept.Labels[name]
+				if !ok {
+					kept.Labels[name] = value
+					continue
+				}
+				if current != value {
+					conflict := kept.Address + "/" + name
+					conflicts[conflict] = struct{}{}
+					if _, known := d.conflicts[conflict]; !known {
+						d.conflictsCnt++
+						d.logger.Warn("conflicting label of duplicate target", "target", kept.Address, "duplicate", target.Address,
+							"label", name, "kept", current, "dropped", value)
+					}
+				}
+			}
+			for _, key := range keys {
+				if _, ok := index[key]; !ok {
+					index[key] = i
+				}
+			}
+		}
+	}
+
+	d.duplicates = duplicates
+	d.conflicts = conflicts
+	return merged
+}
+
+// keys returns what identifies the target's node: its host and node label.
+func (t Target) keys() []string {
+	host := t.Address
+	if h, _, err := net.SplitHostPort(t.Address); err == nil {
+		host = h
+	}
+
+	keys := []string{host}
+	if node := t.Labels[NodeLabel]; node != "" && node != host {
+		keys = append(keys, node)
+	}
+	return keys
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for name, value := range labels {
+		copied[name] = value
+	}
+	return copied
+}
+
+func (d *Dedupe) Describe(ch chan<- *prometheus.Desc) {
+	ch <- d.duplicatesDesc
+	ch <- d.conflictsDesc
+}
+
+func (d *Dedupe) Collect(ch chan<- prometheus.Metric) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(d.duplicatesDesc, prometheus.GaugeValue, float64(d.duplicates))
+	ch <- prometheus.MustNewConstMetric(d.conflictsDesc, prometheus.CounterValue, d.conflictsCnt)
+}
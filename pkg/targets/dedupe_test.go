@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+func TestDedupe(t *testing.T) {
+	var synced []Target
+	d := NewDedupe(logging.Nop(), func(targets []Target) { synced = targets })
+
+	d.Sync(SourceNodes)([]Target{
+		{Address: "10.0.0.1", Labels: map[string]string{NodeLabel: "node-a"}},
+		{Address: "10.0.0.2", Labels: map[string]string{NodeLabel: "node-b"}},
+		{Address: "10.0.0.3", Labels: map[string]string{NodeLabel: "node-c"}},
+	})
+	file := d.Sync(SourceFile)
+	file([]Target{
+		{Address: "10.0.0.1:10250", Labels: map[string]string{"zone": "a"}},
+		{Address: "node-b", Labels: map[string]string{"zone": "b", NodeLabel: "b"}},
+		{Address: "10.0.0.4"},
+	})
+
+	want := []Target{
+		{Address: "10.0.0.1:10250", Labels: map[string]string{"zone": "a", NodeLabel: "node-a"}},
+		{Address: "node-b", Labels: map[string]string{"zone": "b", NodeLabel: "b"}},
+		{Address: "10.0.0.4", Labels: map[string]string{}},
+		{Address: "10.0.0.3", Labels: map[string]string{NodeLabel: "node-c"}},
+	}
+	if !reflect.DeepEqual(synced, want) {
+		t.Errorf("expected %v, got %v", want, synced)
+	}
+
+	expected := `
+# HELP kubelet_summary_exporter_target_duplicates Targets dropped as duplicates of a target of another source or the same one
+# TYPE kubelet_summary_exporter_target_duplicates gauge
+kubelet_summary_exporter_target_duplicates 2
+# HELP kubelet_summary_exporter_target_label_conflicts_total Labels of duplicate targets that differed from those of the target kept
+# TYPE kubelet_summary_exporter_target_label_conflicts_total counter
+kubelet_summary_exporter_target_label_conflicts_total 1
+`
+	// A conflict that persists across syncs is counted once.
+	file([]Target{
+		{Address: "10.0.0.1:10250", Labels: map[string]string{"zone": "a"}},
+		{Address: "node-b", Labels: map[string]string{"zone": "b", NodeLabel: "b"}},
+		{Address: "10.0.0.4"},
+	})
+	if err := testutil.CollectAndCompare(d, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	"sigs.k8s.io/yaml"
+)
+
+// fileGroup is a target group of a Prometheus file_sd file.
+type fileGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// ParseFile parses targets in the Prometheus file_sd format, JSON or YAML.
+func ParseFile(data []byte) ([]Target, error) {
+	var groups []fileGroup
+	if err := yaml.UnmarshalStrict(data, &groups); err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for i, group := range groups {
+		for name := range group.Labels {
+			if !model.LabelName(name).IsValid() {
+				return nil, fmt.Errorf("group %d: invalid label name %q", i, name)
+			}
+		}
+		if value, ok := group.Labels[IntervalLabel]; ok {
+			if _, err := model.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("group %d: invalid %s: %w", i, IntervalLabel, err)
+			}
+		}
+
+		for _, address := range group.Targets {
+			if address == "" {
+				return nil, fmt.Errorf("group %d: empty target", i)
+			}
+			targets = append(targets, Target{Address: address, Labels: group.Labels})
+		}
+	}
+
+	return targets, nil
+}
+
+// WatchFile reads targets from path and passes them to sync, then re-reads
+// the file every interval and syncs again whenever it changed, until ctx is
+// done. A file that can't be read or parsed keeps the previous targets.
+func WatchFile(ctx context.Context, logger logging.Logger, path string, interval time.Duration, sync func([]Target)) error {
+	var last []byte
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		data, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+			logger.Error("failed to read targets file", "file", path, "error", err)
+		case last != nil && bytes.Equal(data, last):
+		default:
+			targets, err := ParseFile(data)
+			if err != nil {
+				logger.Error("failed to parse targets file", "file", path, "error", err)
+				break
+			}
+
+			last = data
+			sync(targets)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// NodeLabel is the label holding a discovered target's node name.
+const NodeLabel = "node"
+
+// NodeLister lists the cluster's nodes.
+type NodeLister interface {
+	List() []*v1.Node
+}
+
+// FromNodes returns a target per node at its internal IP, or its hostname
+// when it has none. Nodes without either are skipped.
+func FromNodes(nodes []*v1.Node) []Target {
+	var targets []Target
+	for _, node := range nodes {
+		address := nodeAddress(node, v1.NodeInternalIP)
+		if address == "" {
+			address = nodeAddress(node, v1.NodeHostName)
+		}
+		if address == "" {
+			continue
+		}
+
+		targets = append(targets, Target{
+			Address: address,
+			Labels:  map[string]string{NodeLabel: node.Name},
+		})
+	}
+
+	return targets
+}
+
+func nodeAddress(node *v1.Node, addressType v1.NodeAddressType) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == addressType {
+			return addr.Address
+		}
+	}
+	return ""
+}
+
+// WatchNodes passes a target per node to sync every interval, until ctx is
+// done.
+func WatchNodes(ctx context.Context, nodes NodeLister, interval time.Duration, sync func([]Target)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sync(FromNodes(nodes.List()))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
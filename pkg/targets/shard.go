@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Shard is the share of targets one of several exporter replicas scrapes.
+// Targets are assigned by a hash of their node name, or of their host when
+// they have none, so every replica agrees on the assignment without
+// coordinating.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// Owns reports whether target belongs to the shard.
+func (s Shard) Owns(target Target) bool {
+	if s.Count <= 1 {
+		return true
+	}
+
+	key := target.Labels[NodeLabel]
+	if key == "" {
+		key = target.keys()[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32()%uint32(s.Count)) == s.Index
+}
+
+// Sync returns sync for the targets of the shard.
+func (s Shard) Sync(sync func([]Target)) func([]Target) {
+	return func(targets []Target) {
+		var owned []Target
+		for _, target := range targets {
+			if s.Owns(target) {
+				owned = append(owned, target)
+			}
+		}
+		sync(owned)
+	}
+}
+
+// Ordinal returns the ordinal a StatefulSet gives the pod named hostname,
+// its suffix after the last dash.
+func Ordinal(hostname string) (int, error) {
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0, fmt.Errorf("%q has no StatefulSet ordinal", hostname)
+	}
+
+	ordinal, err := strconv.Atoi(hostname[i+1:])
+	if err != nil || ordinal < 0 {
+		return 0, fmt.Errorf("%q has no StatefulSet ordinal", hostname)
+	}
+	return ordinal, nil
+}
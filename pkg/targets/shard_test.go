@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShard(t *testing.T) {
+	var all []Target
+	for i := 0; i < 100; i++ {
+		all = append(all, Target{Address: fmt.Sprintf("10.0.0.%d", i), Labels: map[string]string{NodeLabel: fmt.Sprintf("node-%d", i)}})
+	}
+
+	owners := map[string]int{}
+	for index := 0; index < 3; index++ {
+		var owned []Target
+		Shard{Index: index, Count: 3}.Sync(func(targets []Target) { owned = targets })(all)
+		if len(owned) == 0 {
+			t.Errorf("expected shard %d to own targets", index)
+		}
+		for _, target := range owned {
+			owners[target.Address]++
+		}
+	}
+
+	for _, target := range all {
+		if owners[target.Address] != 1 {
+			t.Errorf("expected %s to be owned by one shard, got %d", target.Address, owners[target.Address])
+		}
+	}
+
+	// The node name decides, whatever the address.
+	a := Target{Address: "10.0.0.1", Labels: map[string]string{NodeLabel: "node-a"}}
+	b := Target{Address: "10.0.9.9:10250", Labels: map[string]string{NodeLabel: "node-a"}}
+	for index := 0; index < 3; index++ {
+		s := Shard{Index: index, Count: 3}
+		if s.Owns(a) != s.Owns(b) {
+			t.Errorf("expected targets of the same node in the same shard")
+		}
+	}
+
+	if !(Shard{}).Owns(a) {
+		t.Error("expected a single shard to own every target")
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for hostname, want := range map[string]int{"exporter-0": 0, "kubelet-summary-exporter-12": 12} {
+		if got, err := Ordinal(hostname); err != nil || got != want {
+			t.Errorf("expected ordinal %d of %s, got %d (%v)", want, hostname, got, err)
+		}
+	}
+	for _, hostname := range []string{"exporter", "exporter-abc12", "exporter-"} {
+		if _, err := Ordinal(hostname); err == nil {
+			t.Errorf("expected %s to have no ordinal", hostname)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package targets lets a central exporter scrape many kubelets, whose
+// addresses come from a target source rather than a single --node-host.
+package targets
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+)
+
+// TargetLabel is the label holding a target's address on its metrics.
+const TargetLabel = "target"
+
+// IntervalLabel is the meta label setting how often a target is scraped in
+// the background, as in Prometheus service discovery.
+const IntervalLabel = "__scrape_interval__"
+
+// Target is a kubelet to scrape.
+type Target struct {
+	// Address is the kubelet's host, optionally with a port.
+	Address string
+	// Labels are added to every metric of the target.
+	Labels map[string]string
+}
+
+// Interval returns how often the target is scraped in the background: its
+// IntervalLabel, or fallback without one. 0 scrapes it on every gather.
+func (t Target) Interval(fallback time.Duration) time.Duration {
+	value, ok := t.Labels[IntervalLabel]
+	if !ok {
+		return fallback
+	}
+	interval, err := model.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return time.Duration(interval)
+}
+
+// labels returns the labels added to the target's metrics, sorted by name.
+// Labels starting with __ are meta labels, as in Prometheus service
+// discovery, and are dropped.
+func (t Target) labels() []*dto.LabelPair {
+	labels := []*dto.LabelPair{{Name: stringPtr(TargetLabel), Value: stringPtr(t.Address)}}
+	for name, value := range t.Labels {
+		if !strings.HasPrefix(name, "__") && name != TargetLabel {
+			labels = append(labels, &dto.LabelPair{Name: stringPtr(name), Value: stringPtr(value)})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+	return labels
+}
+
+type registration struct {
+	target    Target
+	collector prometheus.Collector
+	registry  *prometheus.Registry
+	// cancel stops the collector's loop, if it has one and it runs.
+	cancel context.CancelFunc
+}
+
+// runner is a collector with a loop of its own, like a scraper.Cache.
+type runner interface {
+	Run(ctx context.Context) error
+}
+
+// refresher is a collector serving metrics scraped in the background, which
+// can be scraped now.
+type refresher interface {
+	Refresh()
+}
+
+// start runs the collector's loop, if it has one, until ctx is done or the
+// registration is stopped.
+func (r *registration) start(ctx context.Context) {
+	loop, ok := r.collector.(runner)
+	if !ok || r.cancel != nil {
+		return
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	go func() {
+		_ = loop.Run(ctx)
+	}()
+}
+
+// stop stops the collector's loop.
+func (r *registration) stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// contextGatherer is a collector whose scrapes can be bounded by a context,
+// like a scraper.
+type contextGatherer interface {
+	GatherContext(ctx context.Context) ([]*dto.MetricFamily, error)
+}
+
+// gather gathers the registration, passing ctx to its collector if it
+// supports it.
+func (r *registration) gather(ctx context.Context) ([]*dto.MetricFamily, error) {
+	if cg, ok := r.collector.(contextGatherer); ok {
+		return cg.GatherContext(ctx)
+	}
+	return r.registry.Gather()
+}
+
+// Manager gathers one collector per target, each in its own registry so
+// targets can carry different labels. Targets are gathered concurrently.
+// Collectors with a loop of their own, like a scraper.Cache, run while the
+// manager does.
+type Manager struct {
+	logger       logging.Logger
+	newCollector func(Target) prometheus.Collector
+
+	mu      sync.Mutex
+	targets map[string]*registration
+	// ctx is the context of Run, nil until the manager runs.
+	ctx context.Context
+}
+
+var _ prometheus.Gatherer = (*Manager)(nil)
+
+// NewManager gathers the collectors built by newCollector.
+func NewManager(logger logging.Logger, newCollector func(Target) prometheus.Collector) *Manager {
+	return &Manager{
+		logger:       logger,
+		newCollector: newCollector,
+		targets:      map[string]*registration{},
+	}
+}
+
+// Run runs the loops of the collectors, of current targets and of those
+// synced later, until ctx is done.
+func (m *Manager) Run(ctx context.Context) error {
+	m.mu.Lock()
+	m.ctx = ctx
+	for _, r := range m.targets {
+		r.start(ctx)
+	}
+	m.mu.Unlock()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Sync builds collectors for new targets and drops those of targets that
+// are gone. Targets whose labels changed keep their collector, unless their
+// IntervalLabel changed.
+func (m *Manager) Sync(targets []Target) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := map[string]Target{}
+	for _, target := range targets {
+		wanted[target.Address] = target
+	}
+
+	for address := range m.targets {
+		if _, ok := wanted[address]; !ok {
+			m.targets[address].stop()
+			delete(m.targets, address)
+			m.logger.Info("removed target", "target", address)
+		}
+	}
+
+	for address, target := range wanted {
+		if r, ok := m.targets[address]; ok {
+			if r.target.Labels[IntervalLabel] == target.Labels[IntervalLabel] {
+				if !maps.Equal(r.target.Labels, target.Labels) {
+					m.targets[address] = &registration{target: target, collector: r.collector, registry: r.registry, cancel: r.cancel}
+				}
+				continue
+			}
+			r.stop()
+		}
+
+		collector := m.newCollector(target)
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(collector); err != nil {
+			m.logger.Error("failed to register target", "target", address, "error", err)
+			continue
+		}
+
+		r := &registration{target: target, collector: collector, registry: registry}
+		if m.ctx != nil {
+			r.start(m.ctx)
+		}
+		m.targets[address] = r
+		m.logger.Info("added target", "target", address)
+	}
+}
+
+// Targets returns the addresses of the current targets, sorted.
+func (m *Manager) Targets() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	addresses := make([]string, 0, len(m.targets))
+	for address := range m.targets {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// Gather gathers every target and merges their metric families, adding the
+// target's labels to each metric. Labels a metric already has win over the
+// target's.
+func (m *Manager) Gather() ([]*dto.MetricFamily, error) {
+	return m.GatherContext(context.Background())
+}
+
+// GatherContext is Gather with the scrapes of the targets bounded by ctx.
+func (m *Manager) GatherContext(ctx context.Context) ([]*dto.MetricFamily, error) {
+	m.mu.Lock()
+	registrations := make([]*registration, 0, len(m.targets))
+	for _, r := range m.targets {
+		registrations = append(registrations, r)
+	}
+	m.mu.Unlock()
+
+	return gather(ctx, registrations)
+}
+
+// Gatherer returns a gatherer of the single target with the given address
+// or node name. Targets scraped in the background are scraped again first.
+func (m *Manager) Gatherer(target string) (prometheus.Gatherer, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for address, r := range m.targets {
+		if address == target || r.target.Labels[NodeLabel] == target {
+			return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+				if cached, ok := r.collector.(refresher); ok {
+					cached.Refresh()
+				}
+				return gather(context.Background(), []*registration{r})
+			}), true
+		}
+	}
+
+	return nil, false
+}
+
+// Lookup returns the target with the given address or node name.
+func (m *Manager) Lookup(target string) (Target, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for address, r := range m.targets {
+		if address == target || r.target.Labels[NodeLabel] == target {
+			return r.target, true
+		}
+	}
+
+	return Target{}, false
+}
+
+func gather(ctx context.Context, registrations []*registration) ([]*dto.MetricFamily, error) {
+	gathered := make([][]*dto.MetricFamily, len(registrations))
+	errs := make(prometheus.MultiError, len(registrations))
+
+	var wg sync.WaitGroup
+	for i, r := range registrations {
+		wg.Add(1)
+		go func(i int, r *registration) {
+			defer wg.Done()
+			gathered[i], errs[i] = r.gather(ctx)
+		}(i, r)
+	}
+	wg.Wait()
+
+	byName := map[string]*dto.MetricFamily{}
+	for i, families := range gathered {
+		labels := registrations[i].target.labels()
+		for _, family := range families {
+			merged, ok := byName[family.GetName()]
+			if !ok {
+				merged = &dto.MetricFamily{Name: family.Name, Help: family.Help, Type: family.Type}
+				byName[family.GetName()] = merged
+			}
+
+			for _, metric := range family.Metric {
+				metric.Label = withLabels(metric.Label, labels)
+				merged.Metric = append(merged.Metric, metric)
+			}
+		}
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(byName))
+	for _, family := range byName {
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+
+	var nonNil prometheus.MultiError
+	for _, err := range errs {
+		nonNil.Append(err)
+	}
+
+	return families, nonNil.MaybeUnwrap()
+}
+
+// withLabels adds the labels to a metric's, both sorted by name, skipping
+// the ones the metric already has.
+func withLabels(metric, labels []*dto.LabelPair) []*dto.LabelPair {
+	merged := make([]*dto.LabelPair, 0, len(metric)+len(labels))
+	i, j := 0, 0
+	for i < len(metric) || j < len(labels) {
+		switch {
+		case j == len(labels) || i < len(metric) && metric[i].GetName() < labels[j].GetName():
+			merged = append(merged, metric[i])
+			i++
+		case i == len(metric) || labels[j].GetName() < metric[i].GetName():
+			merged = append(merged, labels[j])
+			j++
+		default:
+			merged = append(merged, metric[i])
+			i++
+			j++
+		}
+	}
+
+	return merged
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package targets
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/salesforce/kubelet-summary-exporter/pkg/logging"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func gatheredTargets(t *testing.T, m *Manager) []string {
+	families, err := m.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var targets []string
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			targets = append(targets, strings.Join(labels, ","))
+		}
+	}
+	sort.Strings(targets)
+
+	return targets
+}
+
+func TestManager(t *testing.T) {
+	built := 0
+	m := NewManager(logging.Nop(), func(Target) prometheus.Collector {
+		built++
+		return prometheus.NewGauge(prometheus.GaugeOpts{Name: "up", ConstLabels: prometheus.Labels{"zone": "own"}})
+	})
+
+	m.Sync([]Target{
+		{Address: "10.0.0.1", Labels: map[string]string{"pool": "a", "zone": "a", "__meta_ignored": "x"}},
+		{Address: "10.0.0.2", Labels: map[string]string{"pool": "b"}},
+	})
+	if got, want := gatheredTargets(t, m), []string{"pool=a,target=10.0.0.1,zone=own", "pool=b,target=10.0.0.2,zone=own"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	m.Sync([]Target{
+		{Address: "10.0.0.2", Labels: map[string]string{"pool": "c"}},
+		{Address: "10.0.0.3"},
+	})
+	if got, want := gatheredTargets(t, m), []string{"pool=c,target=10.0.0.2,zone=own", "target=10.0.0.3,zone=own"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if built != 3 {
+		t.Errorf("expected 3 collectors to be built, got %d", built)
+	}
+
+	m.Sync([]Target{{Address: "10.0.0.3"}})
+	if got, want := m.Targets(), []string{"10.0.0.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if built != 3 {
+		t.Errorf("remaining targets should keep their collector, %d built", built)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	want := []Target{
+		{Address: "10.0.0.1", Labels: map[string]string{"zone": "a"}},
+		{Address: "10.0.0.2:10250", Labels: map[string]string{"zone": "a"}},
+		{Address: "10.0.0.3"},
+	}
+
+	for _, data := range []string{
+		`[{"targets": ["10.0.0.1", "10.0.0.2:10250"], "labels": {"zone": "a"}}, {"targets": ["10.0.0.3"]}]`,
+		`
+- targets: [10.0.0.1, "10.0.0.2:10250"]
+  labels:
+    zone: a
+- targets: [10.0.0.3]
+`,
+	} {
+		got, err := ParseFile([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	if _, err := ParseFile([]byte(`[{"target": ["10.0.0.1"]}]`)); err == nil {
+		t.Errorf("expected unknown fields to be rejected")
+	}
+
+	if _, err := ParseFile([]byte(`[{"targets": ["10.0.0.1"], "labels": {"node-pool": "a"}}]`)); err == nil {
+		t.Errorf("expected invalid label names to be rejected")
+	}
+
+	for _, interval := range []string{"10", "-10s", "fast"} {
+		if _, err := ParseFile([]byte(`[{"targets": ["10.0.0.1"], "labels": {"__scrape_interval__": "` + interval + `"}}]`)); err == nil {
+			t.Errorf("expected interval %q to be rejected", interval)
+		}
+	}
+}
+
+// loopCollector is a collector with a loop, counting the loops running and
+// the refreshes.
+type loopCollector struct {
+	prometheus.Collector
+	interval  time.Duration
+	running   *atomic.Int32
+	refreshed atomic.Int32
+}
+
+func (c *loopCollector) Run(ctx context.Context) error {
+	c.running.Add(1)
+	defer c.running.Add(-1)
+	<-ctx.Done()
+	return nil
+}
+
+func (c *loopCollector) Refresh() {
+	c.refreshed.Add(1)
+}
+
+func TestManagerIntervals(t *testing.T) {
+	var running atomic.Int32
+	built := map[string]*loopCollector{}
+	rebuilt := 0
+	m := NewManager(logging.Nop(), func(target Target) prometheus.Collector {
+		c := &loopCollector{
+			Collector: prometheus.NewGauge(prometheus.GaugeOpts{Name: "up"}),
+			interval:  target.Interval(time.Minute),
+			running:   &running,
+		}
+		if _, ok := built[target.Address]; ok {
+			rebuilt++
+		}
+		built[target.Address] = c
+		return c
+	})
+	waitRunning := func(want int32) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); running.Load() != want; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d loops running, got %d", want, running.Load())
+			}
+		}
+	}
+
+	m.Sync([]Target{
+		{Address: "10.0.0.1", Labels: map[string]string{IntervalLabel: "10s"}},
+		{Address: "10.0.0.2"},
+	})
+	if got := built["10.0.0.1"].interval; got != 10*time.Second {
+		t.Errorf("expected the group's interval, got %s", got)
+	}
+	if got := built["10.0.0.2"].interval; got != time.Minute {
+		t.Errorf("expected the fallback interval, got %s", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = m.Run(ctx)
+		close(done)
+	}()
+	waitRunning(2)
+
+	// Changing the interval rebuilds the collector, other labels don't.
+	m.Sync([]Target{
+		{Address: "10.0.0.1", Labels: map[string]string{IntervalLabel: "1m", "pool": "gpu"}},
+		{Address: "10.0.0.2", Labels: map[string]string{"pool": "batch"}},
+		{Address: "10.0.0.3"},
+	})
+	if rebuilt != 1 {
+		t.Errorf("expected 1 collector to be rebuilt, got %d", rebuilt)
+	}
+	if got := built["10.0.0.1"].interval; got != time.Minute {
+		t.Errorf("expected the new interval, got %s", got)
+	}
+	waitRunning(3)
+
+	m.Sync([]Target{{Address: "10.0.0.3"}})
+	waitRunning(1)
+
+	gatherer, ok := m.Gatherer("10.0.0.3")
+	if !ok {
+		t.Fatal("expected a gatherer of the target")
+	}
+	if _, err := gatherer.Gather(); err != nil {
+		t.Fatal(err)
+	}
+	if n := built["10.0.0.3"].refreshed.Load(); n != 1 {
+		t.Errorf("expected scraping the target now to refresh it once, got %d", n)
+	}
+
+	cancel()
+	<-done
+	waitRunning(0)
+}
+
+func TestFromNodes(t *testing.T) {
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: "a.internal"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: "b.internal"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "c"},
+		},
+	}
+
+	want := []Target{
+		{Address: "10.0.0.1", Labels: map[string]string{NodeLabel: "a"}},
+		{Address: "b.internal", Labels: map[string]string{NodeLabel: "b"}},
+	}
+	if got := FromNodes(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestNewTransport(t *testing.T) {
+	config := &tls.Config{ServerName: "push.example.com"}
+	transport := NewTransport(config)
+
+	if transport.TLSClientConfig != config {
+		t.Errorf("expected the given tls config, got %+v", transport.TLSClientConfig)
+	}
+	if transport.Proxy == nil {
+		t.Errorf("expected the proxy of the environment")
+	}
+	if http.RoundTripper(transport) == http.DefaultTransport || transport == NewTransport(nil) {
+		t.Errorf("expected a transport of its own")
+	}
+}
@@ -2,65 +2,52 @@ package utils
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
-	"net"
-	"net/http"
-	"os"
-	"time"
 
-	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
-func ConfigureTLS(logger *zap.Logger, certAuthorityFile string, insecure bool, nodeHost string) error {
-	// Set the root CA pool
-	cadata, err := os.ReadFile(certAuthorityFile)
+// ClientsetFromCluster builds a Kubernetes clientset from incluster config,
+// or from the kubeconfig file when set
+func ClientsetFromCluster(kubeconfig string) (kubernetes.Interface, error) {
+	kubeConfig, err := RESTConfig(kubeconfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	certs := x509.NewCertPool()
-	if !certs.AppendCertsFromPEM(cadata) {
-		return fmt.Errorf("failed to append certs from pem")
-	}
-
-	newTlsConfig := &tls.Config{}
-	newTlsConfig.RootCAs = certs
-
-	if insecure {
-		logger.Warn("using insecure tls")
-
-		newTlsConfig.InsecureSkipVerify = insecure
-	}
-
-	defaultTransport := http.DefaultTransport.(*http.Transport)
-	defaultTransport.TLSClientConfig = newTlsConfig
-
-	dialer := &net.Dialer{
-		Timeout:   2 * time.Second,
-		KeepAlive: 2 * time.Second,
-	}
+	return kubernetes.NewForConfig(kubeConfig)
+}
 
-	defaultTransport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-		return dialer.DialContext(ctx, network, fmt.Sprintf("%s:10250", nodeHost))
+// RESTConfig loads the api-server config from a kubeconfig file, or the
+// incluster config when kubeconfig is empty
+func RESTConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig == "" {
+		return rest.InClusterConfig()
 	}
 
-	return nil
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
 }
 
-// ServerAddrFromCluster uses incluster config to determine a node's Hostname
-func ServerAddrFromCluster(nodeHost string) (string, error) {
-	kubeConfig, err := rest.InClusterConfig()
+// DynamicClientFromCluster builds a Kubernetes dynamic client from incluster
+// config, or from the kubeconfig file when set
+func DynamicClientFromCluster(kubeconfig string) (dynamic.Interface, error) {
+	kubeConfig, err := RESTConfig(kubeconfig)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(kubeConfig)
+	return dynamic.NewForConfig(kubeConfig)
+}
+
+// ServerAddrFromCluster uses incluster config, or the kubeconfig file when
+// set, to determine a node's Hostname
+func ServerAddrFromCluster(kubeconfig, nodeHost string) (string, error) {
+	clientset, err := ClientsetFromCluster(kubeconfig)
 	if err != nil {
 		return "", err
 	}
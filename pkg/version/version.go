@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package version describes the build of the exporter.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Version is the release of the exporter, set at build time with
+//
+//	-ldflags "-X github.com/salesforce/kubelet-summary-exporter/pkg/version.Version=v1.2.3"
+var Version = "dev"
+
+// GoVersion is the Go release the exporter was built with.
+var GoVersion = runtime.Version()
+
+// Revision returns the VCS revision the exporter was built from, suffixed
+// with -dirty for uncommitted changes, or unknown when it wasn't recorded.
+func Revision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	revision, modified := "unknown", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// String describes the build for humans.
+func String() string {
+	return fmt.Sprintf("kubelet-summary-exporter %s (revision %s, %s)", Version, Revision(), GoVersion)
+}
+
+// NewCollector returns a collector of kubelet_summary_exporter_build_info,
+// always 1, labeled with the build, so changes in the metrics can be joined
+// with the deployed versions.
+func NewCollector() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "kubelet_summary_exporter",
+		Name:      "build_info",
+		Help:      "Build of the exporter, always 1",
+		ConstLabels: prometheus.Labels{
+			"version":   Version,
+			"revision":  Revision(),
+			"goversion": GoVersion,
+		},
+	}, func() float64 { return 1 })
+}
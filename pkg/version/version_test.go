@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package version
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	Version = "v1.2.3"
+	defer func() { Version = "dev" }()
+
+	expected := fmt.Sprintf(`
+# HELP kubelet_summary_exporter_build_info Build of the exporter, always 1
+# TYPE kubelet_summary_exporter_build_info gauge
+kubelet_summary_exporter_build_info{goversion=%q,revision=%q,version="v1.2.3"} 1
+`, GoVersion, Revision())
+	if err := testutil.CollectAndCompare(NewCollector(), strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package webconfig
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against for unknown users, so the response time
+// doesn't tell which users exist.
+var dummyHash = []byte("$2a$10$UlZTCPpnaCJYr2.ILX8h4eTPIKx7mR0upIkCi5gjvZdfyBA7bVL9O")
+
+func checkHash(hash string) error {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err
+}
+
+// basicAuth requires the credentials of one of users, whose passwords are
+// bcrypt hashes. Successful checks are cached as bcrypt is deliberately
+// slow and Prometheus sends the same credentials on every scrape.
+func basicAuth(users map[string]string, next http.Handler) http.Handler {
+	var mu sync.Mutex
+	verified := map[[sha256.Size]byte]struct{}{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if ok {
+			key := sha256.Sum256([]byte(user + ":" + password))
+			mu.Lock()
+			_, cached := verified[key]
+			mu.Unlock()
+
+			if cached {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			hash, known := users[user]
+			if !known {
+				hash = string(dummyHash)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil && known {
+				mu.Lock()
+				verified[key] = struct{}{}
+				mu.Unlock()
+
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="kubelet-summary-exporter"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package webconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// listenFDsStart is the first file descriptor systemd passes sockets as.
+const listenFDsStart = 3
+
+var (
+	systemdOnce  sync.Once
+	systemdFiles []*os.File
+	systemdErr   error
+)
+
+// SystemdListeners returns the sockets systemd activated the process with,
+// following sd_listen_fds(3). The sockets are kept open, so each call
+// returns new listeners on them, e.g. to serve again after a reload.
+func SystemdListeners() ([]net.Listener, error) {
+	systemdOnce.Do(func() {
+		systemdFiles, systemdErr = systemdSockets()
+	})
+	if systemdErr != nil {
+		return nil, systemdErr
+	}
+
+	listeners := make([]net.Listener, 0, len(systemdFiles))
+	for _, f := range systemdFiles {
+		// FileListener dups the socket, closing the listener leaves it open.
+		l, err := net.FileListener(f)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+func systemdSockets() ([]*os.File, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("not socket activated by systemd, LISTEN_PID isn't this process")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, errors.New("no sockets passed by systemd in LISTEN_FDS")
+	}
+
+	files := make([]*os.File, 0, count)
+	for fd := listenFDsStart; fd < listenFDsStart+count; fd++ {
+		files = append(files, os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd)))
+	}
+	return files, nil
+}
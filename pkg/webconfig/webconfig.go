@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package webconfig serves HTTP listeners as configured by a web config
+// file in prometheus/exporter-toolkit's format, so the exporter's endpoints
+// can be served over TLS, with client certificates or basic auth, like
+// other Prometheus exporters.
+package webconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config is a web config file.
+type Config struct {
+	TLSServerConfig  *TLSConfig        `json:"tls_server_config,omitempty"`
+	HTTPServerConfig HTTPConfig        `json:"http_server_config,omitempty"`
+	BasicAuthUsers   map[string]string `json:"basic_auth_users,omitempty"`
+}
+
+// TLSConfig enables TLS. Relative paths are relative to the config file.
+type TLSConfig struct {
+	CertFile       string `json:"cert_file"`
+	KeyFile        string `json:"key_file"`
+	ClientAuthType string `json:"client_auth_type,omitempty"`
+	ClientCAFile   string `json:"client_ca_file,omitempty"`
+	MinVersion     string `json:"min_version,omitempty"`
+	MaxVersion     string `json:"max_version,omitempty"`
+}
+
+// HTTPConfig configures the HTTP server.
+type HTTPConfig struct {
+	// HTTP2 enables HTTP/2 over TLS, the default.
+	HTTP2 *bool `json:"http2,omitempty"`
+	// Headers are added to every response.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                           tls.NoClientCert,
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// Load reads and validates the web config file at path, loading the
+// certificates it names.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if t := config.TLSServerConfig; t != nil {
+		dir := filepath.Dir(path)
+		for _, file := range []*string{&t.CertFile, &t.KeyFile, &t.ClientCAFile} {
+			if *file != "" && !filepath.IsAbs(*file) {
+				*file = filepath.Join(dir, *file)
+			}
+		}
+	}
+
+	if _, err := config.tlsConfig(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for user, hash := range config.BasicAuthUsers {
+		if err := checkHash(hash); err != nil {
+			return nil, fmt.Errorf("%s: basic auth user %q: %w", path, user, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// tlsConfig returns the TLS configuration of the listeners, nil without
+// TLS. The certificate is re-read on each handshake so renewed certificates
+// are served without a restart.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	t := c.TLSServerConfig
+	if t == nil {
+		return nil, nil
+	}
+
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, errors.New("tls_server_config needs cert_file and key_file")
+	}
+	if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+		return nil, fmt.Errorf("tls_server_config: %w", err)
+	}
+
+	clientAuth, ok := clientAuthTypes[t.ClientAuthType]
+	if !ok {
+		return nil, fmt.Errorf("unknown client_auth_type %q", t.ClientAuthType)
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+
+	if t.MinVersion != "" {
+		if config.MinVersion, ok = tlsVersions[t.MinVersion]; !ok {
+			return nil, fmt.Errorf("unknown min_version %q", t.MinVersion)
+		}
+	}
+	if t.MaxVersion != "" {
+		if config.MaxVersion, ok = tlsVersions[t.MaxVersion]; !ok {
+			return nil, fmt.Errorf("unknown max_version %q", t.MaxVersion)
+		}
+	}
+
+	if t.ClientCAFile != "" {
+		pem, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client_ca_file: %w", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client_ca_file: no certificates in %s", t.ClientCAFile)
+		}
+	} else if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		return nil, fmt.Errorf("client_auth_type %s needs client_ca_file", t.ClientAuthType)
+	}
+
+	return config, nil
+}
+
+// Configure configures srv to serve as configured, wrapping its handler.
+// A nil Config leaves srv serving plain HTTP.
+func (c *Config) Configure(srv *http.Server) error {
+	if c == nil {
+		return nil
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	srv.Handler = c.handler(srv.Handler)
+	srv.TLSConfig = tlsConfig
+	if tlsConfig != nil && c.HTTPServerConfig.HTTP2 != nil && !*c.HTTPServerConfig.HTTP2 {
+		// A non-nil, empty TLSNextProto disables HTTP/2.
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return nil
+}
+
+// Serve serves srv on l, over TLS if Configure enabled it.
+func Serve(l net.Listener, srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(l, "", "")
+	}
+	return srv.Serve(l)
+}
+
+// handler adds the configured headers and basic auth to next.
+func (c *Config) handler(next http.Handler) http.Handler {
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+	if len(c.BasicAuthUsers) > 0 {
+		next = basicAuth(c.BasicAuthUsers, next)
+	}
+	if len(c.HTTPServerConfig.Headers) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range c.HTTPServerConfig.Headers {
+			w.Header().Set(name, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
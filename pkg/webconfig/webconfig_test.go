@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package webconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// writeCert writes a self-signed certificate for 127.0.0.1 to dir.
+func writeCert(t *testing.T, dir string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kubelet-summary-exporter"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(t, filepath.Join(dir, "tls.crt"), string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	write(t, filepath.Join(dir, "tls.key"), string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func write(t *testing.T, path, data string) {
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve serves an OK handler as configured by the web config data.
+func serve(t *testing.T, dir, data string) string {
+	path := filepath.Join(dir, "web.yaml")
+	write(t, path, data)
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	})}
+	if err := config.Configure(srv); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = Serve(l, srv) }()
+	t.Cleanup(func() { srv.Close() })
+
+	return l.Addr().String()
+}
+
+func TestTLSAndBasicAuth(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeCert(t, dir)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := serve(t, dir, `
+tls_server_config:
+  cert_file: tls.crt
+  key_file: tls.key
+http_server_config:
+  headers:
+    X-Frame-Options: deny
+basic_auth_users:
+  prometheus: `+string(hash)+`
+`)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+
+	for _, tc := range []struct {
+		user, password string
+		status         int
+	}{
+		{"prometheus", "secret", http.StatusOK},
+		{"prometheus", "wrong", http.StatusUnauthorized},
+		{"unknown", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "https://"+addr+"/metrics", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.user != "" {
+			req.SetBasicAuth(tc.user, tc.password)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s:%s: expected status %d, got %d", tc.user, tc.password, tc.status, resp.StatusCode)
+		}
+		if got := resp.Header.Get("X-Frame-Options"); got != "deny" {
+			t.Errorf("expected the configured header, got %q", got)
+		}
+		if tc.status == http.StatusOK && resp.Header.Get("X-Proto") != "HTTP/2.0" {
+			t.Errorf("expected HTTP/2, got %s", resp.Header.Get("X-Proto"))
+		}
+	}
+
+	if resp, err := http.Get("http://" + addr + "/metrics"); err == nil && resp.StatusCode == http.StatusOK {
+		t.Error("expected plain HTTP to be refused")
+	}
+}
+
+func TestDisableHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeCert(t, dir)
+
+	addr := serve(t, dir, `
+tls_server_config:
+  cert_file: tls.crt
+  key_file: tls.key
+http_server_config:
+  http2: false
+`)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+
+	resp, err := client.Get("https://" + addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get("X-Proto"); got != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1, got %s", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeCert(t, dir)
+
+	for name, data := range map[string]string{
+		"unknown field":      "tls_server_config:\n  cert: tls.crt\n",
+		"missing key":        "tls_server_config:\n  cert_file: tls.crt\n",
+		"missing cert":       "tls_server_config:\n  cert_file: missing.crt\n  key_file: tls.key\n",
+		"client auth":        "tls_server_config:\n  cert_file: tls.crt\n  key_file: tls.key\n  client_auth_type: Sometimes\n",
+		"client CA needed":   "tls_server_config:\n  cert_file: tls.crt\n  key_file: tls.key\n  client_auth_type: RequireAndVerifyClientCert\n",
+		"TLS version":        "tls_server_config:\n  cert_file: tls.crt\n  key_file: tls.key\n  min_version: SSL3\n",
+		"plaintext password": "basic_auth_users:\n  prometheus: secret\n",
+	} {
+		path := filepath.Join(dir, "web.yaml")
+		write(t, path, data)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//go:build e2e
+
+/*
+ * Copyright (c) 2022, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package e2e deploys the exporter into kind clusters running different
+// Kubernetes versions and checks the metrics it serves, to catch drift of the
+// kubelet stats API. It needs docker, kind and kubectl on the PATH:
+//
+//	make e2e
+//	KIND_NODE_IMAGES=kindest/node:v1.30.0 make e2e
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const image = "kubelet-summary-exporter:e2e"
+
+// defaultNodeImages are the Kubernetes versions tested unless
+// KIND_NODE_IMAGES overrides them.
+var defaultNodeImages = []string{
+	"kindest/node:v1.29.8",
+	"kindest/node:v1.30.4",
+	"kindest/node:v1.31.0",
+}
+
+// families are metric families every supported kubelet must produce.
+var families = map[string]dto.MetricType{
+	"kubelet_summary_node_cpu_usage_nano_cores":                  dto.MetricType_GAUGE,
+	"kubelet_summary_node_memory_working_set_bytes":              dto.MetricType_GAUGE,
+	"kubelet_summary_node_fs_usage_bytes":                        dto.MetricType_GAUGE,
+	"kubelet_summary_node_runtime_image_fs_usage_bytes":          dto.MetricType_GAUGE,
+	"kubelet_summary_node_interface_rx_bytes":                    dto.MetricType_GAUGE,
+	"kubelet_summary_pod_cpu_usage_nano_cores":                   dto.MetricType_GAUGE,
+	"kubelet_summary_pod_memory_working_set_bytes":               dto.MetricType_GAUGE,
+	"kubelet_summary_pod_ephemeral_storage_usage_bytes":          dto.MetricType_GAUGE,
+	"kubelet_summary_container_cpu_usage_nano_cores":             dto.MetricType_GAUGE,
+	"kubelet_summary_container_fs_usage_bytes":                   dto.MetricType_GAUGE,
+	"kubelet_summary_exporter_scrape_duration_seconds":           dto.MetricType_HISTOGRAM,
+	"kubelet_summary_node_system_container_cpu_usage_nano_cores": dto.MetricType_GAUGE,
+}
+
+func TestKind(t *testing.T) {
+	images := defaultNodeImages
+	if env := os.Getenv("KIND_NODE_IMAGES"); env != "" {
+		images = strings.Split(env, ",")
+	}
+
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, root, "docker", "build", "-t", image, ".")
+
+	for _, nodeImage := range images {
+		nodeImage := nodeImage
+		t.Run(nodeImage, func(t *testing.T) {
+			testCluster(t, nodeImage)
+		})
+	}
+}
+
+func testCluster(t *testing.T, nodeImage string) {
+	cluster := "kse-e2e-" + strings.NewReplacer(".", "-", ":", "-", "/", "-").Replace(nodeImage[strings.LastIndex(nodeImage, ":")+1:])
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+
+	run(t, "", "kind", "create", "cluster", "--name", cluster, "--image", nodeImage, "--kubeconfig", kubeconfig, "--wait", "5m")
+	t.Cleanup(func() {
+		run(t, "", "kind", "delete", "cluster", "--name", cluster, "--kubeconfig", kubeconfig)
+	})
+
+	kubectl := func(args ...string) string {
+		return run(t, "", "kubectl", append([]string{"--kubeconfig", kubeconfig}, args...)...)
+	}
+
+	run(t, "", "kind", "load", "docker-image", image, "--name", cluster)
+	kubectl("apply", "-f", "manifest.yaml")
+	kubectl("-n", "kube-system", "rollout", "status", "daemonset/kubelet-summary-exporter", "--timeout", "5m")
+
+	pod := strings.TrimSpace(kubectl("-n", "kube-system", "get", "pods", "-l", "app=kubelet-summary-exporter",
+		"-o", "jsonpath={.items[0].metadata.name}"))
+
+	// Kubelet needs a moment after startup before every section of the
+	// summary is populated.
+	var missing []string
+	for deadline := time.Now().Add(3 * time.Minute); ; time.Sleep(10 * time.Second) {
+		out := kubectl("get", "--raw", fmt.Sprintf("/api/v1/namespaces/kube-system/pods/%s:9091/proxy/metrics", pod))
+
+		var parser expfmt.TextParser
+		got, err := parser.TextToMetricFamilies(strings.NewReader(out))
+		if err != nil {
+			t.Fatalf("failed to parse metrics: %v", err)
+		}
+
+		missing = missingFamilies(got)
+		if len(missing) == 0 || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	for _, name := range missing {
+		t.Errorf("metric family %s is missing or has the wrong type", name)
+	}
+}
+
+// missingFamilies returns the expected families absent from got or exposed
+// with another type.
+func missingFamilies(got map[string]*dto.MetricFamily) []string {
+	var missing []string
+	for name, typ := range families {
+		family, ok := got[name]
+		if !ok || family.GetType() != typ || len(family.GetMetric()) == 0 {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func run(t *testing.T, dir, name string, args ...string) string {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("%s %s: %v\n%s", name, strings.Join(args, " "), err, stderr.String())
+	}
+
+	return stdout.String()
+}